@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"myapi/config"
+	"myapi/migrations"
+	"myapi/services"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// runMigrate executa o subcomando `migrate up|down [n]|status`.
+//
+// Os argumentos após a ação são repassados para config.Load, permitindo usar as mesmas
+// flags de configuração do servidor (por exemplo, `migrate up -db-dsn ...`).
+//
+// Exemplo de uso:
+//
+//	go run . migrate up
+//	go run . migrate down 1
+//	go run . migrate status
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("uso: migrate up|down [n]|status [flags]")
+	}
+	action, args := args[0], args[1:]
+
+	// Número de migrações a reverter no `down` (padrão: 1)
+	steps := 1
+	if action == "down" && len(args) > 0 {
+		if n, err := strconv.Atoi(args[0]); err == nil {
+			steps, args = n, args[1:]
+		}
+	}
+
+	settings, err := config.Load(args)
+	if err != nil {
+		return err
+	}
+	if settings.Database.Backend == config.BackendMemory {
+		return fmt.Errorf("o backend %q não utiliza migrações", settings.Database.Backend)
+	}
+
+	db, err := config.OpenDB(settings.Database)
+	if err != nil {
+		return err
+	}
+	migrator, err := migrations.NewMigrator(db, settings.Database.Backend)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "up":
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			fmt.Printf("aplicada  %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("Nenhuma migração pendente.")
+		}
+	case "down":
+		reverted, err := migrator.Down(steps)
+		for _, m := range reverted {
+			fmt.Printf("revertida %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("Nenhuma migração aplicada para reverter.")
+		}
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSÃO\tNOME\tSTATUS\tAPLICADA EM")
+		for _, s := range statuses {
+			status, appliedAt := "pendente", "-"
+			if s.Applied {
+				status, appliedAt = "aplicada", s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Migration.Version, s.Migration.Name, status, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("ação de migração desconhecida: %q (use up, down ou status)", action)
+	}
+	return nil
+}
+
+// runPurge executa o subcomando `purge [-dry-run]`, que remove os clientes arquivados há mais dias
+// que o configurado em `retention.archived_days` (ou na flag `-retention-days`).
+//
+// Com `-dry-run`, nada é removido e apenas a quantidade de clientes que seriam removidos é exibida.
+// Os demais argumentos são repassados para config.Load.
+//
+// Exemplo de uso:
+//
+//	go run . purge -retention-days 90 -dry-run
+//	go run . purge -retention-days 90
+func runPurge(args []string) error {
+	// Separa a flag -dry-run das flags de configuração
+	dryRun := false
+	configArgs := make([]string, 0, len(args))
+	for _, arg := range args {
+		switch arg {
+		case "-dry-run", "--dry-run":
+			dryRun = true
+		default:
+			configArgs = append(configArgs, arg)
+		}
+	}
+
+	settings, err := config.Load(configArgs)
+	if err != nil {
+		return err
+	}
+	if !settings.Retention.Enabled() {
+		return fmt.Errorf("informe o período de retenção com retention.archived_days ou -retention-days")
+	}
+
+	repo, err := config.NewRepository(settings.Database)
+	if err != nil {
+		return err
+	}
+
+	result, err := services.PurgeArchivedClients(repo, settings.Retention.ArchivedDays, time.Now(), dryRun)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Printf("%d clientes arquivados antes de %s seriam removidos (dry-run).\n",
+			result.Count(), result.Cutoff.Format("2006-01-02 15:04:05"))
+	} else {
+		fmt.Printf("%d clientes arquivados antes de %s foram removidos.\n",
+			result.Count(), result.Cutoff.Format("2006-01-02 15:04:05"))
+	}
+	return nil
+}
@@ -2,45 +2,105 @@ package config
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"myapi/migrations"
 	"myapi/models"
 
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 var DB *gorm.DB
 
-// ConnectDB realiza a conexão com o banco de dados MySQL e configura a migração automática do modelo `Client`.
+// dialectors associa cada backend SQL suportado ao construtor do driver GORM correspondente.
+// Para suportar um novo banco de dados, basta registrar o driver aqui e adicionar as migrações do dialeto.
+var dialectors = map[string]func(dsn string) gorm.Dialector{
+	BackendMySQL:    mysql.Open,
+	BackendPostgres: postgres.Open,
+	BackendSQLite:   sqlite.Open,
+}
+
+// isSQLBackend informa se o backend é atendido por um driver SQL (e, portanto, pelas migrações).
+func isSQLBackend(backend string) bool {
+	_, ok := dialectors[backend]
+	return ok
+}
+
+// OpenDB abre a conexão com o banco de dados configurado (MySQL, PostgreSQL ou SQLite), sem alterar o schema.
+// É utilizada pelo servidor e pelo subcomando `migrate`.
+func OpenDB(settings DatabaseSettings) (*gorm.DB, error) {
+	open, ok := dialectors[settings.Backend]
+	if !ok {
+		return nil, fmt.Errorf("backend %q não é um banco de dados SQL", settings.Backend)
+	}
+
+	db, err := gorm.Open(open(settings.DSN), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao conectar ao banco de dados: %w", err)
+	}
+
+	if settings.Backend == BackendSQLite {
+		// O SQLite não suporta escritas concorrentes; uma única conexão também mantém bancos `:memory:` consistentes.
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, fmt.Errorf("erro ao configurar o pool de conexões: %w", err)
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
+	return db, nil
+}
+
+// ConnectDB realiza a conexão com o banco de dados e atribui a conexão à variável global `DB`.
 //
-// Esta função é responsável por estabelecer a conexão com o banco de dados MySQL utilizando as credenciais e
-// configurações definidas na string DSN (Data Source Name). Se a conexão for bem-sucedida, ela realiza a migração
-// automática do modelo `Client` para garantir que a tabela esteja atualizada conforme o modelo. Caso contrário,
-// a função registra um erro detalhado e interrompe a execução do programa.
+// Esta função é responsável por estabelecer a conexão com o banco de dados do backend configurado utilizando
+// a string DSN (Data Source Name) recebida na configuração. O schema é mantido pelas migrações versionadas
+// (subcomando `migrate up`); a migração automática do GORM só é executada quando `AutoMigrate` está
+// habilitado na configuração. Caso existam migrações pendentes, um aviso é registrado no log.
 //
 // Exemplo de uso:
 //
-//	config.ConnectDB()
-func ConnectDB() {
-	// String de conexão com o banco de dados MySQL.
-	dsn := "golang:golang@tcp(127.0.0.1:3306)/golang?charset=utf8&parseTime=True&loc=Local"
-
+//	if err := config.ConnectDB(settings.Database); err != nil {
+//		log.Fatalf("Erro ao conectar ao banco de dados: %v", err)
+//	}
+func ConnectDB(settings DatabaseSettings) error {
 	// Tentativa de abrir a conexão com o banco de dados.
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	db, err := OpenDB(settings)
 	if err != nil {
-		// Caso ocorra um erro na conexão, loga o erro e encerra a execução do programa.
-		log.Fatalf("Erro ao conectar ao banco de dados: %v", err)
+		return err
 	}
 
-	// Realiza a migração automática das tabelas `Client` e `ArchivedClient` para o banco de dados.
-	if err := db.AutoMigrate(&models.Client{}, &models.ArchivedClient{}); err != nil {
-		// Caso ocorra um erro durante a migração, loga o erro e encerra a execução do programa.
-		log.Fatalf("Erro ao migrar os modelos: %v", err)
+	if settings.AutoMigrate {
+		// Realiza a migração automática das tabelas `Client`, `ArchivedClient` e `IdempotencyKey` para o banco de dados.
+		if err := db.AutoMigrate(&models.Client{}, &models.ArchivedClient{}, &models.IdempotencyKey{}, &models.GeocodeCacheEntry{}, &models.GeocodingJob{}, &models.GeocodingJobItem{}); err != nil {
+			return fmt.Errorf("erro ao migrar os modelos: %w", err)
+		}
+	} else {
+		warnPendingMigrations(db, settings.Backend)
 	}
 
 	// Atribui a conexão bem-sucedida ao banco de dados à variável global `DB`.
 	DB = db
 
 	// Log de sucesso indicando que a conexão foi bem-sucedida.
-	fmt.Println("Conectado com sucesso ao MySQL!")
+	fmt.Printf("Conectado com sucesso ao banco de dados (%s)!\n", settings.Backend)
+	return nil
+}
+
+// warnPendingMigrations registra um aviso caso existam migrações versionadas ainda não aplicadas.
+func warnPendingMigrations(db *gorm.DB, dialect string) {
+	migrator, err := migrations.NewMigrator(db, dialect)
+	if err != nil {
+		slog.Warn("Não foi possível carregar as migrações", slog.String("error", err.Error()))
+		return
+	}
+	pending, err := migrator.Pending()
+	if err != nil {
+		slog.Warn("Não foi possível verificar as migrações pendentes", slog.String("error", err.Error()))
+		return
+	}
+	if len(pending) > 0 {
+		slog.Warn("Existem migrações pendentes; execute o subcomando `migrate up`", slog.Int("pending", len(pending)))
+	}
 }
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"myapi/geocoding"
+	"myapi/repository"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// Backends de armazenamento suportados pela API.
+const (
+	BackendMySQL    = "mysql"    // Persistência em MySQL via GORM (padrão do servidor)
+	BackendPostgres = "postgres" // Persistência em PostgreSQL/PostGIS via GORM
+	BackendSQLite   = "sqlite"   // Persistência em SQLite via GORM (arquivo local ou `:memory:`)
+	BackendMemory   = "memory"   // Persistência em memória, usada em testes e no modo de desenvolvimento
+)
+
+// NewRepository cria o repositório de entregas para o backend configurado.
+//
+// Para os backends SQL (`mysql`, `postgres` e `sqlite`), a conexão global `DB` é aberta através de
+// ConnectDB com o DSN configurado.
+// Para o backend `memory`, nenhum banco de dados é necessário e os dados (inclusive os
+// clientes arquivados na exclusão) são mantidos apenas durante a execução do processo.
+//
+// Exemplo de uso:
+//
+//	repo, err := config.NewRepository(settings.Database)
+func NewRepository(settings DatabaseSettings) (repository.DeliveryRepository, error) {
+	switch {
+	case isSQLBackend(settings.Backend):
+		if err := ConnectDB(settings); err != nil {
+			return nil, err
+		}
+		repo := repository.NewGormRepository(DB)
+		repo.ArchiveBatchSize = settings.ArchiveBatchSize
+		return repo, nil
+	case settings.Backend == BackendMemory:
+		slog.Info("Utilizando armazenamento em memória", slog.String("backend", settings.Backend))
+		return repository.NewMemoryRepository(), nil
+	default:
+		return nil, fmt.Errorf("backend de armazenamento desconhecido: %q", settings.Backend)
+	}
+}
+
+// NewGeocodingBreaker cria o circuit breaker dos provedores de geocoding, com o limite de falhas e o
+// cooldown configurados.
+func NewGeocodingBreaker(settings GeocodingSettings) *geocoding.Breaker {
+	return geocoding.NewBreaker(settings.BreakerThreshold, settings.BreakerCooldown)
+}
+
+// NewGeocoder cria a cadeia de geocoding: o provedor configurado em `geocoding.provider`, seguido dos
+// provedores de `geocoding.fallback`, consultados quando os anteriores falham, não encontram o endereço ou
+// retornam um resultado com confiança abaixo de `geocoding.min_confidence` (veja geocoding.Chain).
+//
+// As requisições aos provedores usam `geocoding.timeout` em cada tentativa e repetem as falhas temporárias
+// (rede, timeout, 5xx e 429) com backoff exponencial. O circuit breaker é opcional (nil desativa), protege
+// apenas o provedor principal e é informado pelo chamador para que seu estado possa ser exposto no health check.
+//
+// Exemplo de uso:
+//
+//	breaker := config.NewGeocodingBreaker(settings.Geocoding)
+//	geocoder, err := config.NewGeocoder(settings.Geocoding, breaker)
+func NewGeocoder(settings GeocodingSettings, breaker *geocoding.Breaker) (geocoding.Geocoder, error) {
+	policy := retryPolicy(settings)
+
+	primary, err := newGeocodingProvider(settings.Provider, settings.BaseURL, settings, geocoding.NewResilientClient(policy, breaker))
+	if err != nil {
+		return nil, err
+	}
+	providers := []geocoding.ChainProvider{{Name: settings.Provider, Geocoder: primary}}
+	for _, name := range settings.Fallback {
+		// Os provedores de fallback usam o endpoint padrão e não compartilham o circuit breaker do principal
+		fallback, err := newGeocodingProvider(name, defaultGeocodingURLs[name], settings, geocoding.NewResilientClient(policy, nil))
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, geocoding.ChainProvider{Name: name, Geocoder: fallback})
+	}
+	if len(providers) > 1 {
+		slog.Info("Cadeia de provedores de geocoding configurada", slog.String("provedor", settings.Provider),
+			slog.Any("fallback", settings.Fallback), slog.Float64("min_confidence", settings.MinConfidence))
+	}
+	return geocoding.NewChain(settings.MinConfidence, providers...), nil
+}
+
+// retryPolicy retorna a política de novas tentativas das requisições aos provedores externos.
+func retryPolicy(settings GeocodingSettings) geocoding.RetryPolicy {
+	return geocoding.RetryPolicy{
+		MaxRetries: settings.MaxRetries,
+		BaseDelay:  settings.RetryBaseDelay,
+		MaxDelay:   settings.RetryMaxDelay,
+		Timeout:    settings.Timeout,
+	}
+}
+
+// newGeocodingProvider cria um provedor de geocoding pelo nome.
+func newGeocodingProvider(name, baseURL string, settings GeocodingSettings, client *http.Client) (geocoding.Geocoder, error) {
+	switch name {
+	case geocoding.ProviderDistanceMatrix:
+		return geocoding.NewDistanceMatrix(baseURL, settings.APIKey, client), nil
+	case geocoding.ProviderNominatim:
+		return geocoding.NewNominatim(baseURL, settings.UserAgent, client), nil
+	case geocoding.ProviderGazetteer:
+		gazetteer, err := geocoding.LoadGazetteer(settings.GazetteerPath)
+		if err != nil {
+			return nil, err
+		}
+		return gazetteer, nil
+	case geocoding.ProviderFake:
+		slog.Warn("Utilizando o provedor de geocoding local (fake); nenhum endereço será encontrado além dos cadastrados")
+		return geocoding.NewFake(), nil
+	default:
+		return nil, fmt.Errorf("provedor de geocoding desconhecido: %q", name)
+	}
+}
+
+// NewPostalCodeLookup cria o provedor da consulta de CEP configurado em `postal_code.provider`: o ViaCEP,
+// com o timeout e as novas tentativas do geocoding (sem o circuit breaker), o gazetteer do arquivo de
+// `geocoding.gazetteer_path` ou o provedor local.
+//
+// Exemplo de uso:
+//
+//	lookup, err := config.NewPostalCodeLookup(settings.PostalCode, settings.Geocoding)
+func NewPostalCodeLookup(settings PostalCodeSettings, geocodingSettings GeocodingSettings) (geocoding.PostalCodeLookup, error) {
+	switch settings.Provider {
+	case geocoding.ProviderViaCEP:
+		return geocoding.NewViaCEP(settings.BaseURL, geocoding.NewResilientClient(retryPolicy(geocodingSettings), nil)), nil
+	case geocoding.ProviderGazetteer:
+		gazetteer, err := geocoding.LoadGazetteer(geocodingSettings.GazetteerPath)
+		if err != nil {
+			return nil, err
+		}
+		return gazetteer, nil
+	case geocoding.ProviderFake:
+		slog.Warn("Utilizando a consulta de CEP local (fake); nenhum CEP será encontrado")
+		return geocoding.NewFake(), nil
+	default:
+		return nil, fmt.Errorf("provedor de consulta de CEP desconhecido: %q", settings.Provider)
+	}
+}
+
+// sqlDB retorna a conexão dos backends SQL: a conexão global `DB` aberta por NewRepository, ou uma nova
+// conexão aberta com ConnectDB. Para o backend `memory` retorna nil, e um erro para backends desconhecidos.
+func sqlDB(settings DatabaseSettings) (*gorm.DB, error) {
+	switch {
+	case isSQLBackend(settings.Backend):
+		if DB == nil {
+			if err := ConnectDB(settings); err != nil {
+				return nil, err
+			}
+		}
+		return DB, nil
+	case settings.Backend == BackendMemory:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("backend de armazenamento desconhecido: %q", settings.Backend)
+	}
+}
+
+// NewGeocodeCache cria o armazenamento do cache de geocoding na conexão do backend configurado (ou em
+// memória).
+//
+// Exemplo de uso:
+//
+//	cache, err := config.NewGeocodeCache(settings.Database)
+func NewGeocodeCache(settings DatabaseSettings) (repository.GeocodeCache, error) {
+	db, err := sqlDB(settings)
+	if err != nil {
+		return nil, err
+	}
+	if db == nil {
+		return repository.NewMemoryGeocodeCache(), nil
+	}
+	return repository.NewGormGeocodeCache(db), nil
+}
+
+// NewGeocodingJobStore cria o armazenamento dos jobs de geocoding assíncrono na conexão do backend
+// configurado (ou em memória).
+//
+// Exemplo de uso:
+//
+//	jobs, err := config.NewGeocodingJobStore(settings.Database)
+func NewGeocodingJobStore(settings DatabaseSettings) (repository.GeocodingJobStore, error) {
+	db, err := sqlDB(settings)
+	if err != nil {
+		return nil, err
+	}
+	if db == nil {
+		return repository.NewMemoryGeocodingJobStore(), nil
+	}
+	return repository.NewGormGeocodingJobStore(db), nil
+}
+
+// NewIdempotencyStore cria o armazenamento das chaves de idempotência na conexão do backend configurado
+// (ou em memória).
+//
+// Exemplo de uso:
+//
+//	store, err := config.NewIdempotencyStore(settings.Database)
+func NewIdempotencyStore(settings DatabaseSettings) (repository.IdempotencyStore, error) {
+	db, err := sqlDB(settings)
+	if err != nil {
+		return nil, err
+	}
+	if db == nil {
+		return repository.NewMemoryIdempotencyStore(), nil
+	}
+	return repository.NewGormIdempotencyStore(db), nil
+}
@@ -0,0 +1,476 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"myapi/geocoding"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Settings reúne toda a configuração tipada da aplicação.
+//
+// A configuração é montada em camadas, cada uma sobrescrevendo a anterior:
+// 1. Valores padrão (DefaultSettings).
+// 2. Arquivo YAML indicado por `-config` ou pela variável `APP_CONFIG`.
+// 3. Variáveis de ambiente.
+// 4. Flags de linha de comando.
+type Settings struct {
+	Server      ServerSettings      `yaml:"server"`
+	Database    DatabaseSettings    `yaml:"database"`
+	Geocoding   GeocodingSettings   `yaml:"geocoding"`
+	PostalCode  PostalCodeSettings  `yaml:"postal_code"`
+	Retention   RetentionSettings   `yaml:"retention"`
+	Idempotency IdempotencySettings `yaml:"idempotency"`
+}
+
+// ServerSettings contém a configuração do servidor HTTP.
+type ServerSettings struct {
+	Port         int           `yaml:"port"`          // Porta em que o servidor escuta
+	ReadTimeout  time.Duration `yaml:"read_timeout"`  // Tempo máximo para leitura da requisição
+	WriteTimeout time.Duration `yaml:"write_timeout"` // Tempo máximo para escrita da resposta
+}
+
+// DatabaseSettings contém a configuração do armazenamento das entregas.
+type DatabaseSettings struct {
+	Backend     string `yaml:"backend"`      // Backend de armazenamento: "mysql", "postgres", "sqlite" ou "memory"
+	DSN         string `yaml:"dsn"`          // String de conexão do banco de dados
+	AutoMigrate bool   `yaml:"auto_migrate"` // Executa o AutoMigrate do GORM na conexão (desativado por padrão)
+
+	ArchiveBatchSize int `yaml:"archive_batch_size"` // Clientes por lote na exclusão de todos os clientes e na limpeza dos arquivados
+}
+
+// GeocodingSettings contém a configuração do provedor de geocoding.
+type GeocodingSettings struct {
+	Provider  string        `yaml:"provider"`   // Provedor: "distancematrix", "nominatim", "gazetteer" ou "fake"
+	BaseURL   string        `yaml:"base_url"`   // Endpoint do provedor (vazio usa o padrão do provedor)
+	APIKey    string        `yaml:"api_key"`    // Chave de acesso da API (DistanceMatrix)
+	UserAgent string        `yaml:"user_agent"` // User-Agent enviado ao provedor (obrigatório no Nominatim)
+	Timeout   time.Duration `yaml:"timeout"`    // Tempo máximo de cada tentativa de requisição ao provedor
+	CacheTTL  time.Duration `yaml:"cache_ttl"`  // Tempo de vida dos resultados no cache de geocoding (0 desativa o cache)
+
+	Workers         int           `yaml:"workers"`          // Workers do geocoding assíncrono das entregas sem coordenadas
+	RequestInterval time.Duration `yaml:"request_interval"` // Intervalo mínimo entre as consultas dos workers ao provedor
+
+	AutocompleteCacheTTL time.Duration `yaml:"autocomplete_cache_ttl"` // Tempo de vida das sugestões de endereço em memória (0 desativa)
+
+	GazetteerPath string `yaml:"gazetteer_path"` // Arquivo CSV ou GeoJSON do provedor gazetteer (sem acesso à rede)
+
+	Fallback      []string `yaml:"fallback"`       // Provedores consultados, em ordem, quando os anteriores falham ou têm baixa confiança
+	MinConfidence float64  `yaml:"min_confidence"` // Confiança mínima (0 a 1) para aceitar o resultado sem consultar o próximo provedor
+
+	MaxRetries       int           `yaml:"max_retries"`       // Novas tentativas nas falhas de rede, timeouts e respostas 5xx/429 (0 desativa)
+	RetryBaseDelay   time.Duration `yaml:"retry_base_delay"`  // Intervalo base do backoff exponencial entre as tentativas
+	RetryMaxDelay    time.Duration `yaml:"retry_max_delay"`   // Maior intervalo entre duas tentativas
+	BreakerThreshold int           `yaml:"breaker_threshold"` // Falhas seguidas que abrem o circuit breaker
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown"`  // Tempo com o circuito aberto antes da requisição de teste
+}
+
+// PostalCodeSettings contém a configuração da consulta de CEP. As requisições usam o timeout e as novas
+// tentativas de GeocodingSettings; o provedor gazetteer usa o arquivo de `geocoding.gazetteer_path`.
+type PostalCodeSettings struct {
+	Provider   string `yaml:"provider"`    // Provedor: "viacep", "gazetteer" ou "fake"
+	BaseURL    string `yaml:"base_url"`    // Endpoint do ViaCEP (vazio usa o padrão)
+	CrossCheck bool   `yaml:"cross_check"` // Confere o CEP com a cidade e o estado no cadastro de clientes
+}
+
+// RetentionSettings contém a política de retenção dos clientes arquivados.
+type RetentionSettings struct {
+	ArchivedDays int           `yaml:"archived_days"` // Dias que um cliente arquivado é mantido (0 desativa a limpeza)
+	Interval     time.Duration `yaml:"interval"`      // Intervalo entre as execuções da limpeza no servidor
+}
+
+// IdempotencySettings contém a configuração das chaves de idempotência do POST /deliveries.
+type IdempotencySettings struct {
+	TTL time.Duration `yaml:"ttl"` // Janela em que uma Idempotency-Key repetida reproduz a resposta original
+}
+
+// Enabled indica se a limpeza dos clientes arquivados está habilitada.
+func (r RetentionSettings) Enabled() bool {
+	return r.ArchivedDays > 0
+}
+
+// Addr retorna o endereço de escuta do servidor no formato aceito por http.Server.
+func (s ServerSettings) Addr() string {
+	return fmt.Sprintf(":%d", s.Port)
+}
+
+// defaultDSNs contém a string de conexão usada quando nenhum DSN é configurado para o backend,
+// compatível com os serviços do docker-compose do projeto.
+var defaultDSNs = map[string]string{
+	BackendMySQL:    "golang:golang@tcp(127.0.0.1:3306)/golang?charset=utf8&parseTime=True&loc=Local",
+	BackendPostgres: "host=127.0.0.1 port=5432 user=golang password=golang dbname=golang sslmode=disable",
+	BackendSQLite:   "myapi.db",
+}
+
+// defaultGeocodingURLs contém o endpoint usado quando nenhuma `geocoding.base_url` é configurada.
+var defaultGeocodingURLs = map[string]string{
+	geocoding.ProviderDistanceMatrix: geocoding.DistanceMatrixURL,
+	geocoding.ProviderNominatim:      geocoding.NominatimURL,
+}
+
+// DefaultSettings retorna a configuração padrão, compatível com o docker-compose do projeto.
+// O DSN padrão depende do backend escolhido e o endpoint de geocoding, do provedor; ambos são definidos em Load.
+func DefaultSettings() Settings {
+	return Settings{
+		Server: ServerSettings{
+			Port:         8080,
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 30 * time.Second,
+		},
+		Database: DatabaseSettings{
+			Backend:          BackendMySQL,
+			ArchiveBatchSize: 1000,
+		},
+		Geocoding: GeocodingSettings{
+			Provider:  geocoding.ProviderDistanceMatrix,
+			UserAgent: "myapi/1.0",
+			Timeout:   10 * time.Second,
+			CacheTTL:  30 * 24 * time.Hour,
+
+			Workers:         2,
+			RequestInterval: time.Second,
+
+			AutocompleteCacheTTL: time.Minute,
+
+			MinConfidence: 0.5,
+
+			MaxRetries:       2,
+			RetryBaseDelay:   200 * time.Millisecond,
+			RetryMaxDelay:    5 * time.Second,
+			BreakerThreshold: 5,
+			BreakerCooldown:  30 * time.Second,
+		},
+		PostalCode: PostalCodeSettings{
+			Provider: geocoding.ProviderViaCEP,
+		},
+		Retention: RetentionSettings{
+			Interval: 24 * time.Hour,
+		},
+		Idempotency: IdempotencySettings{
+			TTL: 24 * time.Hour,
+		},
+	}
+}
+
+// Load monta a configuração a partir dos valores padrão, do arquivo YAML, das variáveis de ambiente
+// e das flags de linha de comando (nessa ordem de precedência) e a valida.
+//
+// Parâmetros:
+// - args ([]string): Argumentos de linha de comando, sem o nome do programa.
+//
+// Retorno:
+// - Settings: Configuração pronta para uso.
+// - error: Erro de leitura do arquivo, de conversão dos valores ou de validação.
+//
+// Exemplo de uso:
+//
+//	settings, err := config.Load(os.Args[1:])
+func Load(args []string) (Settings, error) {
+	settings := DefaultSettings()
+
+	fs := flag.NewFlagSet("myapi", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("APP_CONFIG"), "arquivo de configuração YAML")
+	port := fs.Int("port", 0, "porta do servidor HTTP")
+	backend := fs.String("db-backend", "", "backend de armazenamento (mysql, postgres, sqlite ou memory)")
+	dsn := fs.String("db-dsn", "", "string de conexão do banco de dados")
+	autoMigrate := fs.Bool("db-auto-migrate", false, "executa o AutoMigrate do GORM ao conectar")
+	geocodingProvider := fs.String("geocoding-provider", "", "provedor de geocoding (distancematrix, nominatim, gazetteer ou fake)")
+	geocodingKey := fs.String("geocoding-key", "", "chave de acesso da API de geocoding")
+	retentionDays := fs.Int("retention-days", 0, "dias de retenção dos clientes arquivados (0 desativa a limpeza)")
+	devMode := fs.Bool("dev", false, "executa a API com armazenamento em memória (modo de desenvolvimento)")
+	if err := fs.Parse(args); err != nil {
+		return Settings{}, err
+	}
+
+	// Camada 2: arquivo de configuração
+	if *configFile != "" {
+		if err := loadFile(*configFile, &settings); err != nil {
+			return Settings{}, err
+		}
+	}
+
+	// Camada 3: variáveis de ambiente
+	if err := applyEnv(&settings); err != nil {
+		return Settings{}, err
+	}
+
+	// Camada 4: flags informadas explicitamente
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "port":
+			settings.Server.Port = *port
+		case "db-backend":
+			settings.Database.Backend = *backend
+		case "db-dsn":
+			settings.Database.DSN = *dsn
+		case "db-auto-migrate":
+			settings.Database.AutoMigrate = *autoMigrate
+		case "geocoding-provider":
+			settings.Geocoding.Provider = *geocodingProvider
+		case "geocoding-key":
+			settings.Geocoding.APIKey = *geocodingKey
+		case "retention-days":
+			settings.Retention.ArchivedDays = *retentionDays
+		case "dev":
+			if *devMode {
+				settings.Database.Backend = BackendMemory
+			}
+		}
+	})
+
+	// DSN padrão do backend escolhido, caso nenhum tenha sido configurado
+	if settings.Database.DSN == "" {
+		settings.Database.DSN = defaultDSNs[settings.Database.Backend]
+	}
+
+	// Endpoint padrão do provedor de geocoding escolhido, caso nenhum tenha sido configurado
+	if settings.Geocoding.BaseURL == "" {
+		settings.Geocoding.BaseURL = defaultGeocodingURLs[settings.Geocoding.Provider]
+	}
+	if settings.PostalCode.BaseURL == "" && settings.PostalCode.Provider == geocoding.ProviderViaCEP {
+		settings.PostalCode.BaseURL = geocoding.ViaCEPURL
+	}
+
+	if err := settings.Validate(); err != nil {
+		return Settings{}, err
+	}
+	return settings, nil
+}
+
+// loadFile lê o arquivo YAML e sobrescreve os valores presentes nele.
+func loadFile(path string, settings *Settings) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir o arquivo de configuração: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(settings); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("erro ao ler o arquivo de configuração %s: %w", path, err)
+	}
+	slog.Info("Arquivo de configuração carregado", slog.String("path", path))
+	return nil
+}
+
+// applyEnv sobrescreve a configuração com as variáveis de ambiente definidas.
+func applyEnv(settings *Settings) error {
+	var errs []error
+
+	envString := func(name string, target *string) {
+		if value, ok := os.LookupEnv(name); ok {
+			*target = value
+		}
+	}
+	envInt := func(name string, target *int) {
+		if value, ok := os.LookupEnv(name); ok {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: valor inteiro inválido %q", name, value))
+				return
+			}
+			*target = parsed
+		}
+	}
+	envBool := func(name string, target *bool) {
+		if value, ok := os.LookupEnv(name); ok {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: valor booleano inválido %q", name, value))
+				return
+			}
+			*target = parsed
+		}
+	}
+	envFloat := func(name string, target *float64) {
+		if value, ok := os.LookupEnv(name); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: valor numérico inválido %q", name, value))
+				return
+			}
+			*target = parsed
+		}
+	}
+	envList := func(name string, target *[]string) {
+		if value, ok := os.LookupEnv(name); ok {
+			*target = nil
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					*target = append(*target, item)
+				}
+			}
+		}
+	}
+	envDuration := func(name string, target *time.Duration) {
+		if value, ok := os.LookupEnv(name); ok {
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: duração inválida %q", name, value))
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	envInt("APP_PORT", &settings.Server.Port)
+	envDuration("SERVER_READ_TIMEOUT", &settings.Server.ReadTimeout)
+	envDuration("SERVER_WRITE_TIMEOUT", &settings.Server.WriteTimeout)
+	envString("DB_BACKEND", &settings.Database.Backend)
+	envString("DB_DSN", &settings.Database.DSN)
+	envBool("DB_AUTO_MIGRATE", &settings.Database.AutoMigrate)
+	envInt("DB_ARCHIVE_BATCH_SIZE", &settings.Database.ArchiveBatchSize)
+	envString("GEOCODING_PROVIDER", &settings.Geocoding.Provider)
+	envString("GEOCODING_BASE_URL", &settings.Geocoding.BaseURL)
+	envString("GEOCODING_API_KEY", &settings.Geocoding.APIKey)
+	envString("GEOCODING_USER_AGENT", &settings.Geocoding.UserAgent)
+	envDuration("GEOCODING_TIMEOUT", &settings.Geocoding.Timeout)
+	envDuration("GEOCODING_CACHE_TTL", &settings.Geocoding.CacheTTL)
+	envInt("GEOCODING_WORKERS", &settings.Geocoding.Workers)
+	envDuration("GEOCODING_REQUEST_INTERVAL", &settings.Geocoding.RequestInterval)
+	envDuration("GEOCODING_AUTOCOMPLETE_CACHE_TTL", &settings.Geocoding.AutocompleteCacheTTL)
+	envString("GEOCODING_GAZETTEER_PATH", &settings.Geocoding.GazetteerPath)
+	envList("GEOCODING_FALLBACK", &settings.Geocoding.Fallback)
+	envFloat("GEOCODING_MIN_CONFIDENCE", &settings.Geocoding.MinConfidence)
+	envInt("GEOCODING_MAX_RETRIES", &settings.Geocoding.MaxRetries)
+	envDuration("GEOCODING_RETRY_BASE_DELAY", &settings.Geocoding.RetryBaseDelay)
+	envDuration("GEOCODING_RETRY_MAX_DELAY", &settings.Geocoding.RetryMaxDelay)
+	envInt("GEOCODING_BREAKER_THRESHOLD", &settings.Geocoding.BreakerThreshold)
+	envDuration("GEOCODING_BREAKER_COOLDOWN", &settings.Geocoding.BreakerCooldown)
+	envString("POSTAL_CODE_PROVIDER", &settings.PostalCode.Provider)
+	envString("POSTAL_CODE_BASE_URL", &settings.PostalCode.BaseURL)
+	envBool("POSTAL_CODE_CROSS_CHECK", &settings.PostalCode.CrossCheck)
+	envInt("RETENTION_ARCHIVED_DAYS", &settings.Retention.ArchivedDays)
+	envDuration("RETENTION_INTERVAL", &settings.Retention.Interval)
+	envDuration("IDEMPOTENCY_TTL", &settings.Idempotency.TTL)
+
+	return errors.Join(errs...)
+}
+
+// Validate verifica toda a configuração e retorna um erro listando cada valor inválido.
+func (s Settings) Validate() error {
+	var errs []error
+
+	if s.Server.Port <= 0 || s.Server.Port > 65535 {
+		errs = append(errs, fmt.Errorf("server.port deve estar entre 1 e 65535, recebido %d", s.Server.Port))
+	}
+	if s.Server.ReadTimeout <= 0 {
+		errs = append(errs, errors.New("server.read_timeout deve ser maior que 0"))
+	}
+	if s.Server.WriteTimeout <= 0 {
+		errs = append(errs, errors.New("server.write_timeout deve ser maior que 0"))
+	}
+
+	switch {
+	case isSQLBackend(s.Database.Backend):
+		if s.Database.DSN == "" {
+			errs = append(errs, fmt.Errorf("database.dsn é obrigatório para o backend %s", s.Database.Backend))
+		}
+	case s.Database.Backend == BackendMemory:
+	default:
+		errs = append(errs, fmt.Errorf("database.backend desconhecido: %q", s.Database.Backend))
+	}
+	if s.Database.ArchiveBatchSize <= 0 {
+		errs = append(errs, errors.New("database.archive_batch_size deve ser maior que 0"))
+	}
+
+	switch s.Geocoding.Provider {
+	case geocoding.ProviderDistanceMatrix, geocoding.ProviderNominatim:
+		if u, err := url.Parse(s.Geocoding.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("geocoding.base_url inválida: %q", s.Geocoding.BaseURL))
+		}
+	case geocoding.ProviderGazetteer, geocoding.ProviderFake:
+	default:
+		errs = append(errs, fmt.Errorf("geocoding.provider desconhecido: %q", s.Geocoding.Provider))
+	}
+	chain := map[string]bool{s.Geocoding.Provider: true}
+	for _, name := range s.Geocoding.Fallback {
+		switch name {
+		case geocoding.ProviderDistanceMatrix, geocoding.ProviderNominatim, geocoding.ProviderGazetteer, geocoding.ProviderFake:
+		default:
+			errs = append(errs, fmt.Errorf("geocoding.fallback: provedor desconhecido %q", name))
+		}
+		if chain[name] {
+			errs = append(errs, fmt.Errorf("geocoding.fallback: provedor %q repetido na cadeia", name))
+		}
+		chain[name] = true
+	}
+	if chain[geocoding.ProviderGazetteer] && s.Geocoding.GazetteerPath == "" {
+		errs = append(errs, errors.New("geocoding.gazetteer_path é obrigatório para o provedor gazetteer"))
+	}
+	if s.Geocoding.MinConfidence < 0 || s.Geocoding.MinConfidence > 1 {
+		errs = append(errs, fmt.Errorf("geocoding.min_confidence deve estar entre 0 e 1, recebido %g", s.Geocoding.MinConfidence))
+	}
+	if s.Geocoding.Timeout <= 0 {
+		errs = append(errs, errors.New("geocoding.timeout deve ser maior que 0"))
+	}
+	if s.Geocoding.CacheTTL < 0 {
+		errs = append(errs, errors.New("geocoding.cache_ttl não pode ser negativo"))
+	}
+	if s.Geocoding.Workers <= 0 {
+		errs = append(errs, fmt.Errorf("geocoding.workers deve ser maior que 0, recebido %d", s.Geocoding.Workers))
+	}
+	if s.Geocoding.RequestInterval <= 0 {
+		errs = append(errs, errors.New("geocoding.request_interval deve ser maior que 0"))
+	}
+	if s.Geocoding.AutocompleteCacheTTL < 0 {
+		errs = append(errs, errors.New("geocoding.autocomplete_cache_ttl não pode ser negativo"))
+	}
+	if s.Geocoding.MaxRetries < 0 {
+		errs = append(errs, fmt.Errorf("geocoding.max_retries não pode ser negativo, recebido %d", s.Geocoding.MaxRetries))
+	}
+	if s.Geocoding.RetryBaseDelay <= 0 || s.Geocoding.RetryMaxDelay < s.Geocoding.RetryBaseDelay {
+		errs = append(errs, errors.New("geocoding.retry_base_delay deve ser maior que 0 e no máximo geocoding.retry_max_delay"))
+	}
+	if s.Geocoding.BreakerThreshold <= 0 {
+		errs = append(errs, fmt.Errorf("geocoding.breaker_threshold deve ser maior que 0, recebido %d", s.Geocoding.BreakerThreshold))
+	}
+	if s.Geocoding.BreakerCooldown <= 0 {
+		errs = append(errs, errors.New("geocoding.breaker_cooldown deve ser maior que 0"))
+	}
+	if chain[geocoding.ProviderDistanceMatrix] && s.Geocoding.APIKey == "" {
+		slog.Warn("geocoding.api_key não configurada; a busca de endereços ficará indisponível")
+	}
+	if chain[geocoding.ProviderNominatim] && s.Geocoding.UserAgent == "" {
+		errs = append(errs, errors.New("geocoding.user_agent é obrigatório para o provedor nominatim"))
+	}
+
+	switch s.PostalCode.Provider {
+	case geocoding.ProviderViaCEP:
+		if u, err := url.Parse(s.PostalCode.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("postal_code.base_url inválida: %q", s.PostalCode.BaseURL))
+		}
+	case geocoding.ProviderGazetteer:
+		if s.Geocoding.GazetteerPath == "" && !chain[geocoding.ProviderGazetteer] {
+			errs = append(errs, errors.New("geocoding.gazetteer_path é obrigatório para a consulta de CEP no gazetteer"))
+		}
+	case geocoding.ProviderFake:
+	default:
+		errs = append(errs, fmt.Errorf("postal_code.provider desconhecido: %q", s.PostalCode.Provider))
+	}
+
+	if s.Retention.ArchivedDays < 0 {
+		errs = append(errs, fmt.Errorf("retention.archived_days não pode ser negativo, recebido %d", s.Retention.ArchivedDays))
+	}
+	if s.Retention.Interval <= 0 {
+		errs = append(errs, errors.New("retention.interval deve ser maior que 0"))
+	}
+	if s.Idempotency.TTL <= 0 {
+		errs = append(errs, errors.New("idempotency.ttl deve ser maior que 0"))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("configuração inválida: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -2,22 +2,71 @@ package controller
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"mime"
 	"myapi/config"
+	"myapi/geocoding"
 	"myapi/handlers"
+	"myapi/i18n"
 	"myapi/models"
+	"myapi/repository"
 	"myapi/services"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
-	"gorm.io/gorm"
 )
 
-type APIController struct{}
+// APIController agrupa os handlers HTTP da API de entregas.
+// O repositório e o provedor de geocoding são injetados na criação, de modo que nenhum handler
+// acessa o banco de dados, o provedor ou a configuração global diretamente.
+type APIController struct {
+	Repo     repository.DeliveryRepository
+	Geocoder geocoding.Geocoder
+
+	// Idempotency armazena as chaves Idempotency-Key do POST /deliveries por IdempotencyTTL. Reservas em
+	// andamento há mais de IdempotencyLease (o tempo máximo de uma requisição) são consideradas abandonadas.
+	Idempotency      repository.IdempotencyStore
+	IdempotencyTTL   time.Duration
+	IdempotencyLease time.Duration
+
+	// GeocodingJobs enfileira o geocoding assíncrono das entregas cadastradas sem coordenadas.
+	GeocodingJobs *services.GeocodingWorker
+
+	// GeocodeCache é o cache de geocoding usado pelas rotas de administração; nil quando o cache está desativado.
+	GeocodeCache *services.CachedGeocoder
+
+	// Autocomplete sugere endereços a partir do histórico de clientes e do provedor de geocoding.
+	Autocomplete *services.Autocompleter
+
+	// GeocodingBreaker é o circuit breaker do provedor de geocoding exposto no health check; nil quando o
+	// provedor não o utiliza.
+	GeocodingBreaker *geocoding.Breaker
+
+	// PostalCodes consulta o endereço dos CEPs; nil quando a consulta de CEP não está configurada. Com
+	// PostalCodeCrossCheck, o CEP de cada cliente criado é conferido com a cidade e o estado.
+	PostalCodes          geocoding.PostalCodeLookup
+	PostalCodeCrossCheck bool
+}
+
+// NewAPIController cria um controlador que utiliza o repositório informado para persistir as entregas
+// e o provedor de geocoding para a busca de endereços (veja config.NewGeocoder).
+// As chaves de idempotência e os jobs de geocoding ficam em memória por padrão; o servidor os substitui
+// pelo armazenamento do banco e inicia os workers do geocoding.
+func NewAPIController(repo repository.DeliveryRepository, geocoder geocoding.Geocoder) *APIController {
+	return &APIController{
+		Repo:             repo,
+		Geocoder:         geocoder,
+		Idempotency:      repository.NewMemoryIdempotencyStore(),
+		IdempotencyTTL:   config.DefaultSettings().Idempotency.TTL,
+		IdempotencyLease: config.DefaultSettings().Server.WriteTimeout,
+		GeocodingJobs:    services.NewGeocodingWorker(repo, repository.NewMemoryGeocodingJobStore(), geocoder, config.DefaultSettings().Geocoding),
+		Autocomplete:     services.NewAutocompleter(repo, geocoder, config.DefaultSettings().Geocoding.AutocompleteCacheTTL),
+	}
+}
 
 // CreateClient lida com a criação de um cliente a partir do corpo da requisição.
 // @Summary Cria um novo cliente
@@ -25,12 +74,14 @@ type APIController struct{}
 // @Description Recebe um JSON contendo os dados de um cliente e insere o registro no sistema.
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Chave que torna a criação idempotente: repetições reproduzem a resposta original"
 // @Param client body models.Client true "Dados do cliente para criação"
 // @Success 200 {object} map[string]interface{} "Cliente criado com sucesso"
-// @Failure 400 {string} string "Requisição inválida: erro no corpo da requisição ou JSON malformado"
-// @Failure 500 {string} string "Erro ao criar cliente no banco de dados"
+// @Failure 400 {object} controller.Problem "Requisição inválida: erro no corpo da requisição ou JSON malformado"
+// @Failure 409 {object} controller.Problem "Requisição com a mesma Idempotency-Key ainda em andamento"
+// @Failure 422 {object} controller.Problem "Campos do cliente inválidos, CEP divergente da cidade e do estado (com a conferência de CEP habilitada) ou Idempotency-Key reutilizada com outro corpo"
+// @Failure 500 {object} controller.Problem "Erro ao criar cliente no banco de dados"
 // @Router /deliveries [post]
-
 func (c *APIController) CreateClient(w http.ResponseWriter, r *http.Request) {
 	slog.Info("Iniciando o processo de criação de cliente", slog.String("endpoint", "CreateClient"))
 
@@ -38,7 +89,7 @@ func (c *APIController) CreateClient(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		slog.Error("Erro ao ler o corpo da requisição", slog.String("error", err.Error()))
-		http.Error(w, "Erro ao ler o corpo da requisição", http.StatusBadRequest)
+		writeError(w, r, requestError(errInvalidRequest, "request.read_body"))
 		return
 	}
 	slog.Info("Corpo da requisição lido com sucesso")
@@ -47,20 +98,35 @@ func (c *APIController) CreateClient(w http.ResponseWriter, r *http.Request) {
 	var client models.Client
 	if err := json.Unmarshal(body, &client); err != nil {
 		slog.Error("Erro ao decodificar JSON para cliente", slog.String("error", err.Error()))
-		http.Error(w, "Formato JSON inválido", http.StatusBadRequest)
+		writeError(w, r, requestError(errInvalidRequest, "request.invalid_json"))
 		return
 	}
 
+	// Confere o CEP com a cidade e o estado, quando habilitado, depois da validação dos campos
+	var checks []func(models.Client) error
+	if c.PostalCodeCrossCheck {
+		checks = append(checks, func(client models.Client) error {
+			return services.CheckPostalCode(r.Context(), c.PostalCodes, client)
+		})
+	}
+
 	// Processa a criação do cliente
-	insertResponse, err := handlers.ProcessClient(client)
+	insertResponse, err := handlers.ProcessClient(c.Repo, client, checks...)
 	if err != nil {
 		slog.Error("Erro ao criar o cliente", slog.String("error", err.Error()))
-		http.Error(w, "Erro ao criar o cliente", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
+	// Entregas sem coordenadas são enfileiradas para o geocoding assíncrono do endereço
+	if id, ok := insertResponse["operationID"].(uint); ok && client.NeedsGeocoding() {
+		if job, ok := c.enqueueGeocoding(id); ok {
+			insertResponse["geocoding_job"] = job
+		}
+	}
+
 	// Responde com sucesso
-	c.respondWithJSON(w, insertResponse)
+	c.respondWithJSON(w, r, insertResponse)
 	slog.Info("Cliente criado e resposta enviada com sucesso", slog.String("client_name", client.Name))
 }
 
@@ -68,139 +134,91 @@ func (c *APIController) CreateClient(w http.ResponseWriter, r *http.Request) {
 // @Summary Busca clientes com paginação e filtros de cidade e ID
 // @Tags deliveries
 // @Description Retorna uma lista de clientes paginada, ou um cliente específico se o ID for fornecido, permitindo definir o limite, offset, cidade e ID.
-// @Param id query int false "ID do cliente para busca específica"
+// @Param id query int false "ID do cliente para busca específica (obsoleto: use GET /deliveries/{id})"
 // @Param limit query int false "Número máximo de clientes por página" default(100)
 // @Param offset query int false "Número de registros a pular antes de começar a listar os clientes" default(0)
 // @Param city query string false "Cidade para filtrar os clientes"
 // @Success 200 {object} map[string]interface{} "Dados da lista de clientes com metadados de paginação"
-// @Failure 500 {string} string "Erro ao buscar clientes"
+// @Failure 500 {object} controller.Problem "Erro ao buscar clientes"
 // @Router /deliveries [get]
-
 func (c *APIController) GetClients(w http.ResponseWriter, r *http.Request) {
-	// Define valores padrão para `limit` e `offset`
-	limit := 100
-	offset := 0
-
 	// Extrai `limit`, `offset`, `city` e `id` dos parâmetros de consulta
-	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
-		limit = l
-		slog.Info("Limit recebido", "limit", limit)
-	} else {
-		slog.Info("Valor default de limit utilizado", "limit", limit)
-	}
-
-	if o, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && o >= 0 {
-		offset = o
-		slog.Info("Offset recebido", "offset", offset)
-	} else {
-		slog.Info("Valor default de offset utilizado", "offset", offset)
-	}
-
-	city := r.URL.Query().Get("city")
-	if city != "" {
-		slog.Info("Filtro de cidade recebido", "city", city)
-	}
-
-	// Extrai o `id` para busca específica de cliente
-	var id int
-	if idParam := r.URL.Query().Get("id"); idParam != "" {
-		if idVal, err := strconv.Atoi(idParam); err == nil && idVal > 0 {
-			id = idVal
-			slog.Info("Filtro de ID recebido", "id", id)
-		} else {
-			slog.Error("ID inválido fornecido", "id", idParam)
-			http.Error(w, "ID inválido fornecido", http.StatusBadRequest)
-			return
-		}
-	}
-
-	// Se um ID foi fornecido, busca apenas o cliente específico
-	if id > 0 {
-		var client models.Client
-		if err := config.DB.First(&client, id).Error; err != nil {
-			http.Error(w, "Cliente não encontrado", http.StatusNotFound)
-			slog.Error("Erro ao buscar cliente específico", "error", err, "id", id)
-			return
-		}
-		slog.Info("Cliente específico encontrado", "client", client)
-
-		// Retorna o cliente encontrado	 como resposta
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(map[string]interface{}{"client": client}); err != nil {
-			slog.Error("Erro ao enviar resposta do cliente específico", slog.String("error", err.Error()))
-			http.Error(w, "Erro ao enviar resposta", http.StatusInternalServerError)
-		}
+	filter, id, ok := parseListQuery(w, r)
+	if !ok {
 		return
 	}
 
-	// Conta o total de clientes no banco de dados com filtro de cidade, se fornecido
-	var total int64
-	dbQuery := config.DB.Model(&models.Client{})
-	if city != "" {
-		dbQuery = dbQuery.Where("city = ?", city)
-	}
-	if err := dbQuery.Count(&total).Error; err != nil {
-		http.Error(w, "Failed to count clients", http.StatusInternalServerError)
-		slog.Error("Erro ao contar clientes", "error", err)
+	// Forma legada (?id=): mantida por compatibilidade, substituída por GET /deliveries/{id}
+	if id > 0 {
+		markDeprecated(w, fmt.Sprintf("/deliveries/%d", id))
+		c.writeClient(w, r, id)
 		return
 	}
-	slog.Info("Total de clientes", "total", total)
 
 	// Busca os clientes com o limit e offset definidos, e aplica filtro de cidade, se fornecido
-	var clients []models.Client
-	dbQuery = config.DB.Limit(limit).Offset(offset)
-	if city != "" {
-		dbQuery = dbQuery.Where("city = ?", city)
-	}
-	if err := dbQuery.Find(&clients).Error; err != nil {
-		http.Error(w, "Failed to fetch clients", http.StatusInternalServerError)
+	clients, total, err := c.Repo.List(filter)
+	if err != nil {
+		writeError(w, r, err)
 		slog.Error("Erro ao buscar clientes", "error", err)
 		return
 	}
+	slog.Info("Total de clientes", "total", total)
 	slog.Info("Clientes encontrados", "num_clients", len(clients))
 
-	// Calcula total de páginas, página atual e próximo offset
-	totalPages := int((total + int64(limit) - 1) / int64(limit)) // Arredonda para cima
-	currentPage := (offset / limit) + 1
-	nextOffset := offset + limit
-
-	// Monta a URL para a próxima página se houver
-	var nextPageURL *string
-	if nextOffset < int(total) {
-		url := fmt.Sprintf("%s?limit=%d&offset=%d", r.URL.Path, limit, nextOffset)
-		if city != "" {
-			url += fmt.Sprintf("&city=%s", city)
-		}
-		nextPageURL = &url
-		slog.Info("URL da próxima página", "nextPageURL", *nextPageURL)
+	// Monta a resposta com os clientes e metadados de paginação
+	response := paginationMetadata(r, filter, total)
+	response["clients"] = clients
+
+	// Envia a resposta
+	c.respondWithJSON(w, r, response)
+	slog.Info("Resposta de clientes enviada com sucesso")
+}
+
+// GetClient lida com a requisição GET para buscar um cliente específico pelo ID.
+// @Summary Busca um cliente pelo ID
+// @Tags deliveries
+// @Description Retorna os dados de um cliente específico.
+// @Param id path int true "ID do cliente"
+// @Success 200 {object} map[string]interface{} "Dados do cliente (o cabeçalho ETag informa a versão)"
+// @Failure 400 {object} controller.Problem "ID inválido"
+// @Failure 404 {object} controller.Problem "Cliente não encontrado"
+// @Failure 500 {object} controller.Problem "Erro ao buscar cliente"
+// @Router /deliveries/{id} [get]
+func (c *APIController) GetClient(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
 	}
+	c.writeClient(w, r, id)
+}
 
-	// Monta a resposta com os clientes e metadados
-	response := map[string]interface{}{
-		"clients":     clients,
-		"total":       total,
-		"totalPages":  totalPages,
-		"currentPage": currentPage,
-		"nextPageURL": nextPageURL,
+// writeClient busca o cliente pelo ID e o envia como resposta, no formato {"client": ...}.
+func (c *APIController) writeClient(w http.ResponseWriter, r *http.Request, id int) {
+	client, err := services.GetClientByID(c.Repo, uint(id))
+	if err != nil {
+		slog.Error("Erro ao buscar cliente específico", "error", err, "id", id)
+		writeError(w, r, err)
+		return
 	}
+	slog.Info("Cliente específico encontrado", "client", client)
 
-	// Envia a resposta
-	c.respondWithJSON(w, response)
-	slog.Info("Resposta de clientes enviada com sucesso")
+	// Retorna o cliente encontrado como resposta, com a versão no ETag
+	w.Header().Set("ETag", etag(client.Version))
+	c.respondWithJSON(w, r, map[string]interface{}{"client": client})
 }
 
-// DeleteClientsHandler lida com a exclusão de clientes.
-// @Summary Excluir clientes
-// @Description Exclui todos os clientes ou um cliente específico pelo ID.
+// DeleteClientsHandler lida com a exclusão de clientes pelos parâmetros de consulta (forma legada).
+// As respostas incluem o cabeçalho `Deprecation`; use DELETE /deliveries/{id} ou DELETE /deliveries/all.
+// @Summary Excluir clientes (obsoleto)
+// @Description Exclui todos os clientes ou um cliente específico pelo ID. Obsoleto: use DELETE /deliveries/{id} ou DELETE /deliveries/all.
 // @Tags deliveries
 // @Param deleteAll query bool false "Excluir todos os clientes (true para excluir todos os clientes)"
 // @Param id query int false "ID do cliente a ser excluído (se deleteAll não for especificado)"
-// @Success 200 {string} string "Mensagem de sucesso"
-// @Failure 400 {string} string "Parâmetros inválidos"
-// @Failure 500 {string} string "Erro interno do servidor"
+// @Success 200 {string} string "Mensagem de sucesso (no deleteAll, o cabeçalho X-Archived-Count informa quantos clientes foram arquivados)"
+// @Failure 400 {object} controller.Problem "Parâmetros inválidos"
+// @Failure 404 {object} controller.Problem "Cliente não encontrado"
+// @Failure 500 {object} controller.Problem "Erro interno do servidor"
 // @Router /deliveries [delete]
-
 func (c *APIController) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 	// Parse os parâmetros da URL
 	queryParams := r.URL.Query()
@@ -209,19 +227,14 @@ func (c *APIController) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Valida se ambos os parâmetros foram fornecidos
 	if deleteAll != "" && idParam != "" {
-		http.Error(w, "Somente um parâmetro pode ser fornecido: 'deleteAll' ou 'id'. Não forneça ambos.", http.StatusBadRequest)
+		writeError(w, r, requestError(errInvalidRequest, "request.delete_params_conflict"))
 		return
 	}
 
 	// Caso deleteAll seja verdadeiro
 	if deleteAll == "true" {
-		err := services.DeleteAllClients()
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("Todos os clientes foram excluídos com sucesso."))
+		markDeprecated(w, "/deliveries/all")
+		c.deleteAll(w, r)
 		return
 	}
 
@@ -229,48 +242,115 @@ func (c *APIController) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 	if idParam != "" {
 		clientID, err := strconv.Atoi(idParam)
 		if err != nil {
-			http.Error(w, "ID inválido: o valor deve ser um número", http.StatusBadRequest)
+			writeError(w, r, fieldError(errInvalidRequest, "id", services.CodeInvalid, "request.invalid_id"))
 			return
 		}
+		markDeprecated(w, fmt.Sprintf("/deliveries/%d", clientID))
+		c.deleteByID(w, r, clientID)
+		return
+	}
 
-		// Converte o ID para uint8 (caso o serviço use uint8)
-		clientIDUint8 := int(clientID)
+	// Caso nenhum parâmetro seja especificado
+	writeError(w, r, requestError(errInvalidRequest, "request.delete_params_missing"))
+}
 
-		err = services.DeleteClientByID(clientIDUint8)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
+// DeleteClient lida com a exclusão (arquivamento) de um cliente específico.
+// @Summary Exclui um cliente
+// @Tags deliveries
+// @Description Arquiva e exclui o cliente com o ID informado. Com If-Match, a exclusão só ocorre se a versão corresponder.
+// @Param id path int true "ID do cliente"
+// @Param If-Match header string false "ETag da versão esperada do cliente"
+// @Success 200 {string} string "Cliente excluído com sucesso"
+// @Failure 400 {object} controller.Problem "ID inválido"
+// @Failure 404 {object} controller.Problem "Cliente não encontrado"
+// @Failure 412 {object} controller.Problem "A versão do cliente não corresponde ao If-Match"
+// @Failure 500 {object} controller.Problem "Erro interno do servidor"
+// @Router /deliveries/{id} [delete]
+func (c *APIController) DeleteClient(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	c.deleteByID(w, r, id)
+}
 
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("Cliente excluído com sucesso."))
+// DeleteAllClients lida com a exclusão (arquivamento) de todos os clientes.
+// Por segurança, a operação exige o cabeçalho `X-Confirm-Delete-All: true`.
+// @Summary Exclui todos os clientes
+// @Tags deliveries
+// @Description Arquiva e exclui todos os clientes. Exige o cabeçalho X-Confirm-Delete-All com o valor true.
+// @Param X-Confirm-Delete-All header string true "Confirmação da exclusão de todos os clientes (true)"
+// @Success 200 {string} string "Mensagem de sucesso (o cabeçalho X-Archived-Count informa quantos clientes foram arquivados)"
+// @Failure 428 {object} controller.Problem "Confirmação ausente"
+// @Failure 500 {object} controller.Problem "Erro interno do servidor"
+// @Router /deliveries/all [delete]
+func (c *APIController) DeleteAllClients(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Confirm-Delete-All") != "true" {
+		slog.Warn("Exclusão de todos os clientes sem confirmação", slog.String("remote_addr", r.RemoteAddr))
+		writeError(w, r, fieldError(errConfirmationRequired, "X-Confirm-Delete-All", services.CodeRequired, "request.confirm_delete_all"))
 		return
 	}
+	c.deleteAll(w, r)
+}
 
-	// Caso nenhum parâmetro seja especificado
-	http.Error(w, "Parâmetros inválidos. Use ?deleteAll=true ou ?id=<ID>", http.StatusBadRequest)
+// deleteAll arquiva todos os clientes e informa a quantidade no cabeçalho X-Archived-Count.
+func (c *APIController) deleteAll(w http.ResponseWriter, r *http.Request) {
+	archived, err := services.DeleteAllClients(c.Repo)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	lang := i18n.FromRequest(r)
+	w.Header().Set("X-Archived-Count", strconv.FormatInt(archived, 10))
+	setContentLanguage(w, lang)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(i18n.T(lang, "clients.deleted_all", archived)))
+}
+
+// deleteByID arquiva o cliente com o ID informado, respeitando o cabeçalho If-Match.
+func (c *APIController) deleteByID(w http.ResponseWriter, r *http.Request, id int) {
+	version, ok := c.ifMatchVersion(r, id)
+	if !ok {
+		preconditionFailed(w, r)
+		return
+	}
+
+	if err := services.DeleteClientByID(c.Repo, id, version); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	lang := i18n.FromRequest(r)
+	setContentLanguage(w, lang)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(i18n.T(lang, "client.deleted")))
 }
 
 // UpdateClient lida com a atualização de um cliente a partir do corpo da requisição.
 // @Summary Atualiza um cliente
 // @Tags deliveries
-// @Description Modifica os dados de um cliente existente.
-// @Param id query int false "ID do cliente para busca específica"
+// @Description Modifica os dados de um cliente existente. A forma com ?id= é obsoleta.
+// @Param id path int true "ID do cliente"
+// @Param If-Match header string false "ETag da versão esperada do cliente"
 // @Param client body models.ClientUpdate true "Cliente para atualizar"
-// @Success 200 {object} map[string]interface{} "Resposta com os dados do cliente atualizado"
-// @Failure 400 {string} string "Corpo da requisição inválido"
-// @Failure 404 {string} string "Cliente não encontrado"
-// @Failure 500 {string} string "Erro ao atualizar cliente"
-// @Router /deliveries [put]
-
+// @Success 200 {object} map[string]interface{} "Resposta com os dados do cliente atualizado e, se o endereço mudou sem novas coordenadas, o job de re-geocoding"
+// @Failure 400 {object} controller.Problem "Corpo da requisição inválido"
+// @Failure 404 {object} controller.Problem "Cliente não encontrado"
+// @Failure 412 {object} controller.Problem "A versão do cliente não corresponde ao If-Match"
+// @Failure 422 {object} controller.Problem "Campos do cliente inválidos"
+// @Failure 500 {object} controller.Problem "Erro ao atualizar cliente"
+// @Router /deliveries/{id} [put]
 func (c *APIController) UpdateClient(w http.ResponseWriter, r *http.Request) {
 	slog.Info("Iniciando o processo de atualização de cliente", slog.String("endpoint", "UpdateClient"))
 
-	// Obtém o ID do cliente dos parâmetros da URL
-	idParam := r.URL.Query().Get("id")
+	// Obtém o ID do cliente do caminho ou, na forma legada, do parâmetro ?id=
+	idParam, fromPath := mux.Vars(r)["id"]
+	if !fromPath {
+		idParam = r.URL.Query().Get("id")
+	}
 	if idParam == "" {
 		slog.Error("ID do cliente não fornecido na URL")
-		http.Error(w, "ID do cliente não fornecido", http.StatusBadRequest)
+		writeError(w, r, fieldError(errInvalidRequest, "id", services.CodeRequired, "request.id_required"))
 		return
 	}
 
@@ -278,29 +358,25 @@ func (c *APIController) UpdateClient(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
 		slog.Error("ID do cliente inválido", slog.String("error", err.Error()))
-		http.Error(w, "ID inválido", http.StatusBadRequest)
+		writeError(w, r, fieldError(errInvalidRequest, "id", services.CodeInvalid, "request.invalid_id"))
 		return
 	}
+	if !fromPath {
+		markDeprecated(w, fmt.Sprintf("/deliveries/%d", id))
+	}
 
-	// Busca o cliente no banco de dados para verificar se existe
-	var existingClient models.Client
-	if err := config.DB.First(&existingClient, id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			slog.Error("Cliente não encontrado", slog.Int("id", id))
-			http.Error(w, "Cliente não encontrado", http.StatusNotFound)
-			return
-		}
-		slog.Error("Erro ao buscar cliente", slog.String("error", err.Error()))
-		http.Error(w, "Erro interno ao buscar cliente", http.StatusInternalServerError)
+	// Versão esperada do cliente (If-Match)
+	version, ok := c.ifMatchVersion(r, id)
+	if !ok {
+		preconditionFailed(w, r)
 		return
 	}
-	slog.Info("Cliente encontrado no banco de dados", slog.Int("client_id", id))
 
 	// Lê o corpo da requisição
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		slog.Error("Erro ao ler o corpo da requisição", slog.String("error", err.Error()))
-		http.Error(w, "Erro ao ler o corpo da requisição", http.StatusBadRequest)
+		writeError(w, r, requestError(errInvalidRequest, "request.read_body"))
 		return
 	}
 	slog.Info("Corpo da requisição lido com sucesso")
@@ -309,37 +385,117 @@ func (c *APIController) UpdateClient(w http.ResponseWriter, r *http.Request) {
 	var clientUpdate models.ClientUpdate
 	if err := json.Unmarshal(body, &clientUpdate); err != nil {
 		slog.Error("Erro ao decodificar JSON", slog.String("error", err.Error()))
-		http.Error(w, "Formato JSON inválido", http.StatusBadRequest)
+		writeError(w, r, requestError(errInvalidRequest, "request.invalid_json"))
 		return
 	}
 
 	// Combina o ID do cliente da URL com o payload recebido
 	clientUpdate.ID = uint(id)
 
-	// Processa a atualização do cliente
-	updatedClient, err := handlers.ProcessClientUpdate(clientUpdate)
+	// Processa a atualização condicional do cliente
+	updatedClient, err := handlers.ProcessClientUpdate(c.Repo, clientUpdate, version)
 	if err != nil {
 		slog.Error("Erro ao processar atualização do cliente", slog.String("error", err.Error()))
-		http.Error(w, "Erro ao atualizar o cliente", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
+	if newVersion, ok := updatedClient["version"].(uint); ok {
+		w.Header().Set("ETag", etag(newVersion))
+	}
+
+	// O endereço mudou sem novas coordenadas: o re-geocoding é feito em segundo plano
+	if stale, _ := updatedClient["coordinates_stale"].(bool); stale {
+		if job, ok := c.enqueueGeocoding(uint(id)); ok {
+			updatedClient["geocoding_job"] = job
+		}
+	}
 
 	// Responde com sucesso para o caso de atualização
-	c.respondWithJSON(w, updatedClient)
+	c.respondWithJSON(w, r, updatedClient)
 
 	slog.Info("Cliente atualizado e resposta enviada com sucesso", slog.String("client_name", clientUpdate.Name))
 }
 
+// PatchClient lida com a alteração parcial de um cliente por JSON Merge Patch (RFC 7396) ou JSON Patch (RFC 6902).
+// Diferente do PUT, valores nulos e vazios são respeitados, permitindo limpar campos. O cliente resultante
+// é validado antes de ser salvo.
+// @Summary Altera parcialmente um cliente
+// @Tags deliveries
+// @Description Aplica um JSON Merge Patch (application/merge-patch+json) ou um JSON Patch (application/json-patch+json) ao cliente.
+// @Accept application/merge-patch+json,application/json-patch+json
+// @Produce json
+// @Param id path int true "ID do cliente"
+// @Param If-Match header string false "ETag da versão esperada do cliente"
+// @Param patch body object true "Documento de patch"
+// @Success 200 {object} map[string]interface{} "Resposta com os dados do cliente alterado"
+// @Failure 400 {object} controller.Problem "Patch inválido"
+// @Failure 404 {object} controller.Problem "Cliente não encontrado"
+// @Failure 412 {object} controller.Problem "A versão do cliente não corresponde ao If-Match"
+// @Failure 415 {object} controller.Problem "Tipo de mídia não suportado"
+// @Failure 422 {object} controller.Problem "O cliente resultante é inválido"
+// @Failure 500 {object} controller.Problem "Erro ao alterar cliente"
+// @Router /deliveries/{id} [patch]
+func (c *APIController) PatchClient(w http.ResponseWriter, r *http.Request) {
+	slog.Info("Iniciando o processo de patch de cliente", slog.String("endpoint", "PatchClient"))
+	w.Header().Set("Accept-Patch", services.MergePatchMediaType+", "+services.JSONPatchMediaType)
+
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	// Verifica o tipo de mídia do patch
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != services.MergePatchMediaType && mediaType != services.JSONPatchMediaType) {
+		slog.Error("Tipo de mídia do patch não suportado", slog.String("content_type", r.Header.Get("Content-Type")))
+		writeError(w, r, fieldError(errUnsupportedMediaType, "Content-Type", services.CodeInvalid, "request.unsupported_patch_type"))
+		return
+	}
+
+	// Lê o corpo da requisição
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.Error("Erro ao ler o corpo da requisição", slog.String("error", err.Error()))
+		writeError(w, r, requestError(errInvalidRequest, "request.read_body"))
+		return
+	}
+
+	// Versão esperada do cliente (If-Match)
+	version, ok := c.ifMatchVersion(r, id)
+	if !ok {
+		preconditionFailed(w, r)
+		return
+	}
+
+	client, err := services.PatchClientData(c.Repo, uint(id), version, mediaType, body)
+	if err != nil {
+		slog.Error("Erro ao aplicar patch ao cliente", slog.Int("id", id), slog.String("error", err.Error()))
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(client.Version))
+	response := map[string]interface{}{"client": client}
+	// O endereço mudou sem novas coordenadas (ou elas foram removidas): o geocoding é feito em segundo plano
+	if client.NeedsGeocoding() {
+		if job, ok := c.enqueueGeocoding(client.ID); ok {
+			response["geocoding_job"] = job
+		}
+	}
+	c.respondWithJSON(w, r, response)
+	slog.Info("Patch aplicado e resposta enviada com sucesso", slog.Int("client_id", id))
+}
+
 // SearchAddress lida com a busca de latitude e longitude a partir de um endereço fornecido.
 // @Summary Busca latitude e longitude de um endereço
 // @Tags geocoding
-// @Description Retorna as coordenadas geográficas (latitude e longitude) de um endereço fornecido.
+// @Description Retorna as coordenadas geográficas (latitude e longitude) de um endereço fornecido, com a precisão (`location_type`), a confiança (`confidence`) e o provedor da cadeia de geocoding que encontrou o resultado.
 // @Param endereco query string true "Endereço a ser consultado"
-// @Success 200 {object} map[string]float64 "Resposta com latitude e longitude do endereço"
-// @Failure 400 {string} string "Parâmetro 'endereco' ausente ou inválido"
-// @Failure 500 {string} string "Erro ao consultar a API de geocoding"
+// @Success 200 {object} geocoding.Result "Resposta com latitude, longitude e componentes do endereço"
+// @Failure 400 {object} controller.Problem "Parâmetro 'endereco' ausente ou inválido"
+// @Failure 404 {object} controller.Problem "Nenhum resultado encontrado para o endereço"
+// @Failure 502 {object} controller.Problem "Erro ao consultar a API de geocoding"
 // @Router /deliveries/geoconding/search [get]
-
 func (c *APIController) SearchAddress(w http.ResponseWriter, r *http.Request) {
 	slog.Info("Iniciando o processo de busca de lat e long por endereço", slog.String("endpoint", "Geocoding"))
 
@@ -347,7 +503,7 @@ func (c *APIController) SearchAddress(w http.ResponseWriter, r *http.Request) {
 	endereco := r.URL.Query().Get("endereco")
 	if endereco == "" {
 		slog.Error("Parâmetro 'endereco' não fornecido na URL")
-		http.Error(w, "Parâmetro 'endereco' é obrigatório", http.StatusBadRequest)
+		writeError(w, r, fieldError(errInvalidRequest, "endereco", services.CodeRequired, "request.address_required"))
 		return
 	}
 	slog.Info("Parâmetro 'endereco' recebido", slog.String("endereco", endereco))
@@ -359,17 +515,13 @@ func (c *APIController) SearchAddress(w http.ResponseWriter, r *http.Request) {
 		slog.String("remote_addr", r.RemoteAddr),
 	)
 
-	// Chama a função para obter os dados do endereço
-	slog.Info("Chamando a função de geocoding para obter os dados do endereço")
-	locationData, err := handlers.GetLocationFromAddress(endereco)
+	// Consulta o provedor de geocoding configurado
+	slog.Info("Chamando o provedor de geocoding para obter os dados do endereço")
+	locationData, err := services.GeocodeAddress(r.Context(), c.Geocoder, endereco)
 	if err != nil {
 		slog.Error("Erro ao consultar a API de geocoding", slog.String("error", err.Error()))
-		// Verifica se o erro é do tipo "nenhum resultado encontrado"
-		if err.Error() == "nenhum resultado encontrado na resposta da API" {
-			http.Error(w, "Nenhum resultado encontrado para o endereço fornecido", http.StatusNotFound)
-		} else {
-			http.Error(w, "Erro ao consultar a API de geocoding", http.StatusInternalServerError)
-		}
+		// services.ErrNotFound (endereço sem resultados) resulta em 404 e services.ErrUpstream em 502
+		writeError(w, r, err)
 		return
 	}
 	slog.Info("Geocoding realizado com sucesso", slog.Any("location_data", locationData))
@@ -382,7 +534,6 @@ func (c *APIController) SearchAddress(w http.ResponseWriter, r *http.Request) {
 	slog.Info("Enviando resposta com os dados do endereço em JSON")
 	if err := json.NewEncoder(w).Encode(locationData); err != nil {
 		slog.Error("Erro ao codificar a resposta JSON", slog.String("error", err.Error()))
-		http.Error(w, "Erro ao gerar a resposta JSON", http.StatusInternalServerError)
 		return
 	}
 	slog.Info("Resposta enviada com sucesso", slog.String("status", "200 OK"))
@@ -391,28 +542,26 @@ func (c *APIController) SearchAddress(w http.ResponseWriter, r *http.Request) {
 // respondWithJSON envia uma resposta JSON ao cliente.
 // Esta função é usada internamente para padronizar a resposta da API.
 // @Description Envia resposta JSON ao cliente
-// @Param data body map[string]interface{} true "Dados a serem enviados ao cliente"
-// @Failure 500 {object} map[string]string "Erro ao gerar ou enviar a resposta JSON"
+// @Param data body interface{} true "Dados a serem enviados ao cliente"
+// @Failure 500 {object} controller.Problem "Erro ao gerar a resposta JSON"
 
-func (c *APIController) respondWithJSON(w http.ResponseWriter, data map[string]interface{}) {
+func (c *APIController) respondWithJSON(w http.ResponseWriter, r *http.Request, data interface{}) {
 	slog.Info("Iniciando o envio de resposta JSON", slog.String("endpoint", "respondWithJSON"))
 
-	// Define o tipo de conteúdo da resposta como JSON
-	w.Header().Set("Content-Type", "application/json")
 	// Tenta converter os dados para JSON
 	jsonResponse, err := json.Marshal(data)
 	if err != nil {
 		// Log de erro se ocorrer um problema ao gerar a resposta JSON
 		slog.Error("Erro ao gerar a resposta JSON", slog.String("error", err.Error()))
-		http.Error(w, "Erro ao enviar resposta", http.StatusInternalServerError)
+		writeError(w, r, fmt.Errorf("erro ao gerar a resposta JSON: %w", err))
 		return
 	}
 
-	// Envia a resposta com o JSON gerado
+	// Define o tipo de conteúdo da resposta como JSON e envia a resposta com o JSON gerado
+	w.Header().Set("Content-Type", "application/json")
 	if _, err := w.Write(jsonResponse); err != nil {
-		// Log de erro se falhar ao escrever a resposta
+		// O status já foi enviado; resta apenas registrar a falha
 		slog.Error("Erro ao enviar a resposta JSON", slog.String("error", err.Error()))
-		http.Error(w, "Erro ao enviar resposta", http.StatusInternalServerError)
 		return
 	}
 
@@ -426,20 +575,28 @@ func (c *APIController) RegisterRoutes(r *mux.Router) {
 	slog.Info("Registrando rotas da API", slog.String("endpoint", "RegisterRoutes"))
 
 	// Definindo a rota para criar um novo cliente
-	r.HandleFunc("/deliveries", c.CreateClient).Methods("POST")
+	r.HandleFunc("/deliveries", c.idempotent(c.CreateClient)).Methods("POST")
 	slog.Info("Rota '/deliveries' registrada para POST")
 
 	// Definindo a rota para obter todos os clientes
 	r.HandleFunc("/deliveries", c.GetClients).Methods("GET")
 	slog.Info("Rota '/deliveries' registrada para GET")
 
-	//Definindo a rota para deletar um cliente
-	r.HandleFunc("/deliveries", c.DeleteHandler).Methods("DELETE")
-	slog.Info("Rota '/deliveries' registrada para DELETE")
+	// Definindo as rotas do recurso de um cliente específico
+	r.HandleFunc("/deliveries/{id:[0-9]+}", c.GetClient).Methods("GET")
+	r.HandleFunc("/deliveries/{id:[0-9]+}", c.UpdateClient).Methods("PUT")
+	r.HandleFunc("/deliveries/{id:[0-9]+}", c.PatchClient).Methods("PATCH")
+	r.HandleFunc("/deliveries/{id:[0-9]+}", c.DeleteClient).Methods("DELETE")
+	slog.Info("Rota '/deliveries/{id}' registrada para GET, PUT, PATCH e DELETE")
 
-	// Definindo a rota para atualizar um cliente existente
+	// Definindo a rota protegida para deletar todos os clientes
+	r.HandleFunc("/deliveries/all", c.DeleteAllClients).Methods("DELETE")
+	slog.Info("Rota '/deliveries/all' registrada para DELETE")
+
+	// Formas legadas com ?id= e ?deleteAll=true (respondem com o cabeçalho Deprecation)
+	r.HandleFunc("/deliveries", c.DeleteHandler).Methods("DELETE")
 	r.HandleFunc("/deliveries", c.UpdateClient).Methods("PUT")
-	slog.Info("Rota '/deliveries' registrada para PUT")
+	slog.Info("Rota '/deliveries' registrada para DELETE e PUT (obsoletas)")
 
 	// Definindo a rota para buscar usar o geoconding reverse
 	r.HandleFunc("/deliveries/geoconding/search", c.SearchAddress).Methods("GET")
@@ -449,6 +606,40 @@ func (c *APIController) RegisterRoutes(r *mux.Router) {
 	r.HandleFunc("/deliveries/geoconding/search", c.SearchAddress).Methods("GET")
 	slog.Info("Rota '/deliveries/geoconding/search' registrada para GET")
 
+	// Definindo a rota do geocoding reverso (coordenadas para endereço)
+	r.HandleFunc("/deliveries/geocoding/reverse", c.ReverseGeocode).Methods("GET")
+	slog.Info("Rota '/deliveries/geocoding/reverse' registrada para GET")
+
+	// Definindo a rota das sugestões de endereço (autocomplete)
+	r.HandleFunc("/deliveries/geocoding/autocomplete", c.AutocompleteAddress).Methods("GET")
+	slog.Info("Rota '/deliveries/geocoding/autocomplete' registrada para GET")
+
+	// Definindo a rota da consulta de CEP
+	r.HandleFunc("/deliveries/cep/{cep}", c.LookupPostalCode).Methods("GET")
+	slog.Info("Rota '/deliveries/cep/{cep}' registrada para GET")
+
+	// Definindo as rotas dos jobs de geocoding assíncrono
+	r.HandleFunc("/deliveries/geocoding/jobs", c.CreateGeocodingJob).Methods("POST")
+	r.HandleFunc("/deliveries/geocoding/jobs/{id:[0-9]+}", c.GetGeocodingJob).Methods("GET")
+	r.HandleFunc("/deliveries/geocoding/jobs/{id:[0-9]+}/items", c.GetGeocodingJobItems).Methods("GET")
+	r.HandleFunc("/deliveries/geocoding/jobs/{id:[0-9]+}/retry", c.RetryGeocodingJob).Methods("POST")
+	slog.Info("Rotas '/deliveries/geocoding/jobs' registradas para POST e GET")
+
+	// Definindo as rotas para listar e restaurar clientes arquivados
+	r.HandleFunc("/deliveries/archived", c.GetArchivedClients).Methods("GET")
+	slog.Info("Rota '/deliveries/archived' registrada para GET")
+	r.HandleFunc("/deliveries/archived/{id:[0-9]+}/restore", c.RestoreArchivedClient).Methods("POST")
+	slog.Info("Rota '/deliveries/archived/{id}/restore' registrada para POST")
+
+	// Definindo as rotas de administração do cache de geocoding
+	r.HandleFunc("/admin/geocoding/cache", c.GetGeocodeCacheStats).Methods("GET")
+	r.HandleFunc("/admin/geocoding/cache", c.InvalidateGeocodeCache).Methods("DELETE")
+	slog.Info("Rota '/admin/geocoding/cache' registrada para GET e DELETE")
+
+	// Definindo a rota do health check
+	r.HandleFunc("/health", c.Health).Methods("GET")
+	slog.Info("Rota '/health' registrada para GET")
+
 	// Definindo a rota para deletar um cliente com base no id
 	slog.Info("Todas as rotas da API foram registradas com sucesso")
 }
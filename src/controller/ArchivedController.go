@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"log/slog"
+	"myapi/services"
+	"net/http"
+)
+
+// GetArchivedClients lida com a requisição GET para listar os clientes arquivados.
+// @Summary Lista clientes arquivados com paginação e filtro de cidade
+// @Tags deliveries
+// @Description Retorna os clientes arquivados (excluídos) de forma paginada, com os mesmos filtros da listagem de clientes.
+// @Param limit query int false "Número máximo de clientes por página" default(100)
+// @Param offset query int false "Número de registros a pular antes de começar a listar os clientes" default(0)
+// @Param city query string false "Cidade para filtrar os clientes"
+// @Success 200 {object} map[string]interface{} "Dados da lista de clientes arquivados com metadados de paginação"
+// @Failure 500 {object} controller.Problem "Erro ao buscar clientes arquivados"
+// @Router /deliveries/archived [get]
+func (c *APIController) GetArchivedClients(w http.ResponseWriter, r *http.Request) {
+	filter, _, ok := parseListQuery(w, r)
+	if !ok {
+		return
+	}
+
+	archived, total, err := c.Repo.ListArchived(filter)
+	if err != nil {
+		slog.Error("Erro ao buscar clientes arquivados", "error", err)
+		writeError(w, r, err)
+		return
+	}
+	slog.Info("Clientes arquivados encontrados", "num_clients", len(archived), "total", total)
+
+	response := paginationMetadata(r, filter, total)
+	response["clients"] = archived
+
+	c.respondWithJSON(w, r, response)
+	slog.Info("Resposta de clientes arquivados enviada com sucesso")
+}
+
+// RestoreArchivedClient lida com a restauração de um cliente arquivado.
+// @Summary Restaura um cliente arquivado
+// @Tags deliveries
+// @Description Move o cliente arquivado de volta para a lista de clientes, com o ID e os timestamps originais.
+// @Param id path int true "ID do cliente arquivado"
+// @Success 200 {object} map[string]interface{} "Resposta com os dados do cliente restaurado"
+// @Failure 400 {object} controller.Problem "ID inválido"
+// @Failure 404 {object} controller.Problem "Cliente arquivado não encontrado"
+// @Failure 409 {object} controller.Problem "O ID do cliente já está em uso"
+// @Failure 500 {object} controller.Problem "Erro ao restaurar cliente"
+// @Router /deliveries/archived/{id}/restore [post]
+func (c *APIController) RestoreArchivedClient(w http.ResponseWriter, r *http.Request) {
+	slog.Info("Iniciando a restauração de cliente arquivado", slog.String("endpoint", "RestoreArchivedClient"))
+
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	client, err := services.RestoreArchivedClient(c.Repo, uint(id))
+	if err != nil {
+		slog.Error("Erro ao restaurar cliente arquivado", slog.Int("id", id), slog.String("error", err.Error()))
+		writeError(w, r, err)
+		return
+	}
+
+	c.respondWithJSON(w, r, map[string]interface{}{"client": client})
+	slog.Info("Cliente restaurado e resposta enviada com sucesso", slog.Int("client_id", id))
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"log/slog"
+	"myapi/services"
+	"net/http"
+	"strings"
+)
+
+// GetGeocodeCacheStats lida com a requisição GET das métricas do cache de geocoding.
+// @Summary Métricas do cache de geocoding
+// @Tags admin
+// @Description Retorna a quantidade de acertos (hits) e falhas (misses) do cache desde o início do servidor, a taxa de acerto e as entradas válidas.
+// @Success 200 {object} services.GeocodeCacheStats "Métricas do cache de geocoding"
+// @Failure 404 {object} controller.Problem "Cache de geocoding desativado"
+// @Failure 500 {object} controller.Problem "Erro ao consultar o cache"
+// @Router /admin/geocoding/cache [get]
+func (c *APIController) GetGeocodeCacheStats(w http.ResponseWriter, r *http.Request) {
+	if c.GeocodeCache == nil {
+		writeError(w, r, requestError(services.ErrNotFound, "geocode_cache.disabled"))
+		return
+	}
+
+	stats, err := c.GeocodeCache.Stats()
+	if err != nil {
+		slog.Error("Erro ao consultar as métricas do cache de geocoding", slog.String("error", err.Error()))
+		writeError(w, r, err)
+		return
+	}
+	c.respondWithJSON(w, r, stats)
+}
+
+// InvalidateGeocodeCache lida com a invalidação do cache de geocoding.
+// @Summary Invalida o cache de geocoding
+// @Tags admin
+// @Description Remove do cache o endereço informado em `endereco` (em todos os provedores) ou, sem o parâmetro, todas as entradas. A próxima busca consulta o provedor novamente.
+// @Param endereco query string false "Endereço a remover do cache"
+// @Success 200 {object} map[string]int64 "Quantidade de entradas removidas"
+// @Failure 400 {object} controller.Problem "Parâmetro 'endereco' vazio"
+// @Failure 404 {object} controller.Problem "Cache de geocoding desativado"
+// @Failure 500 {object} controller.Problem "Erro ao invalidar o cache"
+// @Router /admin/geocoding/cache [delete]
+func (c *APIController) InvalidateGeocodeCache(w http.ResponseWriter, r *http.Request) {
+	if c.GeocodeCache == nil {
+		writeError(w, r, requestError(services.ErrNotFound, "geocode_cache.disabled"))
+		return
+	}
+
+	// Um parâmetro presente, mas vazio, não deve invalidar o cache inteiro por engano
+	query := r.URL.Query()
+	address := strings.TrimSpace(query.Get("endereco"))
+	if query.Has("endereco") && address == "" {
+		writeError(w, r, fieldError(errInvalidRequest, "endereco", services.CodeRequired, "request.address_required"))
+		return
+	}
+
+	removed, err := c.GeocodeCache.Invalidate(address)
+	if err != nil {
+		slog.Error("Erro ao invalidar o cache de geocoding", slog.String("error", err.Error()))
+		writeError(w, r, err)
+		return
+	}
+	c.respondWithJSON(w, r, map[string]interface{}{"removed": removed})
+}
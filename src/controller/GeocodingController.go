@@ -0,0 +1,218 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"myapi/geocoding"
+	"myapi/models"
+	"myapi/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// ReverseGeocode lida com o geocoding reverso: das coordenadas de um pin no mapa para o endereço.
+// @Summary Busca o endereço de uma coordenada
+// @Tags geocoding
+// @Description Retorna rua, número, bairro, cidade, estado e país do endereço mais próximo das coordenadas, com os mesmos nomes de campo do cliente, para preencher o formulário ao mover um pin.
+// @Param lat query number true "Latitude (-90 a 90)"
+// @Param lng query number true "Longitude (-180 a 180)"
+// @Success 200 {object} models.ClientAddress "Campos de endereço do cliente"
+// @Failure 404 {object} controller.Problem "Nenhum endereço encontrado nas coordenadas"
+// @Failure 422 {object} controller.Problem "Coordenadas ausentes, inválidas ou fora do intervalo"
+// @Failure 502 {object} controller.Problem "Erro ao consultar o provedor de geocoding"
+// @Router /deliveries/geocoding/reverse [get]
+func (c *APIController) ReverseGeocode(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	latitude, longitude, err := services.ParseCoordinates("lat", query.Get("lat"), "lng", query.Get("lng"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	slog.Info("Iniciando o geocoding reverso", slog.Float64("latitude", latitude), slog.Float64("longitude", longitude))
+
+	address, err := services.ReverseGeocode(r.Context(), c.Geocoder, latitude, longitude)
+	if err != nil {
+		slog.Error("Erro no geocoding reverso", slog.String("error", err.Error()))
+		writeError(w, r, err)
+		return
+	}
+	c.respondWithJSON(w, r, address)
+}
+
+// AutocompleteAddress lida com as sugestões de endereço enquanto o usuário digita.
+// @Summary Sugere endereços a partir do texto digitado
+// @Tags geocoding
+// @Description Retorna sugestões ordenadas: primeiro os endereços já cadastrados em clientes ativos e arquivados (os que começam com o texto antes dos que apenas o contêm), depois as sugestões do provedor de geocoding. As respostas ficam em cache por alguns instantes.
+// @Param q query string true "Texto digitado (mínimo de 3 caracteres)"
+// @Param limit query int false "Número máximo de sugestões (1 a 20)" default(5)
+// @Success 200 {object} map[string]interface{} "Sugestões em `suggestions`, com os campos do cliente e a origem (history ou provider)"
+// @Failure 400 {object} controller.Problem "Parâmetro 'q' curto demais ou 'limit' inválido"
+// @Failure 502 {object} controller.Problem "Erro ao consultar o provedor de geocoding"
+// @Router /deliveries/geocoding/autocomplete [get]
+func (c *APIController) AutocompleteAddress(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := query.Get("q")
+	if len([]rune(geocoding.NormalizeAddress(q))) < services.AutocompleteMinLength {
+		writeError(w, r, fieldError(errInvalidRequest, "q", services.CodeTooShort, "request.autocomplete_too_short", services.AutocompleteMinLength))
+		return
+	}
+
+	limit := services.AutocompleteDefaultLimit
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > services.AutocompleteMaxLimit {
+			writeError(w, r, fieldError(errInvalidRequest, "limit", services.CodeOutOfRange, "request.invalid_limit", services.AutocompleteMaxLimit))
+			return
+		}
+		limit = parsed
+	}
+
+	suggestions, err := c.Autocomplete.Suggest(r.Context(), q, limit)
+	if err != nil {
+		slog.Error("Erro ao buscar sugestões de endereço", slog.String("error", err.Error()))
+		writeError(w, r, err)
+		return
+	}
+	c.respondWithJSON(w, r, map[string]interface{}{"suggestions": suggestions})
+}
+
+// LookupPostalCode lida com a consulta do endereço de um CEP.
+// @Summary Busca o endereço de um CEP
+// @Tags geocoding
+// @Description Retorna rua, bairro, cidade e estado do CEP, com os mesmos nomes de campo do cliente, para preencher o formulário. CEPs gerais de uma cidade não têm rua nem bairro.
+// @Param cep path string true "CEP (00000-000 ou 00000000)"
+// @Success 200 {object} models.PostalCodeAddress "Campos de endereço do CEP"
+// @Failure 404 {object} controller.Problem "CEP não encontrado"
+// @Failure 422 {object} controller.Problem "CEP malformado"
+// @Failure 502 {object} controller.Problem "Erro ao consultar o provedor de CEP"
+// @Router /deliveries/cep/{cep} [get]
+func (c *APIController) LookupPostalCode(w http.ResponseWriter, r *http.Request) {
+	address, err := services.LookupPostalCode(r.Context(), c.PostalCodes, mux.Vars(r)["cep"])
+	if err != nil {
+		slog.Error("Erro na consulta de CEP", slog.String("error", err.Error()))
+		writeError(w, r, err)
+		return
+	}
+	c.respondWithJSON(w, r, address)
+}
+
+// CreateGeocodingJob lida com a criação de um job de geocoding para as entregas sem coordenadas.
+// @Summary Enfileira o geocoding das entregas sem coordenadas
+// @Tags geocoding
+// @Description Cria um job com todas as entregas cadastradas apenas com o endereço (latitude e longitude iguais a 0) ou com coordenadas desatualizadas (coordinates_stale), exceto as que já estão na fila de outro job. Os workers preenchem as coordenadas em segundo plano; acompanhe o job pela URL do cabeçalho Location.
+// @Success 202 {object} models.GeocodingJob "Job criado"
+// @Failure 500 {object} controller.Problem "Erro ao criar o job"
+// @Router /deliveries/geocoding/jobs [post]
+func (c *APIController) CreateGeocodingJob(w http.ResponseWriter, r *http.Request) {
+	job, err := c.GeocodingJobs.EnqueueMissing()
+	if err != nil {
+		slog.Error("Erro ao criar o job de geocoding", slog.String("error", err.Error()))
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/deliveries/geocoding/jobs/%d", job.ID))
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		slog.Error("Erro ao codificar a resposta JSON", slog.String("error", err.Error()))
+	}
+}
+
+// GetGeocodingJob lida com a consulta da situação de um job de geocoding.
+// @Summary Situação de um job de geocoding
+// @Tags geocoding
+// @Description Retorna a situação do job (queued, running, completed ou completed_with_errors) e a quantidade de itens em cada situação.
+// @Param id path int true "ID do job"
+// @Success 200 {object} models.GeocodingJob "Situação do job"
+// @Failure 400 {object} controller.Problem "ID inválido"
+// @Failure 404 {object} controller.Problem "Job não encontrado"
+// @Router /deliveries/geocoding/jobs/{id} [get]
+func (c *APIController) GetGeocodingJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := c.GeocodingJobs.Job(uint(id))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	c.respondWithJSON(w, r, job)
+}
+
+// GetGeocodingJobItems lida com a listagem dos itens de um job de geocoding.
+// @Summary Itens de um job de geocoding
+// @Tags geocoding
+// @Description Lista as entregas do job com a situação, a quantidade de tentativas e o motivo da última falha. Use `status=failed` para ver apenas as falhas.
+// @Param id path int true "ID do job"
+// @Param status query string false "Situação dos itens (pending, running, done ou failed)"
+// @Success 200 {object} map[string]interface{} "Itens do job"
+// @Failure 400 {object} controller.Problem "ID ou situação inválidos"
+// @Failure 404 {object} controller.Problem "Job não encontrado"
+// @Router /deliveries/geocoding/jobs/{id}/items [get]
+func (c *APIController) GetGeocodingJobItems(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", models.GeocodingItemPending, models.GeocodingItemRunning, models.GeocodingItemDone, models.GeocodingItemFailed:
+	default:
+		writeError(w, r, fieldError(errInvalidRequest, "status", services.CodeInvalid, "request.invalid_item_status"))
+		return
+	}
+
+	items, err := c.GeocodingJobs.Items(uint(id), status)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	c.respondWithJSON(w, r, map[string]interface{}{"items": items})
+}
+
+// RetryGeocodingJob lida com o reenfileiramento dos itens de um job de geocoding que falharam.
+// @Summary Reenfileira as falhas de um job de geocoding
+// @Tags geocoding
+// @Description Devolve para a fila os itens do job que falharam (por exemplo, após corrigir o endereço da entrega) e retorna a situação atualizada do job. As entregas que já estão na fila de outro job ou que foram geocodificadas por um job mais novo continuam como falha.
+// @Param id path int true "ID do job"
+// @Success 202 {object} models.GeocodingJob "Situação do job após o reenfileiramento"
+// @Failure 400 {object} controller.Problem "ID inválido"
+// @Failure 404 {object} controller.Problem "Job não encontrado"
+// @Router /deliveries/geocoding/jobs/{id}/retry [post]
+func (c *APIController) RetryGeocodingJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := c.GeocodingJobs.Retry(uint(id))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		slog.Error("Erro ao codificar a resposta JSON", slog.String("error", err.Error()))
+	}
+}
+
+// enqueueGeocoding enfileira o geocoding assíncrono de um cliente sem coordenadas ou com coordenadas
+// desatualizadas. Uma falha ao enfileirar não desfaz a alteração do cliente: ela é registrada no log e o
+// cliente ainda pode ser geocodificado por POST /deliveries/geocoding/jobs.
+func (c *APIController) enqueueGeocoding(clientID uint) (models.GeocodingJob, bool) {
+	job, err := c.GeocodingJobs.Enqueue([]uint{clientID})
+	if err != nil {
+		slog.Error("Erro ao enfileirar o geocoding do cliente", slog.Uint64("client_id", uint64(clientID)), slog.String("error", err.Error()))
+		return models.GeocodingJob{}, false
+	}
+	return job, true
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"myapi/geocoding"
+	"net/http"
+)
+
+// Situações do health check.
+const (
+	healthOK       = "ok"       // Todas as dependências respondem normalmente
+	healthDegraded = "degraded" // A API responde, mas uma dependência está indisponível
+)
+
+// HealthCheck é a situação de uma dependência da API.
+type HealthCheck struct {
+	Status         string                  `json:"status"`                    // ok ou degraded
+	CircuitBreaker *geocoding.BreakerState `json:"circuit_breaker,omitempty"` // Estado do circuit breaker, quando houver
+}
+
+// HealthResponse é a resposta do health check.
+type HealthResponse struct {
+	Status string                 `json:"status"` // ok, ou degraded se alguma dependência estiver indisponível
+	Checks map[string]HealthCheck `json:"checks"` // Situação de cada dependência
+}
+
+// Health lida com a requisição GET do health check.
+// @Summary Health check
+// @Tags health
+// @Description Retorna a situação da API e de suas dependências. Com o circuit breaker do provedor de geocoding aberto (ou em teste), o geocoding fica `degraded` e as buscas de endereço falham imediatamente com 503 até o provedor se recuperar; as demais rotas continuam disponíveis, por isso o status HTTP é sempre 200.
+// @Produce json
+// @Success 200 {object} controller.HealthResponse "Situação da API e das dependências"
+// @Router /health [get]
+func (c *APIController) Health(w http.ResponseWriter, r *http.Request) {
+	response := HealthResponse{Status: healthOK, Checks: map[string]HealthCheck{}}
+
+	check := HealthCheck{Status: healthOK}
+	if c.GeocodingBreaker != nil {
+		state := c.GeocodingBreaker.State()
+		check.CircuitBreaker = &state
+		if state.State != geocoding.BreakerClosed {
+			check.Status = healthDegraded
+			response.Status = healthDegraded
+		}
+	}
+	response.Checks["geocoding"] = check
+
+	c.respondWithJSON(w, r, response)
+}
@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"fmt"
+	"myapi/services"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// etag formata a versão do cliente como um ETag forte (por exemplo, `"3"`).
+func etag(version uint) string {
+	return fmt.Sprintf("%q", strconv.FormatUint(uint64(version), 10))
+}
+
+// parseETag extrai a versão de um ETag forte. ETags fracos (`W/"3"`) nunca correspondem,
+// já que o If-Match usa comparação forte.
+func parseETag(tag string) (uint, bool) {
+	unquoted, err := strconv.Unquote(tag)
+	if err != nil || strings.HasPrefix(tag, "W/") {
+		return 0, false
+	}
+	version, err := strconv.ParseUint(unquoted, 10, 64)
+	if err != nil || version == 0 {
+		return 0, false
+	}
+	return uint(version), true
+}
+
+// ifMatchVersion interpreta o cabeçalho If-Match da requisição e retorna a versão esperada do cliente.
+//
+// Retorno:
+//   - uint: Versão esperada, ou 0 quando o cabeçalho está ausente ou é `*` (qualquer versão).
+//   - bool: `false` quando nenhum dos ETags informados pode corresponder à versão atual; nesse caso
+//     a resposta deve ser 412 Precondition Failed.
+func (c *APIController) ifMatchVersion(r *http.Request, id int) (uint, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return 0, true
+	}
+
+	var versions []uint
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return 0, true
+		}
+		if version, ok := parseETag(tag); ok {
+			versions = append(versions, version)
+		}
+	}
+
+	switch len(versions) {
+	case 0:
+		return 0, false
+	case 1:
+		return versions[0], true
+	}
+
+	// Com vários ETags, a versão atual precisa estar entre eles; a alteração continua condicional a ela
+	client, err := c.Repo.FindByID(uint(id))
+	if err != nil {
+		// Deixa a operação reportar o erro (por exemplo, 404)
+		return versions[0], true
+	}
+	if slices.Contains(versions, client.Version) {
+		return client.Version, true
+	}
+	return 0, false
+}
+
+// preconditionFailed envia a resposta 412 para um If-Match que não corresponde à versão do cliente.
+func preconditionFailed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, requestError(services.ErrVersionMismatch, "request.stale_version"))
+}
@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"myapi/models"
+	"myapi/services"
+	"net/http"
+	"time"
+)
+
+// maxIdempotencyKeyLength é o tamanho máximo aceito para o cabeçalho Idempotency-Key.
+const maxIdempotencyKeyLength = 255
+
+// idempotent envolve um handler de criação para aceitar o cabeçalho Idempotency-Key.
+//
+// Na primeira requisição com uma chave, a chave, o hash da requisição e a resposta são armazenados
+// por IdempotencyTTL. Uma repetição com o mesmo corpo recebe a resposta original (com o cabeçalho
+// `Idempotent-Replayed: true`), sem executar o handler novamente. Regras:
+// - Mesma chave com outro corpo: 422 Unprocessable Entity.
+// - Mesma chave enquanto a requisição original ainda está em andamento: 409 Conflict.
+// - Respostas 5xx, pânicos do handler e falhas ao armazenar a resposta liberam a chave, permitindo que o
+// cliente tente novamente com a mesma chave.
+// - Reservas em andamento há mais de IdempotencyLease foram abandonadas e são substituídas.
+// Requisições sem o cabeçalho são processadas normalmente.
+func (c *APIController) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			writeError(w, r, fieldError(errInvalidRequest, "Idempotency-Key", services.CodeTooLong, "request.idempotency_key_too_long", maxIdempotencyKeyLength))
+			return
+		}
+
+		// Lê o corpo para calcular o hash e o devolve à requisição
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			slog.Error("Erro ao ler o corpo da requisição", slog.String("error", err.Error()))
+			writeError(w, r, requestError(errInvalidRequest, "request.read_body"))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		hash := requestHash(r, body)
+
+		now := time.Now()
+		existing, reserved, err := c.Idempotency.Reserve(models.IdempotencyKey{
+			Key:         key,
+			RequestHash: hash,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(c.IdempotencyTTL),
+		}, c.IdempotencyLease)
+		if err != nil {
+			slog.Error("Erro ao reservar a chave de idempotência", slog.String("error", err.Error()))
+			writeError(w, r, err)
+			return
+		}
+
+		if !reserved {
+			switch {
+			case existing.RequestHash != hash:
+				slog.Warn("Idempotency-Key reutilizada com outra requisição", slog.String("idempotency_key", key))
+				writeError(w, r, errIdempotencyKeyReused)
+			case existing.InProgress():
+				slog.Warn("Idempotency-Key em andamento", slog.String("idempotency_key", key))
+				writeError(w, r, errIdempotencyKeyInFlight)
+			default:
+				slog.Info("Reproduzindo a resposta da Idempotency-Key", slog.String("idempotency_key", key))
+				if existing.ContentType != "" {
+					w.Header().Set("Content-Type", existing.ContentType)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(existing.StatusCode)
+				w.Write(existing.Body)
+			}
+			return
+		}
+
+		// Libera a chave se a resposta não for armazenada (erro interno, pânico do handler ou falha do
+		// armazenamento), para que a reserva não fique em andamento até expirar
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := c.Idempotency.Release(key); err != nil {
+				slog.Error("Erro ao liberar a chave de idempotência", slog.String("error", err.Error()))
+			}
+		}()
+
+		// Executa o handler capturando a resposta
+		recorder := &bodyRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next(recorder, r)
+
+		if recorder.statusCode >= http.StatusInternalServerError {
+			return
+		}
+		if err := c.Idempotency.Complete(key, recorder.statusCode, recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			slog.Error("Erro ao armazenar a resposta da chave de idempotência", slog.String("error", err.Error()))
+			return
+		}
+		completed = true
+	}
+}
+
+// requestHash calcula o SHA-256 do método, do caminho e do corpo da requisição.
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// bodyRecorder repassa a resposta ao cliente e guarda uma cópia do status e do corpo.
+type bodyRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+// WriteHeader registra o status da resposta.
+func (rec *bodyRecorder) WriteHeader(statusCode int) {
+	if !rec.wroteHeader {
+		rec.statusCode = statusCode
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write registra o corpo da resposta.
+func (rec *bodyRecorder) Write(data []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(data)
+	return rec.ResponseWriter.Write(data)
+}
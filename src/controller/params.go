@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"fmt"
+	"log/slog"
+	"myapi/repository"
+	"myapi/services"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// parseListQuery extrai os parâmetros `limit`, `offset`, `city` e `id` compartilhados pelas listagens
+// de clientes e de clientes arquivados.
+//
+// Retorno:
+// - repository.ClientFilter: Filtro com os valores recebidos ou os valores padrão (limit 100, offset 0).
+// - int: ID para busca específica (0 quando não informado).
+// - bool: `false` caso o ID seja inválido; nesse caso o problema 400 já foi enviado.
+func parseListQuery(w http.ResponseWriter, r *http.Request) (repository.ClientFilter, int, bool) {
+	// Define valores padrão para `limit` e `offset`
+	filter := repository.ClientFilter{Limit: 100, Offset: 0}
+	query := r.URL.Query()
+
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 {
+		filter.Limit = l
+		slog.Info("Limit recebido", "limit", filter.Limit)
+	} else {
+		slog.Info("Valor default de limit utilizado", "limit", filter.Limit)
+	}
+
+	if o, err := strconv.Atoi(query.Get("offset")); err == nil && o >= 0 {
+		filter.Offset = o
+		slog.Info("Offset recebido", "offset", filter.Offset)
+	} else {
+		slog.Info("Valor default de offset utilizado", "offset", filter.Offset)
+	}
+
+	filter.City = query.Get("city")
+	if filter.City != "" {
+		slog.Info("Filtro de cidade recebido", "city", filter.City)
+	}
+
+	// Extrai o `id` para busca específica de cliente
+	var id int
+	if idParam := query.Get("id"); idParam != "" {
+		if idVal, err := strconv.Atoi(idParam); err == nil && idVal > 0 {
+			id = idVal
+			slog.Info("Filtro de ID recebido", "id", id)
+		} else {
+			slog.Error("ID inválido fornecido", "id", idParam)
+			writeError(w, r, fieldError(errInvalidRequest, "id", services.CodeInvalid, "request.invalid_id"))
+			return filter, 0, false
+		}
+	}
+
+	return filter, id, true
+}
+
+// paginationMetadata calcula o total de páginas, a página atual e a URL da próxima página.
+// O mapa retornado é usado como base da resposta das listagens.
+func paginationMetadata(r *http.Request, filter repository.ClientFilter, total int64) map[string]interface{} {
+	totalPages := int((total + int64(filter.Limit) - 1) / int64(filter.Limit)) // Arredonda para cima
+	currentPage := (filter.Offset / filter.Limit) + 1
+	nextOffset := filter.Offset + filter.Limit
+
+	// Monta a URL para a próxima página se houver
+	var nextPageURL *string
+	if nextOffset < int(total) {
+		next := fmt.Sprintf("%s?limit=%d&offset=%d", r.URL.Path, filter.Limit, nextOffset)
+		if filter.City != "" {
+			next += "&city=" + url.QueryEscape(filter.City)
+		}
+		nextPageURL = &next
+		slog.Info("URL da próxima página", "nextPageURL", *nextPageURL)
+	}
+
+	return map[string]interface{}{
+		"total":       total,
+		"totalPages":  totalPages,
+		"currentPage": currentPage,
+		"nextPageURL": nextPageURL,
+	}
+}
+
+// pathID extrai o parâmetro `{id}` do caminho da requisição.
+// Caso o ID seja inválido, o problema 400 já é enviado e o retorno booleano é `false`.
+func pathID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	idParam := mux.Vars(r)["id"]
+	id, err := strconv.Atoi(idParam)
+	if err != nil || id <= 0 {
+		slog.Error("ID inválido no caminho da requisição", slog.String("id", idParam))
+		writeError(w, r, fieldError(errInvalidRequest, "id", services.CodeInvalid, "request.invalid_id"))
+		return 0, false
+	}
+	return id, true
+}
+
+// markDeprecated sinaliza que a forma da requisição está obsoleta, com os cabeçalhos `Deprecation`
+// e `Link` apontando para a rota que a substitui.
+func markDeprecated(w http.ResponseWriter, successor string) {
+	w.Header().Set("Deprecation", "true")
+	w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+	slog.Warn("Forma de requisição obsoleta utilizada", slog.String("successor", successor))
+}
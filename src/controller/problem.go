@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"myapi/i18n"
+	"myapi/services"
+	"net/http"
+	"strings"
+)
+
+// ProblemMediaType é o tipo de mídia dos documentos de problema (RFC 7807).
+const ProblemMediaType = "application/problem+json"
+
+// Erros próprios da camada HTTP, traduzidos por writeError como os erros dos serviços.
+var (
+	errInvalidRequest         = errors.New("requisição inválida")
+	errUnsupportedMediaType   = errors.New("tipo de mídia não suportado")
+	errConfirmationRequired   = errors.New("confirmação obrigatória")
+	errIdempotencyKeyReused   = errors.New("Idempotency-Key já utilizada com um corpo de requisição diferente")
+	errIdempotencyKeyInFlight = errors.New("a requisição original com esta Idempotency-Key ainda está em andamento")
+)
+
+// Problem é o corpo de todas as respostas de erro da API: um documento de problema (RFC 7807) com um
+// código de erro estável e o ID da requisição.
+type Problem struct {
+	Type      string                `json:"type"`             // URI que identifica o tipo do problema (derivado de Code)
+	Title     string                `json:"title"`            // Resumo do tipo do problema
+	Status    int                   `json:"status"`           // Código de status HTTP
+	Code      string                `json:"code"`             // Código de erro estável, para consumo por máquinas
+	Detail    string                `json:"detail,omitempty"` // Explicação específica desta ocorrência
+	Instance  string                `json:"instance"`         // Caminho da requisição que originou o problema
+	RequestID string                `json:"request_id"`       // ID da requisição (cabeçalho X-Request-ID)
+	Errors    []services.FieldError `json:"errors,omitempty"` // Campos inválidos, com caminho, código e mensagem
+}
+
+// errorMapping associa um erro sentinela ao status HTTP e ao código de erro da resposta. O título e o detalhe
+// genérico de cada código ficam no catálogo de mensagens (`problem.<código>.title` e `problem.<código>.detail`).
+type errorMapping struct {
+	target error
+	status int
+	code   string
+}
+
+// errorMappings é a tabela de tradução dos erros em respostas HTTP. A primeira entrada que corresponder
+// ao erro (errors.Is) é usada; erros sem correspondência resultam em 500 `internal_error`.
+var errorMappings = []errorMapping{
+	{errInvalidRequest, http.StatusBadRequest, "invalid_request"},
+	{services.ErrInvalidPatch, http.StatusBadRequest, "invalid_patch"},
+	{errUnsupportedMediaType, http.StatusUnsupportedMediaType, "unsupported_media_type"},
+	{errConfirmationRequired, http.StatusPreconditionRequired, "confirmation_required"},
+	{errIdempotencyKeyReused, http.StatusUnprocessableEntity, "idempotency_key_reused"},
+	{errIdempotencyKeyInFlight, http.StatusConflict, "idempotency_key_in_progress"},
+	{services.ErrValidation, http.StatusUnprocessableEntity, "validation_failed"},
+	{services.ErrNotFound, http.StatusNotFound, "not_found"},
+	{services.ErrVersionMismatch, http.StatusPreconditionFailed, "precondition_failed"},
+	{services.ErrConflict, http.StatusConflict, "conflict"},
+	{services.ErrUnavailable, http.StatusServiceUnavailable, "upstream_unavailable"},
+	{services.ErrUpstream, http.StatusBadGateway, "upstream_error"},
+}
+
+// internalErrorMapping é usado para os erros que não correspondem a nenhuma entrada de errorMappings.
+var internalErrorMapping = errorMapping{nil, http.StatusInternalServerError, "internal_error"}
+
+// writeError traduz o erro em um documento de problema e o envia com o Content-Type application/problem+json.
+//
+// O status e o código vêm de errorMappings e o título e as mensagens seguem o idioma do Accept-Language.
+// Nos erros 4xx, `detail` recebe a mensagem do erro: a do catálogo, para os erros criados com requestError
+// e fieldError, ou a própria mensagem dos demais erros (em português, substituída pelo detalhe genérico do
+// código nos outros idiomas). Os campos de um *services.ValidationError envolvido são listados em `errors`.
+// Nos erros 5xx, a mensagem não é enviada ao cliente (apenas registrada no log, com o ID da requisição).
+//
+// Exemplo de uso:
+//
+//	if err != nil {
+//		writeError(w, r, fmt.Errorf("cliente %d: %w", id, err))
+//		return
+//	}
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	mapping := internalErrorMapping
+	for _, candidate := range errorMappings {
+		if errors.Is(err, candidate.target) {
+			mapping = candidate
+			break
+		}
+	}
+
+	lang := i18n.FromRequest(r)
+	problem := Problem{
+		Type:      "/problems/" + strings.ReplaceAll(mapping.code, "_", "-"),
+		Title:     i18n.T(lang, "problem."+mapping.code+".title"),
+		Status:    mapping.status,
+		Code:      mapping.code,
+		Instance:  r.URL.Path,
+		RequestID: requestIDFrom(r),
+	}
+	if mapping.status < http.StatusInternalServerError {
+		var apiErr *apiError
+		switch {
+		case errors.As(err, &apiErr):
+			problem.Detail = i18n.T(lang, apiErr.key, apiErr.args...)
+		case lang == i18n.Default:
+			problem.Detail = err.Error()
+		default:
+			problem.Detail = i18n.T(lang, "problem."+mapping.code+".detail")
+		}
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			problem.Errors = validationErr.Localize(lang)
+		}
+		slog.Warn("Requisição recusada", slog.String("request_id", problem.RequestID),
+			slog.String("code", problem.Code), slog.String("error", err.Error()))
+	} else {
+		slog.Error("Erro ao processar a requisição", slog.String("request_id", problem.RequestID),
+			slog.String("code", problem.Code), slog.String("error", err.Error()))
+	}
+
+	w.Header().Set("Content-Type", ProblemMediaType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	setContentLanguage(w, lang)
+	w.WriteHeader(problem.Status)
+	if encodeErr := json.NewEncoder(w).Encode(problem); encodeErr != nil {
+		slog.Error("Erro ao enviar o documento de problema", slog.String("error", encodeErr.Error()))
+	}
+}
+
+// apiError é um erro da camada HTTP cuja mensagem vem do catálogo (pacote i18n) e é traduzida no documento
+// de problema. Corresponde (errors.Is) ao seu tipo `kind` e, quando criado por fieldError, ao *services.ValidationError.
+type apiError struct {
+	kind   error
+	key    string
+	args   []interface{}
+	fields *services.ValidationError
+}
+
+// Error retorna o tipo do erro seguido da mensagem no idioma padrão.
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s: %s", e.kind, i18n.T(i18n.Default, e.key, e.args...))
+}
+
+// Unwrap expõe o tipo do erro e os campos inválidos para errors.Is e errors.As.
+func (e *apiError) Unwrap() []error {
+	if e.fields == nil {
+		return []error{e.kind}
+	}
+	return []error{e.kind, e.fields}
+}
+
+// requestError cria um erro do tipo `kind` (por exemplo, errInvalidRequest) com a mensagem `key` do catálogo.
+//
+// Exemplo de uso:
+//
+//	writeError(w, r, requestError(errInvalidRequest, "request.invalid_json"))
+func requestError(kind error, key string, args ...interface{}) error {
+	return &apiError{kind: kind, key: key, args: args}
+}
+
+// fieldError cria um erro do tipo `kind` para um parâmetro, cabeçalho ou campo do corpo da requisição,
+// listado em `errors` no documento de problema com a mesma mensagem `key` do catálogo.
+//
+// Exemplo de uso:
+//
+//	writeError(w, r, fieldError(errInvalidRequest, "id", services.CodeInvalid, "request.invalid_id"))
+func fieldError(kind error, field, code, key string, args ...interface{}) error {
+	return &apiError{
+		kind:   kind,
+		key:    key,
+		args:   args,
+		fields: &services.ValidationError{Errors: []services.FieldError{services.NewFieldError(field, code, key, args...)}},
+	}
+}
+
+// setContentLanguage informa o idioma da resposta e que ela varia conforme o Accept-Language.
+func setContentLanguage(w http.ResponseWriter, lang i18n.Lang) {
+	w.Header().Set("Content-Language", string(lang))
+	w.Header().Add("Vary", "Accept-Language")
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader é o cabeçalho com o ID da requisição, aceito na requisição e sempre enviado na resposta.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength é o tamanho máximo aceito para um ID de requisição recebido do cliente.
+const maxRequestIDLength = 128
+
+// requestIDKey é a chave do ID da requisição no contexto.
+type requestIDKey struct{}
+
+// RequestID é o middleware que atribui um ID a cada requisição.
+//
+// O ID recebido no cabeçalho X-Request-ID é reaproveitado (até 128 caracteres); caso contrário um novo ID
+// aleatório é gerado. O ID é devolvido no cabeçalho X-Request-ID da resposta e incluído nos documentos de erro.
+//
+// Exemplo de uso:
+//
+//	r := mux.NewRouter()
+//	r.Use(controller.RequestID)
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// requestIDFrom retorna o ID da requisição atribuído pelo middleware RequestID, ou o cabeçalho
+// X-Request-ID recebido quando o handler é chamado sem o middleware.
+func requestIDFrom(r *http.Request) string {
+	if id, ok := r.Context().Value(requestIDKey{}).(string); ok {
+		return id
+	}
+	return r.Header.Get(RequestIDHeader)
+}
+
+// newRequestID gera um ID de requisição aleatório de 16 bytes em hexadecimal.
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(buf)
+}
@@ -15,6 +15,79 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/geocoding/cache": {
+            "get": {
+                "description": "Retorna a quantidade de acertos (hits) e falhas (misses) do cache desde o início do servidor, a taxa de acerto e as entradas válidas.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Métricas do cache de geocoding",
+                "responses": {
+                    "200": {
+                        "description": "Métricas do cache de geocoding",
+                        "schema": {
+                            "$ref": "#/definitions/services.GeocodeCacheStats"
+                        }
+                    },
+                    "404": {
+                        "description": "Cache de geocoding desativado",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Erro ao consultar o cache",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove do cache o endereço informado em ` + "`" + `endereco` + "`" + ` (em todos os provedores) ou, sem o parâmetro, todas as entradas. A próxima busca consulta o provedor novamente.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Invalida o cache de geocoding",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Endereço a remover do cache",
+                        "name": "endereco",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Quantidade de entradas removidas",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Parâmetro 'endereco' vazio",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Cache de geocoding desativado",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Erro ao invalidar o cache",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    }
+                }
+            }
+        },
         "/deliveries": {
             "get": {
                 "description": "Retorna uma lista de clientes paginada, ou um cliente específico se o ID for fornecido, permitindo definir o limite, offset, cidade e ID.",
@@ -25,7 +98,7 @@ const docTemplate = `{
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID do cliente para busca específica",
+                        "description": "ID do cliente para busca específica (obsoleto: use GET /deliveries/{id})",
                         "name": "id",
                         "in": "query"
                     },
@@ -61,217 +134,1040 @@ const docTemplate = `{
                     "500": {
                         "description": "Erro ao buscar clientes",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     }
                 }
             },
-            "put": {
-                "description": "Modifica os dados de um cliente existente.",
+            "post": {
+                "description": "Recebe um JSON contendo os dados de um cliente e insere o registro no sistema.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
                     "deliveries"
                 ],
-                "summary": "Atualiza um cliente",
+                "summary": "Cria um novo cliente",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID do cliente para busca específica",
-                        "name": "id",
-                        "in": "query"
+                        "type": "string",
+                        "description": "Chave que torna a criação idempotente: repetições reproduzem a resposta original",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     },
                     {
-                        "description": "Cliente para atualizar",
+                        "description": "Dados do cliente para criação",
                         "name": "client",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.ClientUpdate"
+                            "$ref": "#/definitions/models.Client"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Resposta com os dados do cliente atualizado",
+                        "description": "Cliente criado com sucesso",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Corpo da requisição inválido",
+                        "description": "Requisição inválida: erro no corpo da requisição ou JSON malformado",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Cliente não encontrado",
+                    "409": {
+                        "description": "Requisição com a mesma Idempotency-Key ainda em andamento",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Campos do cliente inválidos, CEP divergente da cidade e do estado (com a conferência de CEP habilitada) ou Idempotency-Key reutilizada com outro corpo",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
                     "500": {
-                        "description": "Erro ao atualizar cliente",
+                        "description": "Erro ao criar cliente no banco de dados",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     }
                 }
             },
-            "post": {
-                "description": "Recebe um JSON contendo os dados de um cliente e insere o registro no sistema.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+            "delete": {
+                "description": "Exclui todos os clientes ou um cliente específico pelo ID. Obsoleto: use DELETE /deliveries/{id} ou DELETE /deliveries/all.",
                 "tags": [
                     "deliveries"
                 ],
-                "summary": "Cria um novo cliente",
+                "summary": "Excluir clientes (obsoleto)",
                 "parameters": [
                     {
-                        "description": "Dados do cliente para criação",
-                        "name": "client",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Client"
-                        }
+                        "type": "boolean",
+                        "description": "Excluir todos os clientes (true para excluir todos os clientes)",
+                        "name": "deleteAll",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID do cliente a ser excluído (se deleteAll não for especificado)",
+                        "name": "id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Cliente criado com sucesso",
+                        "description": "Mensagem de sucesso (no deleteAll, o cabeçalho X-Archived-Count informa quantos clientes foram arquivados)",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Requisição inválida: erro no corpo da requisição ou JSON malformado",
+                        "description": "Parâmetros inválidos",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Cliente não encontrado",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
                     "500": {
-                        "description": "Erro ao criar cliente no banco de dados",
+                        "description": "Erro interno do servidor",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/deliveries/all": {
             "delete": {
-                "description": "Exclui todos os clientes ou um cliente específico pelo ID.",
+                "description": "Arquiva e exclui todos os clientes. Exige o cabeçalho X-Confirm-Delete-All com o valor true.",
                 "tags": [
                     "deliveries"
                 ],
-                "summary": "Excluir clientes",
+                "summary": "Exclui todos os clientes",
                 "parameters": [
                     {
-                        "type": "boolean",
-                        "description": "Excluir todos os clientes (true para excluir todos os clientes)",
-                        "name": "deleteAll",
+                        "type": "string",
+                        "description": "Confirmação da exclusão de todos os clientes (true)",
+                        "name": "X-Confirm-Delete-All",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Mensagem de sucesso (o cabeçalho X-Archived-Count informa quantos clientes foram arquivados)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "Confirmação ausente",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Erro interno do servidor",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/deliveries/archived": {
+            "get": {
+                "description": "Retorna os clientes arquivados (excluídos) de forma paginada, com os mesmos filtros da listagem de clientes.",
+                "tags": [
+                    "deliveries"
+                ],
+                "summary": "Lista clientes arquivados com paginação e filtro de cidade",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Número máximo de clientes por página",
+                        "name": "limit",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "ID do cliente a ser excluído (se deleteAll não for especificado)",
-                        "name": "id",
+                        "default": 0,
+                        "description": "Número de registros a pular antes de começar a listar os clientes",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cidade para filtrar os clientes",
+                        "name": "city",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Mensagem de sucesso",
+                        "description": "Dados da lista de clientes arquivados com metadados de paginação",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Erro ao buscar clientes arquivados",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/deliveries/archived/{id}/restore": {
+            "post": {
+                "description": "Move o cliente arquivado de volta para a lista de clientes, com o ID e os timestamps originais.",
+                "tags": [
+                    "deliveries"
+                ],
+                "summary": "Restaura um cliente arquivado",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do cliente arquivado",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Resposta com os dados do cliente restaurado",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Parâmetros inválidos",
+                        "description": "ID inválido",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Cliente arquivado não encontrado",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "O ID do cliente já está em uso",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
                     "500": {
-                        "description": "Erro interno do servidor",
+                        "description": "Erro ao restaurar cliente",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     }
                 }
             }
         },
-        "/deliveries/geoconding/search": {
+        "/deliveries/cep/{cep}": {
             "get": {
-                "description": "Retorna as coordenadas geográficas (latitude e longitude) de um endereço fornecido.",
+                "description": "Retorna rua, bairro, cidade e estado do CEP, com os mesmos nomes de campo do cliente, para preencher o formulário. CEPs gerais de uma cidade não têm rua nem bairro.",
                 "tags": [
                     "geocoding"
                 ],
-                "summary": "Busca latitude e longitude de um endereço",
+                "summary": "Busca o endereço de um CEP",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Endereço a ser consultado",
-                        "name": "endereco",
-                        "in": "query",
+                        "description": "CEP (00000-000 ou 00000000)",
+                        "name": "cep",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Resposta com latitude e longitude do endereço",
+                        "description": "Campos de endereço do CEP",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "number"
-                            }
+                            "$ref": "#/definitions/models.PostalCodeAddress"
                         }
                     },
-                    "400": {
-                        "description": "Parâmetro 'endereco' ausente ou inválido",
+                    "404": {
+                        "description": "CEP não encontrado",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Erro ao consultar a API de geocoding",
+                    "422": {
+                        "description": "CEP malformado",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "502": {
+                        "description": "Erro ao consultar o provedor de CEP",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.Client": {
-            "type": "object",
-            "properties": {
-                "address": {
-                    "description": "Endereço completo",
-                    "type": "string"
-                },
-                "city": {
-                    "description": "Cidade do cliente",
-                    "type": "string"
-                },
-                "complement": {
-                    "description": "Complemento do endereço",
-                    "type": "string"
-                },
-                "country": {
-                    "description": "País do cliente",
-                    "type": "string"
-                },
-                "createdAt": {
-                    "type": "string"
-                },
+        },
+        "/deliveries/geocoding/autocomplete": {
+            "get": {
+                "description": "Retorna sugestões ordenadas: primeiro os endereços já cadastrados em clientes ativos e arquivados (os que começam com o texto antes dos que apenas o contêm), depois as sugestões do provedor de geocoding. As respostas ficam em cache por alguns instantes.",
+                "tags": [
+                    "geocoding"
+                ],
+                "summary": "Sugere endereços a partir do texto digitado",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Texto digitado (mínimo de 3 caracteres)",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": "Número máximo de sugestões (1 a 20)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sugestões em ` + "`" + `suggestions` + "`" + `, com os campos do cliente e a origem (history ou provider)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Parâmetro 'q' curto demais ou 'limit' inválido",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "502": {
+                        "description": "Erro ao consultar o provedor de geocoding",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/deliveries/geocoding/jobs": {
+            "post": {
+                "description": "Cria um job com todas as entregas cadastradas apenas com o endereço (latitude e longitude iguais a 0) ou com coordenadas desatualizadas (coordinates_stale), exceto as que já estão na fila de outro job. Os workers preenchem as coordenadas em segundo plano; acompanhe o job pela URL do cabeçalho Location.",
+                "tags": [
+                    "geocoding"
+                ],
+                "summary": "Enfileira o geocoding das entregas sem coordenadas",
+                "responses": {
+                    "202": {
+                        "description": "Job criado",
+                        "schema": {
+                            "$ref": "#/definitions/models.GeocodingJob"
+                        }
+                    },
+                    "500": {
+                        "description": "Erro ao criar o job",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/deliveries/geocoding/jobs/{id}": {
+            "get": {
+                "description": "Retorna a situação do job (queued, running, completed ou completed_with_errors) e a quantidade de itens em cada situação.",
+                "tags": [
+                    "geocoding"
+                ],
+                "summary": "Situação de um job de geocoding",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do job",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Situação do job",
+                        "schema": {
+                            "$ref": "#/definitions/models.GeocodingJob"
+                        }
+                    },
+                    "400": {
+                        "description": "ID inválido",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Job não encontrado",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/deliveries/geocoding/jobs/{id}/items": {
+            "get": {
+                "description": "Lista as entregas do job com a situação, a quantidade de tentativas e o motivo da última falha. Use ` + "`" + `status=failed` + "`" + ` para ver apenas as falhas.",
+                "tags": [
+                    "geocoding"
+                ],
+                "summary": "Itens de um job de geocoding",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do job",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Situação dos itens (pending, running, done ou failed)",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Itens do job",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "ID ou situação inválidos",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Job não encontrado",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/deliveries/geocoding/jobs/{id}/retry": {
+            "post": {
+                "description": "Devolve para a fila os itens do job que falharam (por exemplo, após corrigir o endereço da entrega) e retorna a situação atualizada do job. As entregas que já estão na fila de outro job ou que foram geocodificadas por um job mais novo continuam como falha.",
+                "tags": [
+                    "geocoding"
+                ],
+                "summary": "Reenfileira as falhas de um job de geocoding",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do job",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Situação do job após o reenfileiramento",
+                        "schema": {
+                            "$ref": "#/definitions/models.GeocodingJob"
+                        }
+                    },
+                    "400": {
+                        "description": "ID inválido",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Job não encontrado",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/deliveries/geocoding/reverse": {
+            "get": {
+                "description": "Retorna rua, número, bairro, cidade, estado e país do endereço mais próximo das coordenadas, com os mesmos nomes de campo do cliente, para preencher o formulário ao mover um pin.",
+                "tags": [
+                    "geocoding"
+                ],
+                "summary": "Busca o endereço de uma coordenada",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "Latitude (-90 a 90)",
+                        "name": "lat",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude (-180 a 180)",
+                        "name": "lng",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Campos de endereço do cliente",
+                        "schema": {
+                            "$ref": "#/definitions/models.ClientAddress"
+                        }
+                    },
+                    "404": {
+                        "description": "Nenhum endereço encontrado nas coordenadas",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Coordenadas ausentes, inválidas ou fora do intervalo",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "502": {
+                        "description": "Erro ao consultar o provedor de geocoding",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/deliveries/geoconding/search": {
+            "get": {
+                "description": "Retorna as coordenadas geográficas (latitude e longitude) de um endereço fornecido, com a precisão (` + "`" + `location_type` + "`" + `), a confiança (` + "`" + `confidence` + "`" + `) e o provedor da cadeia de geocoding que encontrou o resultado.",
+                "tags": [
+                    "geocoding"
+                ],
+                "summary": "Busca latitude e longitude de um endereço",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Endereço a ser consultado",
+                        "name": "endereco",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Resposta com latitude, longitude e componentes do endereço",
+                        "schema": {
+                            "$ref": "#/definitions/geocoding.Result"
+                        }
+                    },
+                    "400": {
+                        "description": "Parâmetro 'endereco' ausente ou inválido",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Nenhum resultado encontrado para o endereço",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "502": {
+                        "description": "Erro ao consultar a API de geocoding",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/deliveries/{id}": {
+            "get": {
+                "description": "Retorna os dados de um cliente específico.",
+                "tags": [
+                    "deliveries"
+                ],
+                "summary": "Busca um cliente pelo ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do cliente",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dados do cliente (o cabeçalho ETag informa a versão)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "ID inválido",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Cliente não encontrado",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Erro ao buscar cliente",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Modifica os dados de um cliente existente. A forma com ?id= é obsoleta.",
+                "tags": [
+                    "deliveries"
+                ],
+                "summary": "Atualiza um cliente",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do cliente",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag da versão esperada do cliente",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Cliente para atualizar",
+                        "name": "client",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ClientUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Resposta com os dados do cliente atualizado e, se o endereço mudou sem novas coordenadas, o job de re-geocoding",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Corpo da requisição inválido",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Cliente não encontrado",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "A versão do cliente não corresponde ao If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Campos do cliente inválidos",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Erro ao atualizar cliente",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Arquiva e exclui o cliente com o ID informado. Com If-Match, a exclusão só ocorre se a versão corresponder.",
+                "tags": [
+                    "deliveries"
+                ],
+                "summary": "Exclui um cliente",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do cliente",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag da versão esperada do cliente",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Cliente excluído com sucesso",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "ID inválido",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Cliente não encontrado",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "A versão do cliente não corresponde ao If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Erro interno do servidor",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Aplica um JSON Merge Patch (application/merge-patch+json) ou um JSON Patch (application/json-patch+json) ao cliente.",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "deliveries"
+                ],
+                "summary": "Altera parcialmente um cliente",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do cliente",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag da versão esperada do cliente",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Documento de patch",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Resposta com os dados do cliente alterado",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Patch inválido",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Cliente não encontrado",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "A versão do cliente não corresponde ao If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Tipo de mídia não suportado",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "O cliente resultante é inválido",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Erro ao alterar cliente",
+                        "schema": {
+                            "$ref": "#/definitions/controller.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Retorna a situação da API e de suas dependências. Com o circuit breaker do provedor de geocoding aberto (ou em teste), o geocoding fica ` + "`" + `degraded` + "`" + ` e as buscas de endereço falham imediatamente com 503 até o provedor se recuperar; as demais rotas continuam disponíveis, por isso o status HTTP é sempre 200.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "Situação da API e das dependências",
+                        "schema": {
+                            "$ref": "#/definitions/controller.HealthResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "controller.HealthCheck": {
+            "type": "object",
+            "properties": {
+                "circuit_breaker": {
+                    "description": "Estado do circuit breaker, quando houver",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/geocoding.BreakerState"
+                        }
+                    ]
+                },
+                "status": {
+                    "description": "ok ou degraded",
+                    "type": "string"
+                }
+            }
+        },
+        "controller.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "description": "Situação de cada dependência",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/controller.HealthCheck"
+                    }
+                },
+                "status": {
+                    "description": "ok, ou degraded se alguma dependência estiver indisponível",
+                    "type": "string"
+                }
+            }
+        },
+        "controller.Problem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Código de erro estável, para consumo por máquinas",
+                    "type": "string"
+                },
+                "detail": {
+                    "description": "Explicação específica desta ocorrência",
+                    "type": "string"
+                },
+                "errors": {
+                    "description": "Campos inválidos, com caminho, código e mensagem",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.FieldError"
+                    }
+                },
+                "instance": {
+                    "description": "Caminho da requisição que originou o problema",
+                    "type": "string"
+                },
+                "request_id": {
+                    "description": "ID da requisição (cabeçalho X-Request-ID)",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Código de status HTTP",
+                    "type": "integer"
+                },
+                "title": {
+                    "description": "Resumo do tipo do problema",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "URI que identifica o tipo do problema (derivado de Code)",
+                    "type": "string"
+                }
+            }
+        },
+        "geocoding.BreakerState": {
+            "type": "object",
+            "properties": {
+                "consecutive_failures": {
+                    "description": "Falhas seguidas desde o último sucesso",
+                    "type": "integer"
+                },
+                "opened_at": {
+                    "description": "Momento em que o circuito abriu",
+                    "type": "string"
+                },
+                "retry_at": {
+                    "description": "A partir de quando uma requisição de teste é permitida",
+                    "type": "string"
+                },
+                "state": {
+                    "description": "closed, open ou half_open",
+                    "type": "string"
+                }
+            }
+        },
+        "geocoding.Result": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "Componentes do endereço (rua, número, cidade, ...)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AddressComponent"
+                    }
+                },
+                "confidence": {
+                    "description": "Confiança no resultado, de 0 a 1",
+                    "type": "number"
+                },
+                "display_name": {
+                    "description": "Endereço formatado pelo provedor",
+                    "type": "string"
+                },
+                "latitude": {
+                    "description": "Latitude do endereço",
+                    "type": "number"
+                },
+                "location_type": {
+                    "description": "Precisão das coordenadas (ROOFTOP, RANGE_INTERPOLATED, GEOMETRIC_CENTER ou APPROXIMATE)",
+                    "type": "string"
+                },
+                "longitude": {
+                    "description": "Longitude do endereço",
+                    "type": "number"
+                },
+                "provider": {
+                    "description": "Provedor da cadeia que encontrou o resultado",
+                    "type": "string"
+                }
+            }
+        },
+        "models.AddressComponent": {
+            "type": "object",
+            "properties": {
+                "long_name": {
+                    "type": "string"
+                },
+                "short_name": {
+                    "type": "string"
+                },
+                "types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.Client": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "Endereço completo",
+                    "type": "string"
+                },
+                "city": {
+                    "description": "Cidade do cliente (indexada para o filtro por cidade)",
+                    "type": "string"
+                },
+                "complement": {
+                    "description": "Complemento do endereço",
+                    "type": "string"
+                },
+                "coordinates_stale": {
+                    "description": "O endereço mudou depois das coordenadas, que aguardam o re-geocoding",
+                    "type": "boolean"
+                },
+                "country": {
+                    "description": "País do cliente",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
                 "deletedAt": {
                     "type": "string"
                 },
+                "geocode_confidence": {
+                    "description": "Confiança do geocoding nas coordenadas, de 0 a 1",
+                    "type": "number"
+                },
                 "id": {
                     "type": "integer"
                 },
@@ -279,6 +1175,10 @@ const docTemplate = `{
                     "description": "Latitude da localização",
                     "type": "number"
                 },
+                "location_type": {
+                    "description": "Precisão das coordenadas (ROOFTOP, APPROXIMATE, ...; vazio quando informadas manualmente)",
+                    "type": "string"
+                },
                 "longitude": {
                     "description": "Longitude da localização",
                     "type": "number"
@@ -295,6 +1195,10 @@ const docTemplate = `{
                     "description": "Número da residência",
                     "type": "integer"
                 },
+                "postal_code": {
+                    "description": "CEP no formato 00000-000 (opcional)",
+                    "type": "string"
+                },
                 "state": {
                     "description": "Estado do cliente",
                     "type": "string"
@@ -306,12 +1210,69 @@ const docTemplate = `{
                 "updatedAt": {
                     "type": "string"
                 },
+                "version": {
+                    "description": "Versão do registro, incrementada a cada alteração (ETag)",
+                    "type": "integer"
+                },
                 "weight_kg": {
                     "description": "Peso do cliente em kg",
                     "type": "number"
                 }
             }
         },
+        "models.ClientAddress": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "Endereço completo formatado pelo provedor",
+                    "type": "string"
+                },
+                "city": {
+                    "description": "Cidade",
+                    "type": "string"
+                },
+                "country": {
+                    "description": "País",
+                    "type": "string"
+                },
+                "geocode_confidence": {
+                    "description": "Confiança do geocoding nas coordenadas, de 0 a 1",
+                    "type": "number"
+                },
+                "latitude": {
+                    "description": "Latitude do endereço encontrado",
+                    "type": "number"
+                },
+                "location_type": {
+                    "description": "Precisão das coordenadas",
+                    "type": "string"
+                },
+                "longitude": {
+                    "description": "Longitude do endereço encontrado",
+                    "type": "number"
+                },
+                "neighborhood": {
+                    "description": "Bairro",
+                    "type": "string"
+                },
+                "number": {
+                    "description": "Número da residência (0 quando o provedor não informa)",
+                    "type": "integer"
+                },
+                "postal_code": {
+                    "description": "CEP (vazio quando o provedor não informa)",
+                    "type": "string"
+                },
+                "state": {
+                    "description": "Estado",
+                    "type": "string"
+                },
+                "street": {
+                    "description": "Nome da rua",
+                    "type": "string"
+                }
+            }
+        },
         "models.ClientUpdate": {
             "type": "object",
             "properties": {
@@ -337,6 +1298,10 @@ const docTemplate = `{
                 "deletedAt": {
                     "type": "string"
                 },
+                "geocode_confidence": {
+                    "description": "Confiança do geocoding nas coordenadas enviadas",
+                    "type": "number"
+                },
                 "id": {
                     "description": "ID do cliente, necessário para a atualização",
                     "type": "integer"
@@ -345,6 +1310,10 @@ const docTemplate = `{
                     "description": "Latitude da localização",
                     "type": "number"
                 },
+                "location_type": {
+                    "description": "Precisão das coordenadas enviadas (vazio quando informadas manualmente)",
+                    "type": "string"
+                },
                 "longitude": {
                     "description": "Longitude da localização",
                     "type": "number"
@@ -361,6 +1330,10 @@ const docTemplate = `{
                     "description": "Número da residência",
                     "type": "integer"
                 },
+                "postal_code": {
+                    "description": "CEP do cliente",
+                    "type": "string"
+                },
                 "state": {
                     "description": "Estado do cliente",
                     "type": "string"
@@ -377,6 +1350,123 @@ const docTemplate = `{
                     "type": "number"
                 }
             }
+        },
+        "models.GeocodingJob": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "items": {
+                    "description": "Quantidade de itens em cada situação",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.GeocodingJobItemCounts"
+                        }
+                    ]
+                },
+                "status": {
+                    "description": "Situação calculada a partir dos itens",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.GeocodingJobItemCounts": {
+            "type": "object",
+            "properties": {
+                "done": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "pending": {
+                    "type": "integer"
+                },
+                "running": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PostalCodeAddress": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "description": "Cidade",
+                    "type": "string"
+                },
+                "country": {
+                    "description": "País",
+                    "type": "string"
+                },
+                "neighborhood": {
+                    "description": "Bairro (vazio nos CEPs gerais de uma cidade)",
+                    "type": "string"
+                },
+                "postal_code": {
+                    "description": "CEP no formato 00000-000",
+                    "type": "string"
+                },
+                "state": {
+                    "description": "Sigla do estado (UF)",
+                    "type": "string"
+                },
+                "street": {
+                    "description": "Logradouro (vazio nos CEPs gerais de uma cidade)",
+                    "type": "string"
+                }
+            }
+        },
+        "services.FieldError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Código do erro (CodeRequired, CodeMustBePositive, ...)",
+                    "type": "string"
+                },
+                "field": {
+                    "description": "Caminho do campo no JSON (por exemplo, \"weight_kg\")",
+                    "type": "string"
+                },
+                "message": {
+                    "description": "Mensagem legível, no idioma padrão (veja Localize)",
+                    "type": "string"
+                }
+            }
+        },
+        "services.GeocodeCacheStats": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "description": "Entradas não expiradas no cache",
+                    "type": "integer"
+                },
+                "hit_ratio": {
+                    "description": "Proporção de consultas respondidas pelo cache (0 a 1)",
+                    "type": "number"
+                },
+                "hits": {
+                    "description": "Consultas respondidas pelo cache",
+                    "type": "integer"
+                },
+                "misses": {
+                    "description": "Consultas encaminhadas ao provedor",
+                    "type": "integer"
+                },
+                "ttl": {
+                    "description": "Tempo de vida de cada entrada",
+                    "type": "string"
+                }
+            }
         }
     }
 }`
@@ -0,0 +1,151 @@
+package geocoding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// Precisão das coordenadas de um resultado, nos mesmos valores de `geometry.location_type` da DistanceMatrix
+// (veja models.Geometry).
+const (
+	LocationRooftop           = "ROOFTOP"            // Endereço exato, com o número
+	LocationRangeInterpolated = "RANGE_INTERPOLATED" // Posição estimada entre dois números conhecidos da rua
+	LocationGeometricCenter   = "GEOMETRIC_CENTER"   // Centro de uma rua ou região
+	LocationApproximate       = "APPROXIMATE"        // Aproximado: bairro, CEP ou cidade
+)
+
+// locationConfidence é a confiança atribuída a cada precisão quando o provedor não informa a sua.
+var locationConfidence = map[string]float64{
+	LocationRooftop:           1,
+	LocationRangeInterpolated: 0.8,
+	LocationGeometricCenter:   0.6,
+	LocationApproximate:       0.3,
+}
+
+// IsLocationType indica se o valor é uma das precisões conhecidas.
+func IsLocationType(value string) bool {
+	_, ok := locationConfidence[value]
+	return ok
+}
+
+// Normalize completa a precisão e a confiança do resultado: sem LocationType, a precisão é deduzida dos
+// componentes do endereço (número, rua ou apenas a região); sem Confidence, é usada a confiança padrão da
+// precisão. A confiança fica sempre entre 0 e 1.
+func Normalize(result Result) Result {
+	if !IsLocationType(result.LocationType) {
+		result.LocationType = inferLocationType(result)
+	}
+	if result.Confidence <= 0 {
+		result.Confidence = locationConfidence[result.LocationType]
+	}
+	result.Confidence = min(result.Confidence, 1)
+	return result
+}
+
+// inferLocationType deduz a precisão do resultado pelos componentes do endereço.
+func inferLocationType(result Result) string {
+	locationType := LocationApproximate
+	for _, component := range result.Address {
+		for _, componentType := range component.Types {
+			switch componentType {
+			case "street_number":
+				return LocationRooftop
+			case "route":
+				locationType = LocationGeometricCenter
+			}
+		}
+	}
+	return locationType
+}
+
+// ChainProvider é um provedor da cadeia de geocoding, identificado pelo nome nos resultados e nos logs.
+type ChainProvider struct {
+	Name     string
+	Geocoder Geocoder
+}
+
+// Chain implementa Geocoder consultando os provedores em ordem.
+//
+// Cada resultado é normalizado (Normalize) e recebe o nome do provedor. A cadeia passa para o próximo
+// provedor quando o atual falha, não encontra resultados ou quando o resultado mais relevante tem confiança
+// abaixo de `minConfidence`. Se nenhum provedor atingir a confiança mínima, são retornados os resultados de
+// maior confiança encontrados. Sem nenhum resultado, retorna ErrNoResults apenas se todos os provedores
+// responderam; se algum falhou, retorna o erro do último provedor que falhou, de modo que a consulta continue
+// podendo ser repetida (por exemplo, pelo retry dos jobs de geocoding).
+type Chain struct {
+	providers     []ChainProvider
+	minConfidence float64
+}
+
+// NewChain cria a cadeia com os provedores na ordem em que devem ser consultados.
+//
+// Exemplo de uso:
+//
+//	chain := geocoding.NewChain(0.5,
+//		geocoding.ChainProvider{Name: geocoding.ProviderDistanceMatrix, Geocoder: distanceMatrix},
+//		geocoding.ChainProvider{Name: geocoding.ProviderGazetteer, Geocoder: gazetteer},
+//	)
+func NewChain(minConfidence float64, providers ...ChainProvider) *Chain {
+	return &Chain{providers: providers, minConfidence: minConfidence}
+}
+
+// Geocode consulta os provedores em ordem até encontrar um resultado com a confiança mínima.
+func (c *Chain) Geocode(ctx context.Context, address string) ([]Result, error) {
+	return c.resolve(ctx, func(geocoder Geocoder) ([]Result, error) {
+		return geocoder.Geocode(ctx, address)
+	})
+}
+
+// Reverse consulta o geocoding reverso dos provedores em ordem, com as mesmas regras de Geocode.
+func (c *Chain) Reverse(ctx context.Context, latitude, longitude float64) ([]Result, error) {
+	return c.resolve(ctx, func(geocoder Geocoder) ([]Result, error) {
+		return geocoder.Reverse(ctx, latitude, longitude)
+	})
+}
+
+// resolve executa a consulta em cada provedor, na ordem da cadeia.
+func (c *Chain) resolve(ctx context.Context, query func(Geocoder) ([]Result, error)) ([]Result, error) {
+	var best []Result
+	var lastErr error
+	for i, provider := range c.providers {
+		results, err := query(provider.Geocoder)
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("%w: consulta aos provedores interrompida: %w", ErrUnavailable, err)
+		}
+		if errors.Is(err, ErrNoResults) || (err == nil && len(results) == 0) {
+			continue
+		}
+		if err != nil {
+			lastErr = err
+			if i < len(c.providers)-1 {
+				slog.Warn("Falha no provedor de geocoding; consultando o próximo da cadeia",
+					slog.String("provedor", provider.Name), slog.String("error", err.Error()))
+			}
+			continue
+		}
+
+		for j := range results {
+			results[j] = Normalize(results[j])
+			results[j].Provider = provider.Name
+		}
+		if results[0].Confidence >= c.minConfidence {
+			return results, nil
+		}
+		slog.Info("Resultado de geocoding com baixa confiança", slog.String("provedor", provider.Name),
+			slog.String("location_type", results[0].LocationType), slog.Float64("confidence", results[0].Confidence))
+		if best == nil || results[0].Confidence > best[0].Confidence {
+			best = results
+		}
+	}
+
+	switch {
+	case best != nil:
+		return best, nil
+	case lastErr != nil:
+		return nil, lastErr
+	default:
+		return nil, ErrNoResults
+	}
+}
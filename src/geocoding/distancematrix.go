@@ -0,0 +1,89 @@
+package geocoding
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"myapi/models"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// DistanceMatrixURL é o endpoint padrão da API de geocoding da DistanceMatrix.
+const DistanceMatrixURL = "https://api.distancematrix.ai/maps/api/geocode/json"
+
+// DistanceMatrix implementa Geocoder na API de geocoding da DistanceMatrix (formato compatível com o
+// Google Geocoding, decodificado em models.GeocodingResponse).
+type DistanceMatrix struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewDistanceMatrix cria o provedor DistanceMatrix com o endpoint, a chave de acesso e o cliente HTTP informados.
+//
+// Exemplo de uso:
+//
+//	geocoder := geocoding.NewDistanceMatrix(geocoding.DistanceMatrixURL, apiKey, &http.Client{Timeout: 10 * time.Second})
+func NewDistanceMatrix(baseURL, apiKey string, client *http.Client) *DistanceMatrix {
+	return &DistanceMatrix{baseURL: baseURL, apiKey: apiKey, client: client}
+}
+
+// Geocode consulta a API da DistanceMatrix. O status `ZERO_RESULTS` (ou uma lista vazia) resulta em
+// ErrNoResults; os demais status diferentes de `OK`, em ErrUpstream.
+func (d *DistanceMatrix) Geocode(ctx context.Context, address string) ([]Result, error) {
+	query := url.Values{}
+	query.Set("address", address)
+	slog.Info("Consultando a API DistanceMatrix", slog.String("url", d.baseURL), slog.String("endereco", address))
+	return d.query(ctx, query)
+}
+
+// Reverse consulta o geocoding reverso da DistanceMatrix (parâmetro `latlng` no mesmo endpoint).
+func (d *DistanceMatrix) Reverse(ctx context.Context, latitude, longitude float64) ([]Result, error) {
+	query := url.Values{}
+	query.Set("latlng", strconv.FormatFloat(latitude, 'f', -1, 64)+","+strconv.FormatFloat(longitude, 'f', -1, 64))
+	slog.Info("Consultando o geocoding reverso da API DistanceMatrix", slog.String("url", d.baseURL),
+		slog.Float64("latitude", latitude), slog.Float64("longitude", longitude))
+	return d.query(ctx, query)
+}
+
+// query envia a consulta à API com a chave de acesso e converte a resposta em resultados.
+func (d *DistanceMatrix) query(ctx context.Context, query url.Values) ([]Result, error) {
+	if d.apiKey == "" {
+		slog.Error("Chave da API de geocoding não configurada")
+		return nil, fmt.Errorf("%w: chave da API de geocoding não configurada", ErrUpstream)
+	}
+	query.Set("key", d.apiKey)
+
+	var response models.GeocodingResponse
+	if err := getJSON(ctx, d.client, d.baseURL+"?"+query.Encode(), nil, &response); err != nil {
+		slog.Error("Erro ao consultar a API DistanceMatrix", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	switch response.Status {
+	case "OK":
+	case "ZERO_RESULTS":
+		return nil, ErrNoResults
+	default:
+		slog.Error("Erro na resposta da API, status não é OK", slog.String("status", response.Status))
+		return nil, fmt.Errorf("%w: erro na resposta da API de geocoding, status: %s", ErrUpstream, response.Status)
+	}
+	if len(response.Results) == 0 {
+		return nil, ErrNoResults
+	}
+
+	results := make([]Result, 0, len(response.Results))
+	for _, result := range response.Results {
+		results = append(results, Result{
+			Latitude:    result.Geometry.Location.Lat,
+			Longitude:   result.Geometry.Location.Lng,
+			DisplayName: result.FormattedAddress,
+			Address:     result.AddressComponents,
+
+			LocationType: result.Geometry.LocationType,
+		})
+	}
+	return results, nil
+}
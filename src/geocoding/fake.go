@@ -0,0 +1,83 @@
+package geocoding
+
+import (
+	"context"
+	"math"
+	"myapi/models"
+	"strings"
+	"sync"
+)
+
+// Fake implementa Geocoder e PostalCodeLookup sem acesso à rede, a partir de endereços cadastrados com Add
+// e AddPostalCode. É usado nos testes e no desenvolvimento local (`geocoding.provider: fake`).
+type Fake struct {
+	mu          sync.RWMutex
+	results     map[string][]Result
+	postalCodes map[string]models.PostalCodeAddress
+}
+
+// NewFake cria um provedor local sem nenhum endereço cadastrado.
+func NewFake() *Fake {
+	return &Fake{results: make(map[string][]Result), postalCodes: make(map[string]models.PostalCodeAddress)}
+}
+
+// Add cadastra os resultados retornados para o endereço. A busca ignora maiúsculas e espaços extras.
+//
+// Exemplo de uso:
+//
+//	fake := geocoding.NewFake()
+//	fake.Add("Rua Teste, 123", geocoding.Result{Latitude: -22.619, Longitude: -43.164})
+func (f *Fake) Add(address string, results ...Result) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.results[NormalizeAddress(address)] = results
+}
+
+// Geocode retorna os resultados cadastrados para o endereço, ou ErrNoResults.
+func (f *Fake) Geocode(ctx context.Context, address string) ([]Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	results := f.results[NormalizeAddress(address)]
+	if len(results) == 0 {
+		return nil, ErrNoResults
+	}
+	return append([]Result(nil), results...), nil
+}
+
+// Reverse retorna os resultados cadastrados cujas coordenadas coincidem com as informadas (com 4 casas
+// decimais, cerca de 11 metros), ou ErrNoResults.
+func (f *Fake) Reverse(ctx context.Context, latitude, longitude float64) ([]Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var found []Result
+	for _, results := range f.results {
+		for _, result := range results {
+			if sameCoordinate(result.Latitude, latitude) && sameCoordinate(result.Longitude, longitude) {
+				found = append(found, result)
+			}
+		}
+	}
+	if len(found) == 0 {
+		return nil, ErrNoResults
+	}
+	return found, nil
+}
+
+// sameCoordinate compara duas coordenadas com 4 casas decimais.
+func sameCoordinate(a, b float64) bool {
+	return math.Round(a*1e4) == math.Round(b*1e4)
+}
+
+// NormalizeAddress padroniza o endereço para comparação: minúsculas e espaços simples.
+// Também é usado como chave do cache de geocoding.
+func NormalizeAddress(address string) string {
+	return strings.Join(strings.Fields(strings.ToLower(address)), " ")
+}
@@ -0,0 +1,553 @@
+package geocoding
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"myapi/models"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limites das buscas no gazetteer.
+const (
+	gazetteerLimit        = 5     // Resultados retornados por busca
+	gazetteerMinScore     = 0.6   // Fração mínima das palavras da busca encontradas na entrada
+	gazetteerReverseRange = 2_000 // Distância máxima, em metros, do geocoding reverso
+	gazetteerCellDegrees  = 0.02  // Lado, em graus, das células da grade do geocoding reverso (~2,2 km de latitude)
+)
+
+// GazetteerEntry é um lugar do gazetteer: uma rua, um CEP ou uma cidade com as coordenadas de referência.
+// Os campos vazios são ignorados na busca e na montagem do resultado.
+type GazetteerEntry struct {
+	Street       string  `json:"street"`
+	Neighborhood string  `json:"neighborhood"`
+	City         string  `json:"city"`
+	State        string  `json:"state"`
+	PostalCode   string  `json:"postal_code"`
+	Country      string  `json:"country"`
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+}
+
+// gazetteerPlace é uma entrada indexada: as palavras normalizadas e o resultado já montado.
+type gazetteerPlace struct {
+	entry      GazetteerEntry
+	tokens     []string
+	postalCode string
+	result     Result
+}
+
+// Gazetteer implementa Geocoder e PostalCodeLookup sem acesso à rede, a partir de um arquivo local (CSV ou
+// GeoJSON) com ruas, CEPs e cidades, carregado em memória na inicialização. É indicado para o desenvolvimento
+// local e para instalações sem acesso à internet (`geocoding.provider: gazetteer` e `postal_code.provider: gazetteer`).
+//
+// A busca é aproximada: maiúsculas, acentos, pontuação e abreviações comuns (`R.`, `Av.`) são ignorados e
+// palavras com pequenos erros de digitação ou incompletas também são encontradas. Um CEP na busca
+// (`01310-100` ou `01310100`) encontra diretamente as entradas com esse CEP.
+//
+// As buscas não percorrem todas as entradas: NewGazetteer indexa as entradas pelo CEP, por cada palavra e
+// por uma grade de coordenadas, e apenas as entradas candidatas desses índices são comparadas.
+type Gazetteer struct {
+	places []gazetteerPlace
+
+	postalCodes map[string][]int        // CEP (8 dígitos) → entradas com o CEP
+	tokens      map[string][]int        // Palavra normalizada → entradas que a contêm
+	vocabulary  []string                // Palavras distintas das entradas, em ordem alfabética (busca por prefixo)
+	lengths     map[int][]string        // Palavras distintas pela quantidade de letras (busca aproximada)
+	grid        map[gazetteerCell][]int // Célula da grade → entradas nela
+}
+
+// gazetteerCell é uma célula da grade do geocoding reverso, de gazetteerCellDegrees de lado.
+type gazetteerCell struct {
+	lat, lon int
+}
+
+// cellOf retorna a célula da grade que contém as coordenadas.
+func cellOf(latitude, longitude float64) gazetteerCell {
+	return gazetteerCell{lat: int(math.Floor(latitude / gazetteerCellDegrees)), lon: int(math.Floor(longitude / gazetteerCellDegrees))}
+}
+
+// NewGazetteer cria o gazetteer com as entradas informadas.
+//
+// Exemplo de uso:
+//
+//	gazetteer := geocoding.NewGazetteer([]geocoding.GazetteerEntry{
+//		{Street: "Avenida Paulista", City: "São Paulo", State: "SP", Latitude: -23.5614, Longitude: -46.6559},
+//	})
+func NewGazetteer(entries []GazetteerEntry) *Gazetteer {
+	g := &Gazetteer{
+		places:      make([]gazetteerPlace, 0, len(entries)),
+		postalCodes: make(map[string][]int),
+		tokens:      make(map[string][]int),
+		lengths:     make(map[int][]string),
+		grid:        make(map[gazetteerCell][]int),
+	}
+	for i, entry := range entries {
+		place := gazetteerPlace{
+			entry:      entry,
+			tokens:     searchTokens(strings.Join([]string{entry.Street, entry.Neighborhood, entry.City, entry.State, entry.Country}, " ")),
+			postalCode: digitsOnly(entry.PostalCode),
+			result:     entry.result(),
+		}
+		g.places = append(g.places, place)
+
+		if len(place.postalCode) == 8 {
+			g.postalCodes[place.postalCode] = append(g.postalCodes[place.postalCode], i)
+		}
+		for _, token := range place.tokens {
+			postings := g.tokens[token]
+			if len(postings) > 0 && postings[len(postings)-1] == i {
+				continue // Palavra repetida na mesma entrada
+			}
+			if len(postings) == 0 {
+				g.vocabulary = append(g.vocabulary, token)
+				length := utf8.RuneCountInString(token)
+				g.lengths[length] = append(g.lengths[length], token)
+			}
+			g.tokens[token] = append(postings, i)
+		}
+		cell := cellOf(entry.Latitude, entry.Longitude)
+		g.grid[cell] = append(g.grid[cell], i)
+	}
+	sort.Strings(g.vocabulary)
+	return g
+}
+
+// LoadGazetteer carrega o gazetteer do arquivo informado. Arquivos `.csv` devem ter um cabeçalho com as
+// colunas de GazetteerEntry (`latitude` e `longitude` são obrigatórias; as demais, opcionais). Arquivos
+// `.geojson` e `.json` devem conter uma FeatureCollection de pontos, com os mesmos campos em `properties`.
+//
+// Exemplo de uso:
+//
+//	gazetteer, err := geocoding.LoadGazetteer("gazetteer.csv")
+func LoadGazetteer(path string) (*Gazetteer, error) {
+	var read func(io.Reader) ([]GazetteerEntry, error)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		read = readGazetteerCSV
+	case ".geojson", ".json":
+		read = readGazetteerGeoJSON
+	default:
+		return nil, fmt.Errorf("formato do gazetteer não suportado: %q (use .csv ou .geojson)", filepath.Ext(path))
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir o gazetteer: %w", err)
+	}
+	defer file.Close()
+
+	entries, err := read(file)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler o gazetteer %s: %w", path, err)
+	}
+	slog.Info("Gazetteer carregado", slog.String("arquivo", path), slog.Int("lugares", len(entries)))
+	return NewGazetteer(entries), nil
+}
+
+// readGazetteerCSV lê as entradas de um CSV com cabeçalho.
+func readGazetteerCSV(r io.Reader) ([]GazetteerEntry, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("cabeçalho ausente: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["latitude"]; !ok {
+		return nil, errors.New("coluna latitude ausente")
+	}
+	if _, ok := columns["longitude"]; !ok {
+		return nil, errors.New("coluna longitude ausente")
+	}
+
+	var entries []GazetteerEntry
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		latitude, latErr := strconv.ParseFloat(field("latitude"), 64)
+		longitude, lonErr := strconv.ParseFloat(field("longitude"), 64)
+		if latErr != nil || lonErr != nil {
+			return nil, fmt.Errorf("linha %d: coordenadas inválidas", line)
+		}
+		entries = append(entries, GazetteerEntry{
+			Street:       field("street"),
+			Neighborhood: field("neighborhood"),
+			City:         field("city"),
+			State:        field("state"),
+			PostalCode:   field("postal_code"),
+			Country:      field("country"),
+			Latitude:     latitude,
+			Longitude:    longitude,
+		})
+	}
+}
+
+// readGazetteerGeoJSON lê as entradas de uma FeatureCollection de pontos.
+func readGazetteerGeoJSON(r io.Reader) ([]GazetteerEntry, error) {
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Geometry struct {
+				Type        string    `json:"type"`
+				Coordinates []float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties GazetteerEntry `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, err
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("esperado FeatureCollection, recebido %q", collection.Type)
+	}
+
+	entries := make([]GazetteerEntry, 0, len(collection.Features))
+	for i, feature := range collection.Features {
+		if feature.Geometry.Type != "Point" || len(feature.Geometry.Coordinates) < 2 {
+			return nil, fmt.Errorf("feature %d: somente geometrias Point são suportadas", i)
+		}
+		entry := feature.Properties
+		// O GeoJSON usa a ordem [longitude, latitude]
+		entry.Longitude, entry.Latitude = feature.Geometry.Coordinates[0], feature.Geometry.Coordinates[1]
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Geocode retorna os lugares mais parecidos com o endereço, ou ErrNoResults.
+func (g *Gazetteer) Geocode(ctx context.Context, address string) ([]Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type match struct {
+		place          *gazetteerPlace
+		score, covered float64
+	}
+	postalCode := findPostalCode(address)
+	query := searchTokens(address)
+	words := 0
+	for _, token := range query {
+		if !isNumber(token) {
+			words++
+		}
+	}
+
+	var matches []match
+	for _, i := range g.candidates(postalCode, query) {
+		place := &g.places[i]
+		if postalCode != "" && place.postalCode == postalCode {
+			matches = append(matches, match{place: place, score: 2})
+			continue
+		}
+		if words == 0 {
+			continue
+		}
+		found, used := 0, map[int]bool{}
+		for _, token := range query {
+			if isNumber(token) {
+				// Números da casa não fazem parte das entradas do gazetteer
+				continue
+			}
+			for j, candidate := range place.tokens {
+				if !used[j] && similarToken(token, candidate) {
+					used[j] = true
+					found++
+					break
+				}
+			}
+		}
+		score := float64(found) / float64(words)
+		if score >= gazetteerMinScore {
+			matches = append(matches, match{place: place, score: score, covered: float64(found) / float64(len(place.tokens))})
+		}
+	}
+	if len(matches) == 0 {
+		return nil, ErrNoResults
+	}
+
+	// Mais palavras da busca encontradas primeiro; no empate, a entrada mais específica (menos palavras sobrando)
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].covered > matches[j].covered
+	})
+	results := make([]Result, 0, min(len(matches), gazetteerLimit))
+	for _, m := range matches[:min(len(matches), gazetteerLimit)] {
+		// A confiança da precisão do lugar é reduzida pela fração da busca que não foi encontrada
+		result := Normalize(m.place.result)
+		result.Confidence *= min(m.score, 1)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// candidates retorna, em ordem crescente, as entradas que podem corresponder à busca: as do CEP e as que
+// contêm alguma palavra da busca, por igualdade, prefixo ou erro de digitação (veja similarToken).
+func (g *Gazetteer) candidates(postalCode string, query []string) []int {
+	selected := map[int]bool{}
+	for _, i := range g.postalCodes[postalCode] {
+		selected[i] = true
+	}
+	for _, token := range query {
+		if isNumber(token) {
+			continue
+		}
+		for _, word := range g.similarWords(token) {
+			for _, i := range g.tokens[word] {
+				selected[i] = true
+			}
+		}
+	}
+
+	indexes := make([]int, 0, len(selected))
+	for i := range selected {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// similarWords retorna as palavras do gazetteer semelhantes à palavra da busca (veja similarToken). Os
+// prefixos são buscados no vocabulário ordenado e os erros de digitação apenas entre as palavras com uma
+// quantidade de letras próxima.
+func (g *Gazetteer) similarWords(token string) []string {
+	words := []string{}
+	if _, ok := g.tokens[token]; ok {
+		words = append(words, token)
+	}
+	if len(token) >= 3 {
+		for i := sort.SearchStrings(g.vocabulary, token); i < len(g.vocabulary) && strings.HasPrefix(g.vocabulary[i], token); i++ {
+			if g.vocabulary[i] != token {
+				words = append(words, g.vocabulary[i])
+			}
+		}
+	}
+	if len(token) > 3 {
+		length := utf8.RuneCountInString(token)
+		for delta := -2; delta <= 2; delta++ {
+			for _, word := range g.lengths[length+delta] {
+				if word != token && !strings.HasPrefix(word, token) && similarToken(token, word) {
+					words = append(words, word)
+				}
+			}
+		}
+	}
+	return words
+}
+
+// Reverse retorna os lugares a até 2 km das coordenadas, do mais próximo para o mais distante, ou ErrNoResults.
+// Apenas as entradas das células da grade próximas às coordenadas são comparadas.
+func (g *Gazetteer) Reverse(ctx context.Context, latitude, longitude float64) ([]Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Uma célula cobre ~2,2 km de latitude; na longitude, as células encolhem com a latitude
+	center := cellOf(latitude, longitude)
+	lonRange := gazetteerReverseRange / (111_320 * math.Max(math.Cos(latitude*math.Pi/180), 0.01))
+	lonCells := int(math.Ceil(lonRange / gazetteerCellDegrees))
+	var indexes []int
+	for lat := center.lat - 1; lat <= center.lat+1; lat++ {
+		for lon := center.lon - lonCells; lon <= center.lon+lonCells; lon++ {
+			indexes = append(indexes, g.grid[gazetteerCell{lat: lat, lon: lon}]...)
+		}
+	}
+	sort.Ints(indexes)
+
+	type nearby struct {
+		result   Result
+		distance float64
+	}
+	var found []nearby
+	for _, i := range indexes {
+		place := g.places[i]
+		distance := distanceMeters(latitude, longitude, place.result.Latitude, place.result.Longitude)
+		if distance <= gazetteerReverseRange {
+			found = append(found, nearby{result: place.result, distance: distance})
+		}
+	}
+	if len(found) == 0 {
+		return nil, ErrNoResults
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].distance < found[j].distance })
+
+	results := make([]Result, 0, min(len(found), gazetteerLimit))
+	for _, n := range found[:min(len(found), gazetteerLimit)] {
+		results = append(results, Normalize(n.result))
+	}
+	return results, nil
+}
+
+// result monta o resultado da entrada, com os mesmos tipos de componente dos demais provedores.
+func (e GazetteerEntry) result() Result {
+	var parts []string
+	var components []models.AddressComponent
+	add := func(value string, types ...string) {
+		if value == "" {
+			return
+		}
+		parts = append(parts, value)
+		components = append(components, models.AddressComponent{LongName: value, ShortName: value, Types: types})
+	}
+	add(e.Street, "route")
+	add(e.Neighborhood, "sublocality", "neighborhood")
+	add(e.City, "locality")
+	add(e.State, "state")
+	add(e.PostalCode, "postal_code")
+	add(e.Country, "country")
+
+	return Result{
+		Latitude:    e.Latitude,
+		Longitude:   e.Longitude,
+		DisplayName: strings.Join(parts, ", "),
+		Address:     components,
+	}
+}
+
+// gazetteerAbbreviations expande as abreviações comuns dos logradouros.
+var gazetteerAbbreviations = map[string]string{
+	"r":    "rua",
+	"av":   "avenida",
+	"al":   "alameda",
+	"tv":   "travessa",
+	"trav": "travessa",
+	"pc":   "praca",
+	"pca":  "praca",
+	"rod":  "rodovia",
+	"estr": "estrada",
+}
+
+// gazetteerStopWords são palavras ignoradas na comparação.
+var gazetteerStopWords = map[string]bool{"de": true, "da": true, "do": true, "das": true, "dos": true, "e": true}
+
+// accentFolding remove os acentos das letras usadas em português.
+var accentFolding = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "ê", "e", "è", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// searchTokens normaliza o texto em palavras para a busca: minúsculas, sem acentos, sem pontuação, com as
+// abreviações expandidas e sem as palavras ignoradas.
+func searchTokens(text string) []string {
+	folded := accentFolding.Replace(strings.ToLower(text))
+	fields := strings.FieldsFunc(folded, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+
+	tokens := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if expanded, ok := gazetteerAbbreviations[field]; ok {
+			field = expanded
+		}
+		if !gazetteerStopWords[field] {
+			tokens = append(tokens, field)
+		}
+	}
+	return tokens
+}
+
+// similarToken indica se a palavra da busca corresponde à palavra da entrada: igual, prefixo (ao menos 3
+// letras, para a busca enquanto o usuário digita) ou com até 1 (palavras de até 5 letras) ou 2 erros de digitação.
+func similarToken(query, candidate string) bool {
+	if query == candidate {
+		return true
+	}
+	if len(query) >= 3 && strings.HasPrefix(candidate, query) {
+		return true
+	}
+	if len(query) <= 3 {
+		return false
+	}
+	allowed := 1
+	if len(query) > 5 {
+		allowed = 2
+	}
+	return levenshtein(query, candidate) <= allowed
+}
+
+// levenshtein calcula a distância de edição entre as duas palavras.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// findPostalCode retorna o CEP (8 dígitos) presente no texto, ou "".
+func findPostalCode(text string) string {
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsDigit(r) && r != '-' }) {
+		if digits := digitsOnly(field); len(digits) == 8 {
+			return digits
+		}
+	}
+	return ""
+}
+
+// digitsOnly remove do texto tudo o que não é dígito.
+func digitsOnly(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, text)
+}
+
+// isNumber indica se a palavra contém apenas dígitos.
+func isNumber(token string) bool {
+	return token != "" && digitsOnly(token) == token
+}
+
+// distanceMeters calcula a distância entre duas coordenadas pela fórmula de haversine.
+func distanceMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadius = 6_371_000
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+	dLat, dLon := toRadians(lat2-lat1), toRadians(lon2-lon1)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,91 @@
+// Package geocoding define a interface dos provedores de geocoding (conversão de endereço em coordenadas)
+// e suas implementações: DistanceMatrix, Nominatim (OpenStreetMap), um gazetteer local (Gazetteer) e um
+// provedor local para testes (Fake). Também define a consulta de CEP (PostalCodeLookup), respondida pelo
+// ViaCEP, pelo gazetteer e pelo provedor local.
+package geocoding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"myapi/models"
+	"net/http"
+	"net/url"
+)
+
+// Provedores de geocoding suportados, escolhidos pela configuração `geocoding.provider`.
+const (
+	ProviderDistanceMatrix = "distancematrix" // API DistanceMatrix (padrão)
+	ProviderNominatim      = "nominatim"      // Nominatim (OpenStreetMap)
+	ProviderGazetteer      = "gazetteer"      // Arquivo local de ruas, CEPs e cidades, sem acesso à rede
+	ProviderFake           = "fake"           // Provedor local, sem acesso à rede (testes e desenvolvimento)
+)
+
+// ErrNoResults é retornado quando o provedor não encontra nenhum resultado para o endereço.
+var ErrNoResults = errors.New("nenhum resultado encontrado para o endereço")
+
+// ErrUpstream é retornado quando o provedor falha, não está configurado ou responde de forma inesperada.
+var ErrUpstream = errors.New("falha no serviço externo")
+
+// ErrUnavailable é retornado quando não é possível obter uma resposta dos provedores: o circuit breaker está
+// aberto (ErrCircuitOpen) ou a consulta foi interrompida pelo prazo ou pelo cancelamento da requisição.
+var ErrUnavailable = errors.New("provedor de geocoding indisponível")
+
+// Geocoder converte um endereço em coordenadas geográficas e coordenadas em endereço.
+type Geocoder interface {
+	// Geocode retorna os resultados encontrados para o endereço, do mais relevante para o menos relevante.
+	// Retorna ErrNoResults quando não há resultados e ErrUpstream nas falhas do provedor.
+	Geocode(ctx context.Context, address string) ([]Result, error)
+
+	// Reverse retorna os endereços encontrados nas coordenadas, do mais próximo para o menos próximo.
+	// Retorna ErrNoResults quando não há resultados e ErrUpstream nas falhas do provedor.
+	Reverse(ctx context.Context, latitude, longitude float64) ([]Result, error)
+}
+
+// Result é um resultado de geocoding, no formato enviado pela API em GET /deliveries/geoconding/search.
+type Result struct {
+	Latitude    float64                   `json:"latitude"`     // Latitude do endereço
+	Longitude   float64                   `json:"longitude"`    // Longitude do endereço
+	DisplayName string                    `json:"display_name"` // Endereço formatado pelo provedor
+	Address     []models.AddressComponent `json:"address"`      // Componentes do endereço (rua, número, cidade, ...)
+
+	LocationType string  `json:"location_type"`      // Precisão das coordenadas (ROOFTOP, RANGE_INTERPOLATED, GEOMETRIC_CENTER ou APPROXIMATE)
+	Confidence   float64 `json:"confidence"`         // Confiança no resultado, de 0 a 1
+	Provider     string  `json:"provider,omitempty"` // Provedor da cadeia que encontrou o resultado
+}
+
+// getJSON executa um GET na URL informada e decodifica a resposta JSON em `target`.
+// Falhas de rede, status diferente de 2xx e respostas inválidas resultam em ErrUpstream (com o circuit
+// breaker aberto, também em ErrCircuitOpen). A URL (que pode conter a chave de acesso) é removida das
+// mensagens de erro.
+func getJSON(ctx context.Context, client *http.Client, rawURL string, header http.Header, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("%w: requisição inválida para o provedor de geocoding", ErrUpstream)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("%w: falha ao acessar o provedor de geocoding: %w", ErrUpstream, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("%w: provedor de geocoding respondeu com status %d", ErrUpstream, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("%w: falha ao decodificar a resposta do provedor de geocoding: %v", ErrUpstream, err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package geocoding
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"myapi/models"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NominatimURL é o endpoint padrão de busca do Nominatim (OpenStreetMap).
+const NominatimURL = "https://nominatim.openstreetmap.org/search"
+
+// nominatimLimit é a quantidade máxima de resultados pedida ao Nominatim.
+const nominatimLimit = 5
+
+// Nominatim implementa Geocoder na API de busca do Nominatim (OpenStreetMap).
+// A política de uso do Nominatim exige um User-Agent que identifique a aplicação.
+type Nominatim struct {
+	baseURL   string
+	userAgent string
+	client    *http.Client
+}
+
+// nominatimPlace é um resultado da busca do Nominatim (formato `jsonv2`).
+type nominatimPlace struct {
+	Lat         string            `json:"lat"`
+	Lon         string            `json:"lon"`
+	DisplayName string            `json:"display_name"`
+	Address     map[string]string `json:"address"`
+}
+
+// NewNominatim cria o provedor Nominatim com o endpoint, o User-Agent e o cliente HTTP informados.
+//
+// Exemplo de uso:
+//
+//	geocoder := geocoding.NewNominatim(geocoding.NominatimURL, "myapi/1.0", &http.Client{Timeout: 10 * time.Second})
+func NewNominatim(baseURL, userAgent string, client *http.Client) *Nominatim {
+	return &Nominatim{baseURL: baseURL, userAgent: userAgent, client: client}
+}
+
+// Geocode consulta a busca do Nominatim. Uma lista vazia resulta em ErrNoResults.
+func (n *Nominatim) Geocode(ctx context.Context, address string) ([]Result, error) {
+	query := url.Values{}
+	query.Set("q", address)
+	query.Set("format", "jsonv2")
+	query.Set("addressdetails", "1")
+	query.Set("limit", strconv.Itoa(nominatimLimit))
+	slog.Info("Consultando o Nominatim", slog.String("url", n.baseURL), slog.String("endereco", address))
+
+	var places []nominatimPlace
+	if err := getJSON(ctx, n.client, n.baseURL+"?"+query.Encode(), n.header(), &places); err != nil {
+		slog.Error("Erro ao consultar o Nominatim", slog.String("error", err.Error()))
+		return nil, err
+	}
+	if len(places) == 0 {
+		return nil, ErrNoResults
+	}
+	return nominatimResults(places)
+}
+
+// Reverse consulta o geocoding reverso do Nominatim, no endpoint `/reverse` ao lado do endpoint de busca.
+// A resposta com o campo `error` (coordenadas sem endereço, por exemplo no oceano) resulta em ErrNoResults.
+func (n *Nominatim) Reverse(ctx context.Context, latitude, longitude float64) ([]Result, error) {
+	query := url.Values{}
+	query.Set("lat", strconv.FormatFloat(latitude, 'f', -1, 64))
+	query.Set("lon", strconv.FormatFloat(longitude, 'f', -1, 64))
+	query.Set("format", "jsonv2")
+	query.Set("addressdetails", "1")
+	reverseURL := strings.TrimSuffix(n.baseURL, "/search") + "/reverse"
+	slog.Info("Consultando o geocoding reverso do Nominatim", slog.String("url", reverseURL),
+		slog.Float64("latitude", latitude), slog.Float64("longitude", longitude))
+
+	var place struct {
+		nominatimPlace
+		Error string `json:"error"`
+	}
+	if err := getJSON(ctx, n.client, reverseURL+"?"+query.Encode(), n.header(), &place); err != nil {
+		slog.Error("Erro ao consultar o Nominatim", slog.String("error", err.Error()))
+		return nil, err
+	}
+	if place.Error != "" {
+		slog.Info("Nominatim não encontrou endereço nas coordenadas", slog.String("erro", place.Error))
+		return nil, ErrNoResults
+	}
+	return nominatimResults([]nominatimPlace{place.nominatimPlace})
+}
+
+// header retorna os cabeçalhos enviados ao Nominatim, com o User-Agent exigido pela política de uso.
+func (n *Nominatim) header() http.Header {
+	header := http.Header{}
+	header.Set("User-Agent", n.userAgent)
+	return header
+}
+
+// nominatimResults converte os lugares do Nominatim em resultados.
+func nominatimResults(places []nominatimPlace) ([]Result, error) {
+	results := make([]Result, 0, len(places))
+	for _, place := range places {
+		lat, latErr := strconv.ParseFloat(place.Lat, 64)
+		lon, lonErr := strconv.ParseFloat(place.Lon, 64)
+		if latErr != nil || lonErr != nil {
+			return nil, fmt.Errorf("%w: coordenadas inválidas na resposta do Nominatim", ErrUpstream)
+		}
+		results = append(results, Result{
+			Latitude:    lat,
+			Longitude:   lon,
+			DisplayName: place.DisplayName,
+			Address:     nominatimComponents(place.Address),
+		})
+	}
+	return results, nil
+}
+
+// nominatimTypes associa as chaves do endereço do Nominatim aos tipos de componente usados pela
+// DistanceMatrix (e pela interface web), para que os dois provedores retornem o mesmo formato.
+var nominatimTypes = map[string]string{
+	"house_number":  "street_number",
+	"road":          "route",
+	"suburb":        "sublocality",
+	"city":          "locality",
+	"town":          "locality",
+	"village":       "locality",
+	"state":         "state",
+	"neighbourhood": "neighborhood",
+	"postcode":      "postal_code",
+	"country":       "country",
+}
+
+// nominatimComponents converte o endereço do Nominatim em componentes, ordenados pela chave.
+func nominatimComponents(address map[string]string) []models.AddressComponent {
+	keys := make([]string, 0, len(address))
+	for key := range address {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	components := make([]models.AddressComponent, 0, len(keys))
+	for _, key := range keys {
+		types := []string{key}
+		if mapped, ok := nominatimTypes[key]; ok && mapped != key {
+			types = []string{mapped, key}
+		}
+		components = append(components, models.AddressComponent{
+			LongName:  address[key],
+			ShortName: address[key],
+			Types:     types,
+		})
+	}
+	return components
+}
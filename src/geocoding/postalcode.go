@@ -0,0 +1,185 @@
+package geocoding
+
+import (
+	"context"
+	"log/slog"
+	"myapi/models"
+	"net/http"
+	"strings"
+)
+
+// ProviderViaCEP é o provedor padrão da consulta de CEP (`postal_code.provider`). O gazetteer e o provedor
+// local também respondem à consulta de CEP (ProviderGazetteer e ProviderFake).
+const ProviderViaCEP = "viacep"
+
+// ViaCEPURL é o endpoint padrão da API ViaCEP.
+const ViaCEPURL = "https://viacep.com.br/ws"
+
+// PostalCodeLookup consulta o endereço de um CEP.
+type PostalCodeLookup interface {
+	// LookupPostalCode retorna o endereço do CEP (8 dígitos, com ou sem hífen). Retorna ErrNoResults quando
+	// o CEP não existe e ErrUpstream nas falhas do provedor.
+	LookupPostalCode(ctx context.Context, postalCode string) (models.PostalCodeAddress, error)
+}
+
+// FormatPostalCode padroniza o CEP no formato `00000-000`. Aceita o CEP com ou sem hífen, com o ponto do
+// formato antigo (`01.310-100`) e com espaços nas pontas; retorna false para qualquer outro valor.
+//
+// Exemplo de uso:
+//
+//	cep, ok := geocoding.FormatPostalCode("01310100") // "01310-100", true
+func FormatPostalCode(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	digits := strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' {
+			return -1
+		}
+		return r
+	}, value)
+	if len(digits) != 8 || digitsOnly(digits) != digits || strings.Count(value, "-") > 1 || strings.Count(value, ".") > 1 {
+		return value, false
+	}
+	return digits[:5] + "-" + digits[5:], true
+}
+
+// brazilianStates contém o nome de cada estado brasileiro pela sigla (UF).
+var brazilianStates = map[string]string{
+	"AC": "Acre", "AL": "Alagoas", "AP": "Amapá", "AM": "Amazonas", "BA": "Bahia", "CE": "Ceará",
+	"DF": "Distrito Federal", "ES": "Espírito Santo", "GO": "Goiás", "MA": "Maranhão", "MT": "Mato Grosso",
+	"MS": "Mato Grosso do Sul", "MG": "Minas Gerais", "PA": "Pará", "PB": "Paraíba", "PR": "Paraná",
+	"PE": "Pernambuco", "PI": "Piauí", "RJ": "Rio de Janeiro", "RN": "Rio Grande do Norte",
+	"RS": "Rio Grande do Sul", "RO": "Rondônia", "RR": "Roraima", "SC": "Santa Catarina", "SP": "São Paulo",
+	"SE": "Sergipe", "TO": "Tocantins",
+}
+
+// SamePlace compara dois nomes de lugar (cidade ou bairro) ignorando maiúsculas, acentos e pontuação.
+func SamePlace(a, b string) bool {
+	return strings.Join(searchTokens(a), " ") == strings.Join(searchTokens(b), " ")
+}
+
+// SameState compara dois estados brasileiros informados pela sigla ou pelo nome (`SP` e `São Paulo` são
+// o mesmo estado), ignorando maiúsculas e acentos.
+func SameState(a, b string) bool {
+	return SamePlace(stateName(a), stateName(b))
+}
+
+// stateName retorna o nome do estado para uma sigla (UF), ou o próprio valor.
+func stateName(state string) string {
+	if name, ok := brazilianStates[strings.ToUpper(strings.TrimSpace(state))]; ok {
+		return name
+	}
+	return state
+}
+
+// ViaCEP implementa PostalCodeLookup na API ViaCEP (`GET /ws/{cep}/json/`), que não exige chave de acesso.
+type ViaCEP struct {
+	baseURL string
+	client  *http.Client
+}
+
+// viaCEPAddress é a resposta da API ViaCEP. CEPs inexistentes são respondidos com o campo `erro`, que pode
+// ser um booleano ou a string "true".
+type viaCEPAddress struct {
+	CEP        string      `json:"cep"`
+	Logradouro string      `json:"logradouro"`
+	Bairro     string      `json:"bairro"`
+	Localidade string      `json:"localidade"`
+	UF         string      `json:"uf"`
+	Erro       interface{} `json:"erro"`
+}
+
+// NewViaCEP cria o provedor ViaCEP com o endpoint e o cliente HTTP informados.
+//
+// Exemplo de uso:
+//
+//	lookup := geocoding.NewViaCEP(geocoding.ViaCEPURL, &http.Client{Timeout: 10 * time.Second})
+func NewViaCEP(baseURL string, client *http.Client) *ViaCEP {
+	return &ViaCEP{baseURL: strings.TrimRight(baseURL, "/"), client: client}
+}
+
+// LookupPostalCode consulta o CEP na API ViaCEP.
+func (v *ViaCEP) LookupPostalCode(ctx context.Context, postalCode string) (models.PostalCodeAddress, error) {
+	digits := digitsOnly(postalCode)
+	if len(digits) != 8 {
+		return models.PostalCodeAddress{}, ErrNoResults
+	}
+	slog.Info("Consultando o ViaCEP", slog.String("url", v.baseURL), slog.String("cep", digits))
+
+	var place viaCEPAddress
+	if err := getJSON(ctx, v.client, v.baseURL+"/"+digits+"/json/", nil, &place); err != nil {
+		slog.Error("Erro ao consultar o ViaCEP", slog.String("error", err.Error()))
+		return models.PostalCodeAddress{}, err
+	}
+	if (place.Erro != nil && place.Erro != false) || place.CEP == "" {
+		return models.PostalCodeAddress{}, ErrNoResults
+	}
+
+	cep, _ := FormatPostalCode(place.CEP)
+	return models.PostalCodeAddress{
+		PostalCode:   cep,
+		Street:       place.Logradouro,
+		Neighborhood: place.Bairro,
+		City:         place.Localidade,
+		State:        place.UF,
+		Country:      "Brasil",
+	}, nil
+}
+
+// LookupPostalCode retorna o endereço da primeira entrada do gazetteer com o CEP, dando preferência às
+// entradas com a rua (as mais específicas), ou ErrNoResults.
+func (g *Gazetteer) LookupPostalCode(ctx context.Context, postalCode string) (models.PostalCodeAddress, error) {
+	if err := ctx.Err(); err != nil {
+		return models.PostalCodeAddress{}, err
+	}
+
+	var found *GazetteerEntry
+	for _, i := range g.postalCodes[digitsOnly(postalCode)] {
+		entry := &g.places[i].entry
+		if found == nil || found.Street == "" && entry.Street != "" {
+			found = entry
+		}
+	}
+	if found == nil {
+		return models.PostalCodeAddress{}, ErrNoResults
+	}
+
+	cep, _ := FormatPostalCode(found.PostalCode)
+	country := found.Country
+	if country == "" {
+		country = "Brasil"
+	}
+	return models.PostalCodeAddress{
+		PostalCode:   cep,
+		Street:       found.Street,
+		Neighborhood: found.Neighborhood,
+		City:         found.City,
+		State:        found.State,
+		Country:      country,
+	}, nil
+}
+
+// AddPostalCode cadastra o endereço retornado para o CEP do endereço.
+//
+// Exemplo de uso:
+//
+//	fake.AddPostalCode(models.PostalCodeAddress{PostalCode: "25000-000", City: "Duque de Caxias", State: "RJ"})
+func (f *Fake) AddPostalCode(address models.PostalCodeAddress) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.postalCodes[digitsOnly(address.PostalCode)] = address
+}
+
+// LookupPostalCode retorna o endereço cadastrado para o CEP, ou ErrNoResults.
+func (f *Fake) LookupPostalCode(ctx context.Context, postalCode string) (models.PostalCodeAddress, error) {
+	if err := ctx.Err(); err != nil {
+		return models.PostalCodeAddress{}, err
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	address, ok := f.postalCodes[digitsOnly(postalCode)]
+	if !ok {
+		return models.PostalCodeAddress{}, ErrNoResults
+	}
+	return address, nil
+}
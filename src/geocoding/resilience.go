@@ -0,0 +1,311 @@
+package geocoding
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen é retornado sem acessar o provedor enquanto o circuit breaker está aberto, após uma
+// sequência de falhas. Corresponde a ErrUnavailable e também a ErrUpstream quando envolvido pelos provedores.
+var ErrCircuitOpen = fmt.Errorf("%w (circuit breaker aberto)", ErrUnavailable)
+
+// Estados do circuit breaker.
+const (
+	BreakerClosed   = "closed"    // As requisições são enviadas normalmente
+	BreakerOpen     = "open"      // As requisições falham imediatamente com ErrCircuitOpen
+	BreakerHalfOpen = "half_open" // Uma requisição de teste decide se o circuito fecha ou volta a abrir
+)
+
+// BreakerState é o estado do circuit breaker exposto no health check.
+type BreakerState struct {
+	State               string     `json:"state"`                // closed, open ou half_open
+	ConsecutiveFailures int        `json:"consecutive_failures"` // Falhas seguidas desde o último sucesso
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`  // Momento em que o circuito abriu
+	RetryAt             *time.Time `json:"retry_at,omitempty"`   // A partir de quando uma requisição de teste é permitida
+}
+
+// Breaker é um circuit breaker para o provedor de geocoding.
+//
+// Depois de `threshold` falhas seguidas (falhas de rede, timeouts e respostas 5xx/429 que esgotaram as
+// tentativas), o circuito abre e as requisições falham imediatamente por `cooldown`, sem ocupar goroutines
+// esperando um provedor lento. Passado esse tempo, uma única requisição de teste é enviada: o sucesso fecha
+// o circuito e a falha o abre novamente. É seguro para uso concorrente.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewBreaker cria um circuit breaker fechado.
+//
+// Exemplo de uso:
+//
+//	breaker := geocoding.NewBreaker(5, 30*time.Second)
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{threshold: max(threshold, 1), cooldown: cooldown, now: time.Now, state: BreakerClosed}
+}
+
+// Allow informa se uma requisição pode ser enviada. Com o circuito aberto retorna ErrCircuitOpen; após o
+// cooldown, libera uma única requisição de teste (half-open).
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Before(b.openedAt.Add(b.cooldown)) {
+			return ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		slog.Info("Circuit breaker do geocoding em half-open: enviando requisição de teste")
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Success registra uma resposta do provedor e fecha o circuito.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != BreakerClosed {
+		slog.Info("Circuit breaker do geocoding fechado")
+	}
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure registra uma falha do provedor, abrindo o circuito ao atingir o limite ou se a requisição de
+// teste falhar.
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= b.threshold) {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+		slog.Warn("Circuit breaker do geocoding aberto", slog.Int("falhas", b.failures), slog.Duration("cooldown", b.cooldown))
+	}
+}
+
+// Release libera a requisição de teste sem registrar sucesso nem falha (por exemplo, quando o chamador
+// cancelou a requisição).
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerHalfOpen {
+		b.probing = false
+	}
+}
+
+// State retorna o estado atual do circuit breaker.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := BreakerState{State: b.state, ConsecutiveFailures: b.failures}
+	if b.state == BreakerClosed && b.failures == 0 {
+		return state
+	}
+	if b.state != BreakerClosed {
+		openedAt, retryAt := b.openedAt, b.openedAt.Add(b.cooldown)
+		state.OpenedAt, state.RetryAt = &openedAt, &retryAt
+	}
+	return state
+}
+
+// RetryPolicy define as novas tentativas das requisições ao provedor que falham por erro de rede, timeout
+// ou status 5xx/429. O intervalo antes da tentativa n é sorteado entre 0 e min(MaxDelay, BaseDelay*2^n)
+// (exponential backoff com full jitter); um cabeçalho Retry-After menor que MaxDelay é respeitado.
+type RetryPolicy struct {
+	MaxRetries int           // Novas tentativas após a primeira (0 desativa)
+	BaseDelay  time.Duration // Intervalo base do backoff exponencial
+	MaxDelay   time.Duration // Maior intervalo entre duas tentativas
+	Timeout    time.Duration // Tempo máximo de cada tentativa (0 usa apenas o prazo do contexto)
+}
+
+// resilientTransport é o http.RoundTripper dos provedores de geocoding: aplica o prazo de cada tentativa,
+// as novas tentativas da RetryPolicy e o circuit breaker.
+type resilientTransport struct {
+	next    http.RoundTripper
+	policy  RetryPolicy
+	breaker *Breaker
+	sleep   func(ctx context.Context, delay time.Duration) error
+}
+
+// NewResilientClient cria o cliente HTTP dos provedores de geocoding, com o prazo por tentativa, as novas
+// tentativas e o circuit breaker (opcional; nil desativa). O prazo do contexto da requisição é sempre
+// respeitado, inclusive durante a espera entre as tentativas.
+//
+// Exemplo de uso:
+//
+//	client := geocoding.NewResilientClient(geocoding.RetryPolicy{MaxRetries: 2, BaseDelay: 200 * time.Millisecond,
+//		MaxDelay: 5 * time.Second, Timeout: 10 * time.Second}, geocoding.NewBreaker(5, 30*time.Second))
+func NewResilientClient(policy RetryPolicy, breaker *Breaker) *http.Client {
+	return &http.Client{Transport: &resilientTransport{
+		next:    http.DefaultTransport,
+		policy:  policy,
+		breaker: breaker,
+		sleep:   sleepContext,
+	}}
+}
+
+// RoundTrip envia a requisição, repetindo as falhas temporárias. Somente GET e HEAD são repetidos.
+func (t *resilientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.breaker != nil {
+		if err := t.breaker.Allow(); err != nil {
+			return nil, err
+		}
+	}
+
+	retries := t.policy.MaxRetries
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.attempt(req)
+		if req.Context().Err() != nil {
+			// O chamador desistiu da requisição: não é uma falha do provedor
+			if resp != nil {
+				resp.Body.Close()
+			}
+			t.release()
+			return nil, req.Context().Err()
+		}
+		if !retryable(resp, err) {
+			t.success()
+			return resp, err
+		}
+		if attempt >= retries {
+			t.failure()
+			return resp, err
+		}
+
+		delay := t.backoff(attempt, resp)
+		slog.Warn("Falha temporária no provedor de geocoding; nova tentativa agendada",
+			slog.Int("tentativa", attempt+1), slog.Duration("espera", delay), slog.String("motivo", describe(resp, err)))
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err := t.sleep(req.Context(), delay); err != nil {
+			t.release()
+			return nil, err
+		}
+	}
+}
+
+// attempt envia uma tentativa com o prazo da política. O prazo é encerrado quando o corpo da resposta é fechado.
+func (t *resilientTransport) attempt(req *http.Request) (*http.Response, error) {
+	if t.policy.Timeout <= 0 {
+		return t.next.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.policy.Timeout)
+	resp, err := t.next.RoundTrip(req.Clone(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// backoff calcula a espera antes da próxima tentativa.
+func (t *resilientTransport) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			if delay := time.Duration(seconds) * time.Second; delay <= t.policy.MaxDelay {
+				return delay
+			}
+		}
+	}
+	ceiling := t.policy.MaxDelay
+	if exp := t.policy.BaseDelay << attempt; exp > 0 && exp < ceiling {
+		ceiling = exp
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling + 1)
+}
+
+func (t *resilientTransport) success() {
+	if t.breaker != nil {
+		t.breaker.Success()
+	}
+}
+
+func (t *resilientTransport) failure() {
+	if t.breaker != nil {
+		t.breaker.Failure()
+	}
+}
+
+func (t *resilientTransport) release() {
+	if t.breaker != nil {
+		t.breaker.Release()
+	}
+}
+
+// retryable indica se a tentativa falhou de forma temporária: erro de rede, timeout ou status 5xx/429.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// describe resume o motivo da falha para o log.
+func describe(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("status %d", resp.StatusCode)
+}
+
+// sleepContext aguarda o intervalo ou o cancelamento do contexto.
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// cancelBody encerra o prazo da tentativa quando o corpo da resposta é fechado.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,210 @@
+package i18n
+
+// catalog contém as mensagens da API, chaveadas por uma chave estável e pelo idioma.
+// Toda mensagem deve ter a versão em Default (pt-BR); as chaves `problem.<código>.*` acompanham os
+// códigos de erro da API (veja controller/problem.go).
+var catalog = map[string]map[Lang]string{
+	// Validação dos campos do cliente (o primeiro argumento é o caminho do campo)
+	"validation.required": {
+		PtBR: "%s é obrigatório",
+		En:   "%s is required",
+	},
+	"validation.must_be_positive": {
+		PtBR: "%s deve ser maior que 0",
+		En:   "%s must be greater than 0",
+	},
+	"validation.invalid_number": {
+		PtBR: "%s deve ser um número válido",
+		En:   "%s must be a valid number",
+	},
+	"validation.out_of_range": {
+		PtBR: "%s deve estar entre %v e %v",
+		En:   "%s must be between %v and %v",
+	},
+	"validation.invalid_location_type": {
+		PtBR: "%s deve ser ROOFTOP, RANGE_INTERPOLATED, GEOMETRIC_CENTER ou APPROXIMATE",
+		En:   "%s must be ROOFTOP, RANGE_INTERPOLATED, GEOMETRIC_CENTER or APPROXIMATE",
+	},
+	"validation.invalid_postal_code": {
+		PtBR: "%s deve ser um CEP no formato 00000-000",
+		En:   "%s must be a postal code (CEP) in the 00000-000 format",
+	},
+	"validation.postal_code_not_found": {
+		PtBR: "%s não corresponde a nenhum CEP existente",
+		En:   "%s does not match any existing postal code (CEP)",
+	},
+	"validation.postal_code_mismatch": {
+		PtBR: "%s não corresponde ao CEP informado, que pertence a %s",
+		En:   "%s does not match the given postal code (CEP), which belongs to %s",
+	},
+
+	// Parâmetros, cabeçalhos e corpo das requisições
+	"request.read_body": {
+		PtBR: "erro ao ler o corpo da requisição",
+		En:   "could not read the request body",
+	},
+	"request.invalid_json": {
+		PtBR: "formato JSON inválido",
+		En:   "invalid JSON format",
+	},
+	"request.invalid_id": {
+		PtBR: "ID inválido",
+		En:   "invalid ID",
+	},
+	"request.id_required": {
+		PtBR: "ID do cliente não fornecido",
+		En:   "client ID not provided",
+	},
+	"request.delete_params_conflict": {
+		PtBR: "somente um parâmetro pode ser fornecido: 'deleteAll' ou 'id', não forneça ambos",
+		En:   "only one parameter may be provided: 'deleteAll' or 'id', not both",
+	},
+	"request.delete_params_missing": {
+		PtBR: "parâmetros inválidos, use ?deleteAll=true ou ?id=<ID>",
+		En:   "invalid parameters, use ?deleteAll=true or ?id=<ID>",
+	},
+	"request.address_required": {
+		PtBR: "o parâmetro 'endereco' é obrigatório",
+		En:   "the 'endereco' parameter is required",
+	},
+	"request.confirm_delete_all": {
+		PtBR: "para excluir todos os clientes, envie o cabeçalho X-Confirm-Delete-All: true",
+		En:   "to delete all clients, send the X-Confirm-Delete-All: true header",
+	},
+	"request.unsupported_patch_type": {
+		PtBR: "use application/merge-patch+json ou application/json-patch+json",
+		En:   "use application/merge-patch+json or application/json-patch+json",
+	},
+	"request.idempotency_key_too_long": {
+		PtBR: "Idempotency-Key deve ter no máximo %d caracteres",
+		En:   "Idempotency-Key must be at most %d characters long",
+	},
+	"request.stale_version": {
+		PtBR: "o cliente foi alterado por outra requisição, busque a versão atual e tente novamente",
+		En:   "the client was changed by another request, fetch the current version and try again",
+	},
+	"request.invalid_item_status": {
+		PtBR: "status deve ser pending, running, done ou failed",
+		En:   "status must be pending, running, done or failed",
+	},
+	"request.autocomplete_too_short": {
+		PtBR: "o parâmetro 'q' deve ter pelo menos %d caracteres",
+		En:   "the 'q' parameter must be at least %d characters long",
+	},
+	"request.invalid_limit": {
+		PtBR: "o parâmetro 'limit' deve ser um número entre 1 e %d",
+		En:   "the 'limit' parameter must be a number between 1 and %d",
+	},
+	"geocode_cache.disabled": {
+		PtBR: "o cache de geocoding está desativado (geocoding.cache_ttl igual a 0)",
+		En:   "the geocoding cache is disabled (geocoding.cache_ttl is 0)",
+	},
+
+	// Respostas de sucesso das exclusões
+	"client.deleted": {
+		PtBR: "Cliente excluído com sucesso.",
+		En:   "Client deleted successfully.",
+	},
+	"clients.deleted_all": {
+		PtBR: "Todos os clientes foram excluídos com sucesso. Clientes arquivados: %d.",
+		En:   "All clients were deleted successfully. Archived clients: %d.",
+	},
+
+	// Títulos e detalhes genéricos dos documentos de problema, por código de erro
+	"problem.invalid_request.title": {
+		PtBR: "Requisição inválida",
+		En:   "Invalid request",
+	},
+	"problem.invalid_request.detail": {
+		PtBR: "A requisição contém parâmetros, cabeçalhos ou corpo inválidos.",
+		En:   "The request has invalid parameters, headers or body.",
+	},
+	"problem.invalid_patch.title": {
+		PtBR: "Patch inválido",
+		En:   "Invalid patch",
+	},
+	"problem.invalid_patch.detail": {
+		PtBR: "O documento de patch é inválido ou tenta alterar campos somente leitura.",
+		En:   "The patch document is invalid or tries to change read-only fields.",
+	},
+	"problem.unsupported_media_type.title": {
+		PtBR: "Tipo de mídia não suportado",
+		En:   "Unsupported media type",
+	},
+	"problem.unsupported_media_type.detail": {
+		PtBR: "O tipo de mídia do corpo da requisição não é suportado.",
+		En:   "The media type of the request body is not supported.",
+	},
+	"problem.confirmation_required.title": {
+		PtBR: "Confirmação obrigatória",
+		En:   "Confirmation required",
+	},
+	"problem.confirmation_required.detail": {
+		PtBR: "A operação exige uma confirmação explícita.",
+		En:   "The operation requires an explicit confirmation.",
+	},
+	"problem.idempotency_key_reused.title": {
+		PtBR: "Idempotency-Key reutilizada",
+		En:   "Idempotency-Key reused",
+	},
+	"problem.idempotency_key_reused.detail": {
+		PtBR: "A Idempotency-Key já foi utilizada com um corpo de requisição diferente.",
+		En:   "The Idempotency-Key was already used with a different request body.",
+	},
+	"problem.idempotency_key_in_progress.title": {
+		PtBR: "Idempotency-Key em andamento",
+		En:   "Idempotency-Key in progress",
+	},
+	"problem.idempotency_key_in_progress.detail": {
+		PtBR: "A requisição original com esta Idempotency-Key ainda está em andamento.",
+		En:   "The original request with this Idempotency-Key is still in progress.",
+	},
+	"problem.validation_failed.title": {
+		PtBR: "Dados inválidos",
+		En:   "Invalid data",
+	},
+	"problem.validation_failed.detail": {
+		PtBR: "Um ou mais campos são inválidos.",
+		En:   "One or more fields are invalid.",
+	},
+	"problem.not_found.title": {
+		PtBR: "Recurso não encontrado",
+		En:   "Resource not found",
+	},
+	"problem.not_found.detail": {
+		PtBR: "O recurso solicitado não foi encontrado.",
+		En:   "The requested resource was not found.",
+	},
+	"problem.precondition_failed.title": {
+		PtBR: "A versão do recurso não corresponde ao If-Match",
+		En:   "The resource version does not match If-Match",
+	},
+	"problem.precondition_failed.detail": {
+		PtBR: "O recurso foi alterado por outra requisição.",
+		En:   "The resource was changed by another request.",
+	},
+	"problem.conflict.title": {
+		PtBR: "Conflito com o estado atual do recurso",
+		En:   "Conflict with the current state of the resource",
+	},
+	"problem.conflict.detail": {
+		PtBR: "A operação conflita com o estado atual do recurso.",
+		En:   "The operation conflicts with the current state of the resource.",
+	},
+	"problem.upstream_error.title": {
+		PtBR: "Falha no serviço externo",
+		En:   "Upstream service failure",
+	},
+	"problem.upstream_unavailable.title": {
+		PtBR: "Serviço externo temporariamente indisponível",
+		En:   "Upstream service temporarily unavailable",
+	},
+	"problem.upstream_unavailable.detail": {
+		PtBR: "O provedor de geocoding está falhando; tente novamente em alguns instantes.",
+		En:   "The geocoding provider is failing; try again in a few moments.",
+	},
+	"problem.internal_error.title": {
+		PtBR: "Erro interno do servidor",
+		En:   "Internal server error",
+	},
+}
@@ -0,0 +1,111 @@
+// Package i18n contém o catálogo de mensagens da API em português (pt-BR) e inglês (en) e a escolha do
+// idioma a partir do cabeçalho Accept-Language.
+package i18n
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lang identifica um idioma suportado pelo catálogo.
+type Lang string
+
+// Idiomas suportados.
+const (
+	PtBR Lang = "pt-BR"
+	En   Lang = "en"
+)
+
+// Default é o idioma usado quando a requisição não informa um idioma suportado.
+const Default = PtBR
+
+// Supported lista os idiomas do catálogo, na ordem de preferência usada em caso de empate.
+var Supported = []Lang{PtBR, En}
+
+// T retorna a mensagem `key` no idioma informado, formatada com `args` (fmt.Sprintf).
+//
+// Caso a mensagem não exista no idioma, é usada a versão em Default; caso não exista no catálogo,
+// a própria chave é retornada.
+//
+// Exemplo de uso:
+//
+//	i18n.T(i18n.En, "client.deleted") // "Client deleted successfully."
+func T(lang Lang, key string, args ...interface{}) string {
+	translations, ok := catalog[key]
+	if !ok {
+		return key
+	}
+	message, ok := translations[lang]
+	if !ok {
+		message = translations[Default]
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// FromRequest retorna o idioma preferido da requisição, a partir do cabeçalho Accept-Language.
+func FromRequest(r *http.Request) Lang {
+	return Parse(r.Header.Get("Accept-Language"))
+}
+
+// Parse escolhe o idioma suportado de maior preferência em um cabeçalho Accept-Language
+// (por exemplo, "en-US,en;q=0.9,pt;q=0.5"). Tags regionais correspondem ao idioma base ("en-GB" → en,
+// "pt" ou "pt-PT" → pt-BR) e `*` corresponde a Default. Sem correspondência, retorna Default.
+func Parse(header string) Lang {
+	type candidate struct {
+		lang    Lang
+		quality float64
+	}
+	var candidates []candidate
+
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		quality := 1.0
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality <= 0 {
+			continue
+		}
+		if lang, ok := match(tag); ok {
+			candidates = append(candidates, candidate{lang, quality})
+		}
+	}
+	if len(candidates) == 0 {
+		return Default
+	}
+
+	// A ordem do cabeçalho desempata idiomas com a mesma qualidade
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	return candidates[0].lang
+}
+
+// match associa uma tag de idioma a um idioma suportado.
+func match(tag string) (Lang, bool) {
+	if tag == "*" {
+		return Default, true
+	}
+	base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+	switch base {
+	case "pt":
+		return PtBR, true
+	case "en":
+		return En, true
+	}
+	return "", false
+}
@@ -3,11 +3,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"log/slog"
 	"myapi/config"
 	"myapi/controller"
+	"myapi/services"
 	"net/http"
 	"os"
 
@@ -38,8 +40,9 @@ func init() {
 func enableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*") // Allow all origins
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Origin, X-Requested-With")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Origin, X-Requested-With, X-Confirm-Delete-All, If-Match, Idempotency-Key, X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "Deprecation, Link, X-Archived-Count, ETag, Idempotent-Replayed, X-Request-ID")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -62,6 +65,7 @@ func logRequest(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Logando informações da requisição antes de processar
 		logger.Info("Recebendo requisição",
+			slog.String("request_id", w.Header().Get(controller.RequestIDHeader)),
 			slog.String("method", r.Method),
 			slog.String("url", r.URL.Path),
 			slog.String("remoteAddr", r.RemoteAddr),
@@ -73,6 +77,7 @@ func logRequest(next http.Handler) http.Handler {
 
 		// Logando informações após processar a requisição
 		logger.Info("Resposta enviada",
+			slog.String("request_id", w.Header().Get(controller.RequestIDHeader)),
 			slog.Int("statusCode", recorder.statusCode),
 			slog.String("method", r.Method),
 			slog.String("url", r.URL.Path),
@@ -105,14 +110,95 @@ func (rec *responseRecorder) WriteHeader(statusCode int) {
 
 func main() {
 
+	// Subcomandos de manutenção (por exemplo: `migrate up|down|status` e `purge -dry-run`)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatalf("Erro ao executar as migrações: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "purge" {
+		if err := runPurge(os.Args[2:]); err != nil {
+			log.Fatalf("Erro ao remover clientes arquivados: %v", err)
+		}
+		return
+	}
+
 	// Criar o roteador
 	r := mux.NewRouter()
 
-	// Conectar ao banco de dados
-	config.ConnectDB()
-
-	// Criar uma instância do controlador
-	controller := &controller.APIController{}
+	// Atribuir um ID a cada requisição (cabeçalho X-Request-ID), incluído nos logs e nas respostas de erro
+	r.Use(controller.RequestID)
+
+	// Carregar a configuração (arquivo, variáveis de ambiente e flags)
+	settings, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatalf("Erro ao carregar a configuração: %v", err)
+	}
+
+	// Conectar ao armazenamento e criar o repositório de entregas
+	repo, err := config.NewRepository(settings.Database)
+	if err != nil {
+		log.Fatalf("Erro ao configurar o armazenamento: %v", err)
+	}
+
+	// Agendar a limpeza dos clientes arquivados conforme a política de retenção
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go services.RunRetentionScheduler(ctx, repo, settings.Retention)
+
+	// Armazenamento das chaves Idempotency-Key do POST /deliveries
+	idempotencyStore, err := config.NewIdempotencyStore(settings.Database)
+	if err != nil {
+		log.Fatalf("Erro ao configurar as chaves de idempotência: %v", err)
+	}
+
+	// Provedor de geocoding configurado (DistanceMatrix, Nominatim ou local), com novas tentativas e circuit breaker
+	geocodingBreaker := config.NewGeocodingBreaker(settings.Geocoding)
+	geocoder, err := config.NewGeocoder(settings.Geocoding, geocodingBreaker)
+	if err != nil {
+		log.Fatalf("Erro ao configurar o provedor de geocoding: %v", err)
+	}
+
+	// Consulta de CEP (ViaCEP ou local)
+	postalCodes, err := config.NewPostalCodeLookup(settings.PostalCode, settings.Geocoding)
+	if err != nil {
+		log.Fatalf("Erro ao configurar a consulta de CEP: %v", err)
+	}
+
+	// Sugestões de endereço: o provedor é consultado sem o cache do banco, que guardaria cada prefixo digitado
+	autocomplete := services.NewAutocompleter(repo, geocoder, settings.Geocoding.AutocompleteCacheTTL)
+
+	// Cache dos resultados de geocoding no banco de dados, consultado antes do provedor
+	var geocodeCache *services.CachedGeocoder
+	if settings.Geocoding.CacheTTL > 0 {
+		cacheStore, err := config.NewGeocodeCache(settings.Database)
+		if err != nil {
+			log.Fatalf("Erro ao configurar o cache de geocoding: %v", err)
+		}
+		geocodeCache = services.NewCachedGeocoder(geocoder, cacheStore, settings.Geocoding.Provider, settings.Geocoding.MinConfidence, settings.Geocoding.CacheTTL)
+		geocoder = geocodeCache
+	}
+
+	// Geocoding assíncrono das entregas cadastradas sem coordenadas
+	geocodingJobs, err := config.NewGeocodingJobStore(settings.Database)
+	if err != nil {
+		log.Fatalf("Erro ao configurar os jobs de geocoding: %v", err)
+	}
+	geocodingWorker := services.NewGeocodingWorker(repo, geocodingJobs, geocoder, settings.Geocoding)
+	go geocodingWorker.Run(ctx)
+
+	// Criar uma instância do controlador com o repositório e o provedor de geocoding injetados
+	controller := controller.NewAPIController(repo, geocoder)
+	controller.Idempotency = idempotencyStore
+	controller.IdempotencyTTL = settings.Idempotency.TTL
+	controller.IdempotencyLease = settings.Server.WriteTimeout
+	controller.GeocodeCache = geocodeCache
+	controller.GeocodingJobs = geocodingWorker
+	controller.Autocomplete = autocomplete
+	controller.GeocodingBreaker = geocodingBreaker
+	controller.PostalCodes = postalCodes
+	controller.PostalCodeCrossCheck = settings.PostalCode.CrossCheck
 
 	// Registrar as rotas no controlador
 	controller.RegisterRoutes(r)
@@ -148,6 +234,12 @@ func main() {
 	})
 
 	// Iniciar o servidor
-	fmt.Println("Servidor iniciado em http://localhost:8080")
-	log.Fatal(http.ListenAndServe(":8080", r))
+	server := &http.Server{
+		Addr:         settings.Server.Addr(),
+		Handler:      r,
+		ReadTimeout:  settings.Server.ReadTimeout,
+		WriteTimeout: settings.Server.WriteTimeout,
+	}
+	fmt.Printf("Servidor iniciado em http://localhost:%d\n", settings.Server.Port)
+	log.Fatal(server.ListenAndServe())
 }
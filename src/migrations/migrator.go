@@ -0,0 +1,295 @@
+// Package migrations aplica as migrações SQL versionadas do banco de dados.
+//
+// Cada migração é composta por um par de arquivos `NNNN_descricao.up.sql` e `NNNN_descricao.down.sql`,
+// embutidos no binário. As versões aplicadas ficam registradas na tabela `schema_migrations`.
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// files contém as migrações de cada dialeto, em um diretório por dialeto (mysql, postgres, sqlite).
+//
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// Migration representa uma migração versionada com os scripts de aplicação e reversão.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// SchemaMigration é o registro de uma migração aplicada na tabela `schema_migrations`.
+type SchemaMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:255"`
+	AppliedAt time.Time
+}
+
+// TableName define o nome da tabela de controle das migrações.
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status descreve a situação de uma migração no banco de dados.
+type Status struct {
+	Migration Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Files retorna os arquivos de migração do dialeto informado ("mysql", "postgres" ou "sqlite").
+func Files(dialect string) (fs.FS, error) {
+	if dialect == "" || strings.ContainsAny(dialect, "/.") {
+		return nil, fmt.Errorf("dialeto de migração inválido: %q", dialect)
+	}
+	if _, err := fs.Stat(files, dialect); err != nil {
+		return nil, fmt.Errorf("não há migrações para o dialeto %q", dialect)
+	}
+	return fs.Sub(files, dialect)
+}
+
+// Load lê os pares de arquivos `.up.sql`/`.down.sql` da raiz de fsys e os retorna ordenados por versão.
+// Retorna erro se algum nome estiver fora do padrão, se houver versões duplicadas ou se faltar um dos scripts.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar migrações: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		version, name, direction, err := parseFileName(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler a migração %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		} else if migration.Name != name {
+			return nil, fmt.Errorf("versão %d duplicada: %q e %q", version, migration.Name, name)
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if strings.TrimSpace(migration.Up) == "" || strings.TrimSpace(migration.Down) == "" {
+			return nil, fmt.Errorf("migração %04d_%s deve ter os scripts up e down", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// parseFileName extrai versão, nome e direção de um arquivo no formato `0001_nome.up.sql`.
+func parseFileName(fileName string) (int, string, string, error) {
+	base := strings.TrimSuffix(fileName, ".sql")
+	direction := path.Ext(base)
+	if direction != ".up" && direction != ".down" {
+		return 0, "", "", fmt.Errorf("arquivo de migração sem direção up/down: %s", fileName)
+	}
+	base = strings.TrimSuffix(base, direction)
+
+	versionPart, name, found := strings.Cut(base, "_")
+	version, err := strconv.Atoi(versionPart)
+	if !found || err != nil || version <= 0 || name == "" {
+		return 0, "", "", fmt.Errorf("nome de arquivo de migração inválido: %s", fileName)
+	}
+	return version, name, strings.TrimPrefix(direction, "."), nil
+}
+
+// Migrator aplica e reverte migrações em uma conexão GORM.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator cria um Migrator com as migrações embutidas do dialeto informado.
+//
+// Exemplo de uso:
+//
+//	migrator, err := migrations.NewMigrator(db, "mysql")
+//	applied, err := migrator.Up()
+func NewMigrator(db *gorm.DB, dialect string) (*Migrator, error) {
+	fsys, err := Files(dialect)
+	if err != nil {
+		return nil, err
+	}
+	list, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: list}, nil
+}
+
+// ensureTable cria a tabela `schema_migrations` caso ela ainda não exista.
+func (m *Migrator) ensureTable() error {
+	if err := m.db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return fmt.Errorf("erro ao criar a tabela schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// applied retorna as migrações registradas em `schema_migrations`, indexadas pela versão.
+func (m *Migrator) applied() (map[int]SchemaMigration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+	var rows []SchemaMigration
+	if err := m.db.Order("version").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("erro ao consultar schema_migrations: %w", err)
+	}
+	result := make(map[int]SchemaMigration, len(rows))
+	for _, row := range rows {
+		result[row.Version] = row
+	}
+	return result, nil
+}
+
+// Status retorna a situação (aplicada ou pendente) de cada migração conhecida.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		row, ok := applied[migration.Version]
+		statuses = append(statuses, Status{Migration: migration, Applied: ok, AppliedAt: row.AppliedAt})
+	}
+	return statuses, nil
+}
+
+// Pending retorna as migrações ainda não aplicadas, em ordem de versão.
+func (m *Migrator) Pending() ([]Migration, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, status := range statuses {
+		if !status.Applied {
+			pending = append(pending, status.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up aplica todas as migrações pendentes, em ordem de versão, e retorna as que foram aplicadas.
+// A execução para na primeira migração que falhar.
+func (m *Migrator) Up() ([]Migration, error) {
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range pending {
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := execScript(tx, migration.Up); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("erro ao aplicar a migração %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		slog.Info("Migração aplicada", slog.Int("version", migration.Version), slog.String("name", migration.Name))
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down reverte as últimas `steps` migrações aplicadas, da mais recente para a mais antiga,
+// e retorna as que foram revertidas.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, errors.New("o número de migrações a reverter deve ser maior que 0")
+	}
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(statuses) - 1; i >= 0 && len(done) < steps; i-- {
+		if !statuses[i].Applied {
+			continue
+		}
+		migration := statuses[i].Migration
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := execScript(tx, migration.Down); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, "version = ?", migration.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("erro ao reverter a migração %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		slog.Info("Migração revertida", slog.Int("version", migration.Version), slog.String("name", migration.Name))
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// execScript executa cada instrução do script separadamente.
+// As instruções são separadas por `;` no fim da linha, e comentários `--` de linha inteira são ignorados.
+func execScript(tx *gorm.DB, script string) error {
+	for _, statement := range splitStatements(script) {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStatements divide um script SQL em instruções individuais.
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
@@ -0,0 +1,41 @@
+-- Cria as tabelas de clientes e de clientes arquivados.
+-- Usa IF NOT EXISTS para adotar bancos criados anteriormente pelo AutoMigrate.
+CREATE TABLE IF NOT EXISTS clients (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    deleted_at DATETIME(3) NULL,
+    name LONGTEXT,
+    weight_kg DOUBLE,
+    address LONGTEXT,
+    street LONGTEXT,
+    number BIGINT,
+    neighborhood LONGTEXT,
+    complement LONGTEXT,
+    city LONGTEXT,
+    state LONGTEXT,
+    country LONGTEXT,
+    latitude DOUBLE,
+    longitude DOUBLE,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS archived_clients (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    name VARCHAR(255),
+    weight_kg DOUBLE,
+    address VARCHAR(255),
+    street VARCHAR(255),
+    number BIGINT,
+    neighborhood VARCHAR(255),
+    complement VARCHAR(255),
+    city VARCHAR(255),
+    state VARCHAR(255),
+    country VARCHAR(255),
+    latitude DOUBLE,
+    longitude DOUBLE,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    deleted_at DATETIME(3) NULL,
+    PRIMARY KEY (id)
+);
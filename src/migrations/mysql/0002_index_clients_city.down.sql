@@ -0,0 +1,2 @@
+DROP INDEX idx_clients_city ON clients;
+ALTER TABLE clients MODIFY city LONGTEXT;
@@ -0,0 +1,4 @@
+-- Índice para o filtro por cidade de GET /deliveries.
+-- A coluna passa a VARCHAR(255), já que o MySQL não indexa LONGTEXT sem prefixo.
+ALTER TABLE clients MODIFY city VARCHAR(255);
+CREATE INDEX idx_clients_city ON clients (city);
@@ -0,0 +1 @@
+DROP INDEX idx_archived_clients_deleted_at ON archived_clients;
@@ -0,0 +1,2 @@
+ALTER TABLE archived_clients DROP COLUMN version;
+ALTER TABLE clients DROP COLUMN version;
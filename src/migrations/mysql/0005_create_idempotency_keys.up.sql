@@ -0,0 +1,12 @@
+-- Chaves de idempotência do POST /deliveries (cabeçalho Idempotency-Key).
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status_code INT NOT NULL DEFAULT 0,
+    content_type VARCHAR(255) NULL,
+    body MEDIUMBLOB NULL,
+    created_at DATETIME(3) NULL,
+    expires_at DATETIME(3) NOT NULL,
+    PRIMARY KEY (idempotency_key),
+    INDEX idx_idempotency_keys_expires_at (expires_at)
+);
@@ -0,0 +1,11 @@
+-- Cache dos resultados de geocoding, indexado pelo hash do endereço normalizado.
+CREATE TABLE IF NOT EXISTS geocode_cache (
+    provider VARCHAR(32) NOT NULL,
+    address_key VARCHAR(64) NOT NULL,
+    address TEXT NOT NULL,
+    results MEDIUMBLOB NOT NULL,
+    created_at DATETIME(3) NULL,
+    expires_at DATETIME(3) NOT NULL,
+    PRIMARY KEY (provider, address_key),
+    INDEX idx_geocode_cache_expires_at (expires_at)
+);
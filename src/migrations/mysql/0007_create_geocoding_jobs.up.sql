@@ -0,0 +1,21 @@
+-- Jobs de geocoding assíncrono das entregas sem coordenadas e seus itens (uma entrega por item).
+CREATE TABLE IF NOT EXISTS geocoding_jobs (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS geocoding_job_items (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    job_id BIGINT UNSIGNED NOT NULL,
+    client_id BIGINT UNSIGNED NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    error VARCHAR(1024) NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_geocoding_job_items_job_id (job_id),
+    INDEX idx_geocoding_job_items_status (status)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS archived_clients;
+DROP TABLE IF EXISTS clients;
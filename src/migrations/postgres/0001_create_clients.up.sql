@@ -0,0 +1,38 @@
+-- Cria as tabelas de clientes e de clientes arquivados.
+CREATE TABLE IF NOT EXISTS clients (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NULL,
+    updated_at TIMESTAMPTZ NULL,
+    deleted_at TIMESTAMPTZ NULL,
+    name TEXT,
+    weight_kg DOUBLE PRECISION,
+    address TEXT,
+    street TEXT,
+    number BIGINT,
+    neighborhood TEXT,
+    complement TEXT,
+    city TEXT,
+    state TEXT,
+    country TEXT,
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION
+);
+
+CREATE TABLE IF NOT EXISTS archived_clients (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255),
+    weight_kg DOUBLE PRECISION,
+    address VARCHAR(255),
+    street VARCHAR(255),
+    number BIGINT,
+    neighborhood VARCHAR(255),
+    complement VARCHAR(255),
+    city VARCHAR(255),
+    state VARCHAR(255),
+    country VARCHAR(255),
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION,
+    created_at TIMESTAMPTZ NULL,
+    updated_at TIMESTAMPTZ NULL,
+    deleted_at TIMESTAMPTZ NULL
+);
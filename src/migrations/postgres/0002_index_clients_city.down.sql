@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_clients_city;
+ALTER TABLE clients ALTER COLUMN city TYPE TEXT;
@@ -0,0 +1,3 @@
+-- Índice para o filtro por cidade de GET /deliveries.
+ALTER TABLE clients ALTER COLUMN city TYPE VARCHAR(255);
+CREATE INDEX IF NOT EXISTS idx_clients_city ON clients (city);
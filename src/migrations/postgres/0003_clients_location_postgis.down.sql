@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_clients_location;
+ALTER TABLE clients DROP COLUMN IF EXISTS location;
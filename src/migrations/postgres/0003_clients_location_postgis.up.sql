@@ -0,0 +1,6 @@
+-- Armazena a posição de cada entrega como geography (WGS 84) com índice GiST.
+-- A coluna é gerada a partir de latitude/longitude, então a aplicação continua gravando apenas esses campos.
+CREATE EXTENSION IF NOT EXISTS postgis;
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS location geography(Point, 4326)
+    GENERATED ALWAYS AS (ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)::geography) STORED;
+CREATE INDEX IF NOT EXISTS idx_clients_location ON clients USING GIST (location);
@@ -0,0 +1,3 @@
+-- Versão do cliente para o controle de concorrência otimista (ETag / If-Match).
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE archived_clients ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,11 @@
+-- Cache dos resultados de geocoding, indexado pelo hash do endereço normalizado.
+CREATE TABLE IF NOT EXISTS geocode_cache (
+    provider VARCHAR(32) NOT NULL,
+    address_key VARCHAR(64) NOT NULL,
+    address TEXT NOT NULL,
+    results BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (provider, address_key)
+);
+CREATE INDEX IF NOT EXISTS idx_geocode_cache_expires_at ON geocode_cache (expires_at);
@@ -0,0 +1,19 @@
+-- Jobs de geocoding assíncrono das entregas sem coordenadas e seus itens (uma entrega por item).
+CREATE TABLE IF NOT EXISTS geocoding_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NULL,
+    updated_at TIMESTAMPTZ NULL
+);
+
+CREATE TABLE IF NOT EXISTS geocoding_job_items (
+    id BIGSERIAL PRIMARY KEY,
+    job_id BIGINT NOT NULL,
+    client_id BIGINT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error VARCHAR(1024),
+    created_at TIMESTAMPTZ NULL,
+    updated_at TIMESTAMPTZ NULL
+);
+CREATE INDEX IF NOT EXISTS idx_geocoding_job_items_job_id ON geocoding_job_items (job_id);
+CREATE INDEX IF NOT EXISTS idx_geocoding_job_items_status ON geocoding_job_items (status);
@@ -0,0 +1,3 @@
+-- Marca as entregas cujo endereço mudou sem novas coordenadas, aguardando o re-geocoding.
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS coordinates_stale BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE archived_clients ADD COLUMN IF NOT EXISTS coordinates_stale BOOLEAN NOT NULL DEFAULT FALSE;
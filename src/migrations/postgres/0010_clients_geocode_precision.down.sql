@@ -0,0 +1,4 @@
+ALTER TABLE archived_clients DROP COLUMN IF EXISTS geocode_confidence;
+ALTER TABLE archived_clients DROP COLUMN IF EXISTS location_type;
+ALTER TABLE clients DROP COLUMN IF EXISTS geocode_confidence;
+ALTER TABLE clients DROP COLUMN IF EXISTS location_type;
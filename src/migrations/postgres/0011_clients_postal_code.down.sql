@@ -0,0 +1,2 @@
+ALTER TABLE archived_clients DROP COLUMN IF EXISTS postal_code;
+ALTER TABLE clients DROP COLUMN IF EXISTS postal_code;
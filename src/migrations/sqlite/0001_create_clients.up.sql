@@ -0,0 +1,38 @@
+-- Cria as tabelas de clientes e de clientes arquivados.
+CREATE TABLE IF NOT EXISTS clients (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL,
+    deleted_at DATETIME NULL,
+    name TEXT,
+    weight_kg REAL,
+    address TEXT,
+    street TEXT,
+    number INTEGER,
+    neighborhood TEXT,
+    complement TEXT,
+    city TEXT,
+    state TEXT,
+    country TEXT,
+    latitude REAL,
+    longitude REAL
+);
+
+CREATE TABLE IF NOT EXISTS archived_clients (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT,
+    weight_kg REAL,
+    address TEXT,
+    street TEXT,
+    number INTEGER,
+    neighborhood TEXT,
+    complement TEXT,
+    city TEXT,
+    state TEXT,
+    country TEXT,
+    latitude REAL,
+    longitude REAL,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL,
+    deleted_at DATETIME NULL
+);
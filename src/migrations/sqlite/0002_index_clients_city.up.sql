@@ -0,0 +1,2 @@
+-- Índice para o filtro por cidade de GET /deliveries.
+CREATE INDEX IF NOT EXISTS idx_clients_city ON clients (city);
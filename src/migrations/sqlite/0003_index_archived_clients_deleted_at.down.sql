@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_archived_clients_deleted_at;
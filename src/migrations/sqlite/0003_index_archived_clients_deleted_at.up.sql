@@ -0,0 +1,2 @@
+-- Índice para a limpeza dos clientes arquivados por data de arquivamento (retention.archived_days).
+CREATE INDEX IF NOT EXISTS idx_archived_clients_deleted_at ON archived_clients (deleted_at);
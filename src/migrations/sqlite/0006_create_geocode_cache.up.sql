@@ -0,0 +1,11 @@
+-- Cache dos resultados de geocoding, indexado pelo hash do endereço normalizado.
+CREATE TABLE IF NOT EXISTS geocode_cache (
+    provider TEXT NOT NULL,
+    address_key TEXT NOT NULL,
+    address TEXT NOT NULL,
+    results BLOB NOT NULL,
+    created_at DATETIME NULL,
+    expires_at DATETIME NOT NULL,
+    PRIMARY KEY (provider, address_key)
+);
+CREATE INDEX IF NOT EXISTS idx_geocode_cache_expires_at ON geocode_cache (expires_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS geocoding_job_items;
+DROP TABLE IF EXISTS geocoding_jobs;
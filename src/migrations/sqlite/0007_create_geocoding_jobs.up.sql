@@ -0,0 +1,19 @@
+-- Jobs de geocoding assíncrono das entregas sem coordenadas e seus itens (uma entrega por item).
+CREATE TABLE IF NOT EXISTS geocoding_jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL
+);
+
+CREATE TABLE IF NOT EXISTS geocoding_job_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_id INTEGER NOT NULL,
+    client_id INTEGER NOT NULL,
+    status TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL
+);
+CREATE INDEX IF NOT EXISTS idx_geocoding_job_items_job_id ON geocoding_job_items (job_id);
+CREATE INDEX IF NOT EXISTS idx_geocoding_job_items_status ON geocoding_job_items (status);
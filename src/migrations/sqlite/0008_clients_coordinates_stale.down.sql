@@ -0,0 +1,2 @@
+ALTER TABLE archived_clients DROP COLUMN coordinates_stale;
+ALTER TABLE clients DROP COLUMN coordinates_stale;
@@ -0,0 +1,4 @@
+ALTER TABLE archived_clients DROP COLUMN geocode_confidence;
+ALTER TABLE archived_clients DROP COLUMN location_type;
+ALTER TABLE clients DROP COLUMN geocode_confidence;
+ALTER TABLE clients DROP COLUMN location_type;
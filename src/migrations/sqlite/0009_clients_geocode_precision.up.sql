@@ -0,0 +1,5 @@
+-- Precisão (location_type) e confiança do geocoding que gerou as coordenadas de cada entrega.
+ALTER TABLE clients ADD COLUMN location_type VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE clients ADD COLUMN geocode_confidence REAL NOT NULL DEFAULT 0;
+ALTER TABLE archived_clients ADD COLUMN location_type VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE archived_clients ADD COLUMN geocode_confidence REAL NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE archived_clients DROP COLUMN postal_code;
+ALTER TABLE clients DROP COLUMN postal_code;
@@ -0,0 +1,3 @@
+-- CEP (00000-000) do endereço de cada entrega.
+ALTER TABLE clients ADD COLUMN postal_code VARCHAR(9) NOT NULL DEFAULT '';
+ALTER TABLE archived_clients ADD COLUMN postal_code VARCHAR(9) NOT NULL DEFAULT '';
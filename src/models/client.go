@@ -17,20 +17,44 @@ import (
 // A tabela associada a este modelo no banco de dados é chamada "clients".
 type Client struct {
 	gorm.Model
-	Name         string  `json:"name"`         // Nome do cliente
-	WeightKg     float64 `json:"weight_kg"`    // Peso do cliente em kg
-	Address      string  `json:"address"`      // Endereço completo
-	Street       string  `json:"street"`       // Nome da rua
-	Number       int     `json:"number"`       // Número da residência
-	Neighborhood string  `json:"neighborhood"` // Bairro do cliente
-	Complement   string  `json:"complement"`   // Complemento do endereço
-	City         string  `json:"city"`         // Cidade do cliente
-	State        string  `json:"state"`        // Estado do cliente
-	Country      string  `json:"country"`      // País do cliente
-	Latitude     float64 `json:"latitude"`     // Latitude da localização
-	Longitude    float64 `json:"longitude"`    // Longitude da localização
+	Name         string  `json:"name"`                                        // Nome do cliente
+	WeightKg     float64 `json:"weight_kg"`                                   // Peso do cliente em kg
+	Address      string  `json:"address"`                                     // Endereço completo
+	Street       string  `json:"street"`                                      // Nome da rua
+	Number       int     `json:"number"`                                      // Número da residência
+	Neighborhood string  `json:"neighborhood"`                                // Bairro do cliente
+	Complement   string  `json:"complement"`                                  // Complemento do endereço
+	City         string  `json:"city" gorm:"size:255;index:idx_clients_city"` // Cidade do cliente (indexada para o filtro por cidade)
+	State        string  `json:"state"`                                       // Estado do cliente
+	Country      string  `json:"country"`                                     // País do cliente
+	Latitude     float64 `json:"latitude"`                                    // Latitude da localização
+	Longitude    float64 `json:"longitude"`                                   // Longitude da localização
+	Version      uint    `json:"version" gorm:"not null;default:1"`           // Versão do registro, incrementada a cada alteração (ETag)
+
+	CoordinatesStale bool `json:"coordinates_stale" gorm:"not null;default:false"` // O endereço mudou depois das coordenadas, que aguardam o re-geocoding
+
+	LocationType      string  `json:"location_type" gorm:"size:32;not null;default:''"` // Precisão das coordenadas (ROOFTOP, APPROXIMATE, ...; vazio quando informadas manualmente)
+	GeocodeConfidence float64 `json:"geocode_confidence" gorm:"not null;default:0"`     // Confiança do geocoding nas coordenadas, de 0 a 1
+
+	PostalCode string `json:"postal_code" gorm:"size:9;not null;default:''"` // CEP no formato 00000-000 (opcional)
+}
+
+// HasCoordinates indica se o cliente possui coordenadas. Clientes cadastrados apenas com o endereço
+// (latitude e longitude iguais a 0) aguardam o geocoding assíncrono.
+func (c Client) HasCoordinates() bool {
+	return c.Latitude != 0 || c.Longitude != 0
 }
 
+// NeedsGeocoding indica se as coordenadas do cliente devem ser obtidas pelo geocoding: ele não possui
+// coordenadas ou o endereço foi alterado depois delas (CoordinatesStale).
+func (c Client) NeedsGeocoding() bool {
+	return !c.HasCoordinates() || c.CoordinatesStale
+}
+
+// AddressFields são os campos de models.Client que compõem o endereço. Alterar qualquer um deles sem
+// informar novas coordenadas torna as coordenadas armazenadas desatualizadas.
+var AddressFields = []string{"Address", "Street", "Number", "Neighborhood", "City", "State", "Country", "PostalCode"}
+
 // ClientUpdate representa um cliente com os campos atualizáveis.
 // Ele é utilizado para receber dados de atualização, incluindo o ID do cliente.
 // Esse modelo é usado quando os dados de um cliente existente precisam ser atualizados no banco de dados.
@@ -48,27 +72,36 @@ type ClientUpdate struct {
 	City         string  `json:"city"`         // Cidade do cliente
 	State        string  `json:"state"`        // Estado do cliente
 	Country      string  `json:"country"`      // País do cliente
+	PostalCode   string  `json:"postal_code"`  // CEP do cliente
 	Latitude     float64 `json:"latitude"`     // Latitude da localização
 	Longitude    float64 `json:"longitude"`    // Longitude da localização
+
+	LocationType      string  `json:"location_type"`      // Precisão das coordenadas enviadas (vazio quando informadas manualmente)
+	GeocodeConfidence float64 `json:"geocode_confidence"` // Confiança do geocoding nas coordenadas enviadas
 }
 
 type ArchivedClient struct {
-	ID           int    `gorm:"primaryKey"`
-	Name         string `gorm:"size:255"`
-	WeightKg     float64
-	Address      string `gorm:"size:255"`
-	Street       string `gorm:"size:255"`
-	Number       int    `gorm:"size:50"`
-	Neighborhood string `gorm:"size:255"`
-	Complement   string `gorm:"size:255"`
-	City         string `gorm:"size:255"`
-	State        string `gorm:"size:255"`
-	Country      string `gorm:"size:255"`
-	Latitude     float64
-	Longitude    float64
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
-	DeletedAt    time.Time
+	ID                int       `gorm:"primaryKey"`
+	Name              string    `json:"name" gorm:"size:255"`
+	WeightKg          float64   `json:"weight_kg"`
+	Address           string    `json:"address" gorm:"size:255"`
+	Street            string    `json:"street" gorm:"size:255"`
+	Number            int       `json:"number" gorm:"size:50"`
+	Neighborhood      string    `json:"neighborhood" gorm:"size:255"`
+	Complement        string    `json:"complement" gorm:"size:255"`
+	City              string    `json:"city" gorm:"size:255"`
+	State             string    `json:"state" gorm:"size:255"`
+	Country           string    `json:"country" gorm:"size:255"`
+	PostalCode        string    `json:"postal_code" gorm:"size:9;not null;default:''"`
+	Latitude          float64   `json:"latitude"`
+	Longitude         float64   `json:"longitude"`
+	Version           uint      `json:"version" gorm:"not null;default:1"`
+	CoordinatesStale  bool      `json:"coordinates_stale" gorm:"not null;default:false"`
+	LocationType      string    `json:"location_type" gorm:"size:32;not null;default:''"`
+	GeocodeConfidence float64   `json:"geocode_confidence" gorm:"not null;default:0"`
+	CreatedAt         time.Time // Data de criação original do cliente
+	UpdatedAt         time.Time // Data da última atualização antes do arquivamento
+	DeletedAt         time.Time // Momento em que o cliente foi arquivado
 }
 
 // Struct auxiliar para garantir a ordem dos campos
@@ -84,13 +117,64 @@ type ClientResponse struct {
 	City         string  `json:"city"`         // Cidade do cliente
 	State        string  `json:"state"`        // Estado do cliente
 	Country      string  `json:"country"`      // País do cliente
+	PostalCode   string  `json:"postal_code"`  // CEP do cliente
 	Latitude     float64 `json:"latitude"`     // Latitude da localização
 	Longitude    float64 `json:"longitude"`    // Longitude da localização
+	Version      uint    `json:"version"`      // Versão do registro após a alteração
+
+	CoordinatesStale bool `json:"coordinates_stale"` // As coordenadas aguardam o re-geocoding do novo endereço
+
+	LocationType      string  `json:"location_type"`      // Precisão das coordenadas
+	GeocodeConfidence float64 `json:"geocode_confidence"` // Confiança do geocoding nas coordenadas
+}
+
+// ClientAddress contém os campos de endereço de um cliente obtidos pelo geocoding reverso.
+// Os nomes JSON são os mesmos de Client, de modo que a interface web preenche o formulário diretamente.
+type ClientAddress struct {
+	Address      string  `json:"address"`      // Endereço completo formatado pelo provedor
+	Street       string  `json:"street"`       // Nome da rua
+	Number       int     `json:"number"`       // Número da residência (0 quando o provedor não informa)
+	Neighborhood string  `json:"neighborhood"` // Bairro
+	City         string  `json:"city"`         // Cidade
+	State        string  `json:"state"`        // Estado
+	Country      string  `json:"country"`      // País
+	PostalCode   string  `json:"postal_code"`  // CEP (vazio quando o provedor não informa)
+	Latitude     float64 `json:"latitude"`     // Latitude do endereço encontrado
+	Longitude    float64 `json:"longitude"`    // Longitude do endereço encontrado
+
+	LocationType      string  `json:"location_type"`      // Precisão das coordenadas
+	GeocodeConfidence float64 `json:"geocode_confidence"` // Confiança do geocoding nas coordenadas, de 0 a 1
+}
+
+// PostalCodeAddress contém os campos de endereço de um CEP, obtidos pela consulta de CEP
+// (GET /deliveries/cep/{cep}). Os nomes JSON são os mesmos de Client, para preencher o formulário diretamente.
+type PostalCodeAddress struct {
+	PostalCode   string `json:"postal_code"`  // CEP no formato 00000-000
+	Street       string `json:"street"`       // Logradouro (vazio nos CEPs gerais de uma cidade)
+	Neighborhood string `json:"neighborhood"` // Bairro (vazio nos CEPs gerais de uma cidade)
+	City         string `json:"city"`         // Cidade
+	State        string `json:"state"`        // Sigla do estado (UF)
+	Country      string `json:"country"`      // País
+}
+
+// Origens das sugestões do autocomplete de endereços.
+const (
+	SuggestionSourceHistory  = "history"  // Endereço já cadastrado em um cliente ativo ou arquivado
+	SuggestionSourceProvider = "provider" // Endereço sugerido pelo provedor de geocoding
+)
+
+// AddressSuggestion é uma sugestão do autocomplete de endereços: os campos de endereço do cliente,
+// prontos para preencher o formulário, e a origem da sugestão.
+type AddressSuggestion struct {
+	ClientAddress
+	Source string `json:"source"` // SuggestionSourceHistory ou SuggestionSourceProvider
 }
 
+// GeocodingResponse é a resposta da API de geocoding da DistanceMatrix (veja geocoding.DistanceMatrix).
+// Diferente do Google Geocoding, a DistanceMatrix envia a lista de resultados na chave `result`.
 type GeocodingResponse struct {
 	Status  string   `json:"status"`
-	Results []Result `json:"results"`
+	Results []Result `json:"result"`
 }
 
 type Result struct {
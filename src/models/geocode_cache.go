@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// GeocodeCacheEntry armazena os resultados de geocoding de um endereço, evitando consultar o provedor
+// novamente para o mesmo endereço enquanto a entrada não expira.
+// A tabela associada a este modelo no banco de dados é chamada "geocode_cache".
+type GeocodeCacheEntry struct {
+	Provider   string    `gorm:"primaryKey;size:32"` // Provedor que gerou os resultados (por exemplo, "distancematrix")
+	AddressKey string    `gorm:"primaryKey;size:64"` // SHA-256 do endereço normalizado
+	Address    string    // Endereço normalizado consultado
+	Results    []byte    // Resultados do provedor, em JSON
+	CreatedAt  time.Time // Momento da consulta ao provedor
+	ExpiresAt  time.Time `gorm:"index"` // A partir deste momento a entrada é ignorada e removida
+}
+
+// TableName define o nome da tabela do cache de geocoding.
+func (GeocodeCacheEntry) TableName() string {
+	return "geocode_cache"
+}
@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+// Situações de um item do job de geocoding.
+const (
+	GeocodingItemPending = "pending" // Aguardando um worker
+	GeocodingItemRunning = "running" // Em processamento por um worker
+	GeocodingItemDone    = "done"    // Coordenadas gravadas no cliente
+	GeocodingItemFailed  = "failed"  // Falhou; pode ser reenfileirado pela rota de retry
+)
+
+// Situações de um job de geocoding, calculadas a partir dos itens (veja GeocodingJob.Summarize).
+const (
+	GeocodingJobQueued              = "queued"                // Nenhum item processado ainda
+	GeocodingJobRunning             = "running"               // Há itens processados e itens pendentes
+	GeocodingJobCompleted           = "completed"             // Todos os itens foram geocodificados
+	GeocodingJobCompletedWithErrors = "completed_with_errors" // Todos os itens terminaram, mas algum falhou
+)
+
+// GeocodingJob é um lote de entregas enfileirado para o geocoding assíncrono, preenchendo Latitude e Longitude
+// a partir do endereço.
+// A tabela associada a este modelo no banco de dados é chamada "geocoding_jobs".
+type GeocodingJob struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Status string                 `json:"status" gorm:"-"` // Situação calculada a partir dos itens
+	Items  GeocodingJobItemCounts `json:"items" gorm:"-"`  // Quantidade de itens em cada situação
+}
+
+// GeocodingJobItemCounts contém a quantidade de itens de um job em cada situação.
+type GeocodingJobItemCounts struct {
+	Total   int64 `json:"total"`
+	Pending int64 `json:"pending"`
+	Running int64 `json:"running"`
+	Done    int64 `json:"done"`
+	Failed  int64 `json:"failed"`
+}
+
+// Summarize preenche Items.Total e Status a partir das quantidades de cada situação.
+func (j *GeocodingJob) Summarize() {
+	counts := &j.Items
+	counts.Total = counts.Pending + counts.Running + counts.Done + counts.Failed
+	switch {
+	case counts.Pending+counts.Running == 0 && counts.Failed > 0:
+		j.Status = GeocodingJobCompletedWithErrors
+	case counts.Pending+counts.Running == 0:
+		j.Status = GeocodingJobCompleted
+	case counts.Running+counts.Done+counts.Failed == 0:
+		j.Status = GeocodingJobQueued
+	default:
+		j.Status = GeocodingJobRunning
+	}
+}
+
+// TableName define o nome da tabela dos jobs de geocoding.
+func (GeocodingJob) TableName() string {
+	return "geocoding_jobs"
+}
+
+// GeocodingJobItem é uma entrega (cliente) de um job de geocoding.
+// A tabela associada a este modelo no banco de dados é chamada "geocoding_job_items".
+type GeocodingJobItem struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	JobID     uint      `json:"job_id" gorm:"index"`              // Job ao qual o item pertence
+	ClientID  uint      `json:"client_id"`                        // Cliente a ser geocodificado
+	Status    string    `json:"status" gorm:"size:16;index"`      // GeocodingItemPending, GeocodingItemRunning, ...
+	Attempts  int       `json:"attempts"`                         // Quantidade de vezes que o item foi processado
+	Error     string    `json:"error,omitempty" gorm:"size:1024"` // Motivo da última falha
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName define o nome da tabela dos itens dos jobs de geocoding.
+func (GeocodingJobItem) TableName() string {
+	return "geocoding_job_items"
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// IdempotencyKey registra uma requisição feita com o cabeçalho Idempotency-Key e a resposta enviada,
+// permitindo reproduzir a mesma resposta quando a requisição é repetida.
+// A tabela associada a este modelo no banco de dados é chamada "idempotency_keys".
+type IdempotencyKey struct {
+	Key         string    `gorm:"column:idempotency_key;primaryKey;size:255"` // Valor do cabeçalho Idempotency-Key
+	RequestHash string    `gorm:"size:64"`                                    // SHA-256 do método, caminho e corpo da requisição original
+	StatusCode  int       // Status da resposta original (0 enquanto a requisição está em andamento)
+	ContentType string    `gorm:"size:255"` // Content-Type da resposta original
+	Body        []byte    // Corpo da resposta original
+	CreatedAt   time.Time // Momento em que a chave foi recebida
+	ExpiresAt   time.Time `gorm:"index"` // A partir deste momento a chave pode ser reutilizada
+}
+
+// InProgress indica que a requisição original ainda não terminou.
+func (k IdempotencyKey) InProgress() bool {
+	return k.StatusCode == 0
+}
+
+// TableName define o nome da tabela das chaves de idempotência.
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"errors"
+	"myapi/geocoding"
+	"myapi/models"
+	"strings"
+	"time"
+)
+
+// ErrNotFound é retornado quando o registro solicitado (cliente, cliente arquivado ou chave de idempotência)
+// não existe no armazenamento.
+var ErrNotFound = errors.New("registro não encontrado")
+
+// ErrConflict é retornado quando a operação não pode ser concluída porque conflita com o estado atual
+// do armazenamento (por exemplo, restaurar um cliente cujo ID já foi reutilizado).
+var ErrConflict = errors.New("conflito com o estado atual do cliente")
+
+// ErrVersionMismatch é retornado quando a versão esperada do cliente não corresponde à versão armazenada,
+// indicando que ele foi alterado por outra requisição (controle de concorrência otimista).
+var ErrVersionMismatch = errors.New("a versão do cliente não corresponde à versão esperada")
+
+// ClientFilter reúne os filtros e a paginação aceitos na listagem de clientes.
+//
+// Campos:
+// - City: quando preenchido, retorna apenas os clientes da cidade informada.
+// - Limit: número máximo de registros retornados (0 significa sem limite).
+// - Offset: número de registros a pular antes de começar a listar.
+type ClientFilter struct {
+	City   string
+	Limit  int
+	Offset int
+}
+
+// DeliveryRepository define as operações de persistência das entregas (clientes).
+//
+// Os services, os handlers e o controller dependem apenas desta interface, permitindo trocar
+// o backend de armazenamento (MySQL via GORM, memória, etc.) sem alterar o código HTTP.
+type DeliveryRepository interface {
+	// Create insere um novo cliente, preenchendo ID e timestamps na estrutura recebida.
+	Create(client *models.Client) error
+
+	// FindByID busca um cliente pelo ID. Retorna ErrNotFound caso ele não exista.
+	FindByID(id uint) (models.Client, error)
+
+	// List retorna os clientes que atendem ao filtro e o total de registros sem paginação.
+	List(filter ClientFilter) ([]models.Client, int64, error)
+
+	// Update aplica os campos informados (chaveados pelo nome do campo em models.Client)
+	// ao cliente com o ID especificado, incrementa sua versão e retorna o registro atualizado.
+	// Com expectedVersion diferente de 0, a atualização só acontece se a versão armazenada for a
+	// esperada; caso contrário retorna ErrVersionMismatch.
+	Update(id uint, expectedVersion uint, fields map[string]interface{}) (models.Client, error)
+
+	// ArchiveByID copia o cliente para a tabela de arquivados e o remove da tabela principal,
+	// de forma atômica. Retorna ErrNotFound caso ele não exista e, com expectedVersion diferente
+	// de 0, ErrVersionMismatch caso a versão armazenada seja outra.
+	ArchiveByID(id uint, expectedVersion uint) error
+
+	// ArchiveAll arquiva e remove todos os clientes da tabela principal, de forma atômica,
+	// e retorna a quantidade de clientes arquivados.
+	ArchiveAll() (int64, error)
+
+	// ListArchived retorna os clientes arquivados que atendem ao filtro e o total sem paginação.
+	ListArchived(filter ClientFilter) ([]models.ArchivedClient, int64, error)
+
+	// RestoreArchived move o cliente arquivado de volta para a tabela principal, preservando o ID
+	// e os timestamps originais, de forma atômica. Retorna ErrNotFound caso ele não esteja arquivado
+	// e ErrConflict caso o ID já esteja em uso por outro cliente.
+	RestoreArchived(id uint) (models.Client, error)
+
+	// ListIDsNeedingGeocoding retorna, em ordem crescente, os IDs dos clientes que aguardam o geocoding:
+	// cadastrados apenas com o endereço (latitude e longitude iguais a 0) ou com coordenadas desatualizadas.
+	ListIDsNeedingGeocoding() ([]uint, error)
+
+	// SearchAddresses retorna até `limit` endereços distintos (sem diferenciar maiúsculas e espaços) de
+	// clientes ativos e arquivados que contêm `query`, apenas com coordenadas válidas. Os clientes ativos
+	// vêm primeiro e, em cada tabela, os alterados mais recentemente.
+	SearchAddresses(query string, limit int) ([]models.ClientAddress, error)
+
+	// PurgeArchived remove definitivamente os clientes arquivados antes de `before`, em lotes por ordem
+	// crescente de ID, e retorna quantos foram removidos. `onBatch` (opcional) é chamada após cada lote.
+	// Com dryRun, apenas percorre e conta os clientes que seriam removidos. Em caso de erro, os lotes
+	// anteriores já foram removidos e estão incluídos na quantidade retornada.
+	PurgeArchived(before time.Time, dryRun bool, onBatch func(PurgeBatch)) (int, error)
+}
+
+// PurgeBatch descreve um lote de clientes arquivados removidos (ou que seriam removidos) por PurgeArchived.
+type PurgeBatch struct {
+	Count int // Quantidade de clientes do lote
+	MinID int // Menor ID do lote
+	MaxID int // Maior ID do lote
+}
+
+// newPurgeBatch descreve o lote de IDs em ordem crescente.
+func newPurgeBatch(ids []int) PurgeBatch {
+	return PurgeBatch{Count: len(ids), MinID: ids[0], MaxID: ids[len(ids)-1]}
+}
+
+// toArchivedClient converte um cliente para o formato de arquivamento (`archived_clients`),
+// registrando em DeletedAt o momento do arquivamento.
+func toArchivedClient(client models.Client, archivedAt time.Time) models.ArchivedClient {
+	return models.ArchivedClient{
+		ID:                int(client.ID), // Conversão de uint para int
+		Name:              client.Name,
+		WeightKg:          client.WeightKg,
+		Address:           client.Address,
+		Street:            client.Street,
+		Number:            client.Number,
+		Neighborhood:      client.Neighborhood,
+		Complement:        client.Complement,
+		City:              client.City,
+		State:             client.State,
+		Country:           client.Country,
+		PostalCode:        client.PostalCode,
+		Latitude:          client.Latitude,
+		Longitude:         client.Longitude,
+		Version:           client.Version,
+		CoordinatesStale:  client.CoordinatesStale,
+		LocationType:      client.LocationType,
+		GeocodeConfidence: client.GeocodeConfidence,
+		CreatedAt:         client.CreatedAt,
+		UpdatedAt:         client.UpdatedAt,
+		DeletedAt:         archivedAt,
+	}
+}
+
+// fromArchivedClient converte um cliente arquivado de volta para o modelo da tabela principal,
+// mantendo o ID e os timestamps originais.
+func fromArchivedClient(archived models.ArchivedClient) models.Client {
+	client := models.Client{
+		Name:              archived.Name,
+		WeightKg:          archived.WeightKg,
+		Address:           archived.Address,
+		Street:            archived.Street,
+		Number:            archived.Number,
+		Neighborhood:      archived.Neighborhood,
+		Complement:        archived.Complement,
+		City:              archived.City,
+		State:             archived.State,
+		Country:           archived.Country,
+		PostalCode:        archived.PostalCode,
+		Latitude:          archived.Latitude,
+		Longitude:         archived.Longitude,
+		Version:           archived.Version,
+		CoordinatesStale:  archived.CoordinatesStale,
+		LocationType:      archived.LocationType,
+		GeocodeConfidence: archived.GeocodeConfidence,
+	}
+	if client.Version == 0 {
+		client.Version = 1
+	}
+	client.ID = uint(archived.ID) // Conversão de int para uint
+	client.CreatedAt = archived.CreatedAt
+	client.UpdatedAt = archived.UpdatedAt
+	return client
+}
+
+// addressSearchScan é quantas linhas de cada tabela SearchAddresses lê para cada endereço pedido, já que
+// muitas entregas costumam compartilhar o mesmo endereço.
+const addressSearchScan = 5
+
+// addressSet acumula os endereços distintos encontrados por SearchAddresses, até o limite.
+type addressSet struct {
+	limit     int
+	seen      map[string]bool
+	addresses []models.ClientAddress
+}
+
+func newAddressSet(limit int) *addressSet {
+	return &addressSet{limit: limit, seen: map[string]bool{}, addresses: []models.ClientAddress{}}
+}
+
+// add inclui o endereço caso ele ainda não esteja no conjunto e retorna false quando o limite foi atingido.
+func (s *addressSet) add(address models.ClientAddress) bool {
+	if len(s.addresses) >= s.limit {
+		return false
+	}
+	key := geocoding.NormalizeAddress(address.Address)
+	if !s.seen[key] {
+		s.seen[key] = true
+		s.addresses = append(s.addresses, address)
+	}
+	return len(s.addresses) < s.limit
+}
+
+// clientAddress retorna os campos de endereço do cliente.
+func clientAddress(client models.Client) models.ClientAddress {
+	return models.ClientAddress{
+		Address:      client.Address,
+		Street:       client.Street,
+		Number:       client.Number,
+		Neighborhood: client.Neighborhood,
+		City:         client.City,
+		State:        client.State,
+		Country:      client.Country,
+		PostalCode:   client.PostalCode,
+		Latitude:     client.Latitude,
+		Longitude:    client.Longitude,
+
+		LocationType:      client.LocationType,
+		GeocodeConfidence: client.GeocodeConfidence,
+	}
+}
+
+// archivedClientAddress retorna os campos de endereço do cliente arquivado.
+func archivedClientAddress(archived models.ArchivedClient) models.ClientAddress {
+	return clientAddress(fromArchivedClient(archived))
+}
+
+// likePattern monta o padrão de LIKE que encontra `query` em qualquer posição, escapando os curingas
+// com `!` (usado em ESCAPE '!', aceito por MySQL, PostgreSQL e SQLite).
+func likePattern(query string) string {
+	escaped := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(query)
+	return "%" + escaped + "%"
+}
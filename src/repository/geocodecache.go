@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"myapi/models"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GeocodeCache define o armazenamento do cache de geocoding.
+//
+// As entradas são identificadas pelo provedor e pela chave do endereço normalizado. Entradas expiradas
+// são ignoradas nas consultas e descartadas na gravação de novas entradas.
+type GeocodeCache interface {
+	// Get retorna a entrada não expirada do endereço no provedor, com found igual a `false` quando não há.
+	Get(provider, addressKey string, now time.Time) (entry models.GeocodeCacheEntry, found bool, err error)
+
+	// Put grava a entrada, substituindo uma entrada existente para o mesmo provedor e endereço.
+	Put(entry models.GeocodeCacheEntry) error
+
+	// Invalidate remove as entradas do endereço em todos os provedores (ou todas as entradas, quando
+	// addressKey é vazio) e retorna a quantidade removida.
+	Invalidate(addressKey string) (int64, error)
+
+	// Count retorna a quantidade de entradas não expiradas.
+	Count(now time.Time) (int64, error)
+}
+
+// GormGeocodeCache implementa GeocodeCache na tabela `geocode_cache`.
+type GormGeocodeCache struct {
+	db *gorm.DB
+}
+
+// NewGormGeocodeCache cria um cache de geocoding na conexão GORM informada.
+func NewGormGeocodeCache(db *gorm.DB) *GormGeocodeCache {
+	return &GormGeocodeCache{db: db}
+}
+
+// Get busca a entrada não expirada do endereço.
+func (c *GormGeocodeCache) Get(provider, addressKey string, now time.Time) (models.GeocodeCacheEntry, bool, error) {
+	var entry models.GeocodeCacheEntry
+	err := c.db.Where("provider = ? AND address_key = ? AND expires_at > ?", provider, addressKey, now).First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.GeocodeCacheEntry{}, false, nil
+	}
+	if err != nil {
+		return models.GeocodeCacheEntry{}, false, fmt.Errorf("erro ao buscar o endereço no cache de geocoding: %w", err)
+	}
+	return entry, true, nil
+}
+
+// Put descarta as entradas expiradas e grava (ou substitui) a entrada do endereço.
+func (c *GormGeocodeCache) Put(entry models.GeocodeCacheEntry) error {
+	return c.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at <= ?", entry.CreatedAt).Delete(&models.GeocodeCacheEntry{}).Error; err != nil {
+			return fmt.Errorf("erro ao remover entradas expiradas do cache de geocoding: %w", err)
+		}
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&entry).Error; err != nil {
+			return fmt.Errorf("erro ao gravar o endereço no cache de geocoding: %w", err)
+		}
+		return nil
+	})
+}
+
+// Invalidate remove as entradas do endereço, ou todas as entradas quando addressKey é vazio.
+func (c *GormGeocodeCache) Invalidate(addressKey string) (int64, error) {
+	query := c.db.Where("1 = 1")
+	if addressKey != "" {
+		query = c.db.Where("address_key = ?", addressKey)
+	}
+	result := query.Delete(&models.GeocodeCacheEntry{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("erro ao invalidar o cache de geocoding: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// Count conta as entradas não expiradas.
+func (c *GormGeocodeCache) Count(now time.Time) (int64, error) {
+	var total int64
+	if err := c.db.Model(&models.GeocodeCacheEntry{}).Where("expires_at > ?", now).Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("erro ao contar as entradas do cache de geocoding: %w", err)
+	}
+	return total, nil
+}
+
+// geocodeCacheKey identifica uma entrada do cache em memória.
+type geocodeCacheKey struct {
+	provider   string
+	addressKey string
+}
+
+// MemoryGeocodeCache implementa GeocodeCache em memória, para testes e para o modo de desenvolvimento.
+type MemoryGeocodeCache struct {
+	mu      sync.Mutex
+	entries map[geocodeCacheKey]models.GeocodeCacheEntry
+}
+
+// NewMemoryGeocodeCache cria um cache de geocoding em memória vazio.
+func NewMemoryGeocodeCache() *MemoryGeocodeCache {
+	return &MemoryGeocodeCache{entries: make(map[geocodeCacheKey]models.GeocodeCacheEntry)}
+}
+
+// Get busca a entrada não expirada do endereço.
+func (c *MemoryGeocodeCache) Get(provider, addressKey string, now time.Time) (models.GeocodeCacheEntry, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[geocodeCacheKey{provider, addressKey}]
+	if !ok || !entry.ExpiresAt.After(now) {
+		return models.GeocodeCacheEntry{}, false, nil
+	}
+	return entry, true, nil
+}
+
+// Put descarta as entradas expiradas e grava (ou substitui) a entrada do endereço.
+func (c *MemoryGeocodeCache) Put(entry models.GeocodeCacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, existing := range c.entries {
+		if !existing.ExpiresAt.After(entry.CreatedAt) {
+			delete(c.entries, key)
+		}
+	}
+	entry.Results = append([]byte(nil), entry.Results...)
+	c.entries[geocodeCacheKey{entry.Provider, entry.AddressKey}] = entry
+	return nil
+}
+
+// Invalidate remove as entradas do endereço, ou todas as entradas quando addressKey é vazio.
+func (c *MemoryGeocodeCache) Invalidate(addressKey string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var removed int64
+	for key := range c.entries {
+		if addressKey == "" || key.addressKey == addressKey {
+			delete(c.entries, key)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// Count conta as entradas não expiradas.
+func (c *MemoryGeocodeCache) Count(now time.Time) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var total int64
+	for _, entry := range c.entries {
+		if entry.ExpiresAt.After(now) {
+			total++
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,498 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"myapi/models"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GeocodingJobStore define o armazenamento dos jobs de geocoding assíncrono e de seus itens.
+//
+// Cada item passa por pending → running → done/failed. Os workers reservam itens pendentes com
+// ClaimPending e registram o resultado com FinishItem; itens que falharam voltam para pending com RetryFailed.
+type GeocodingJobStore interface {
+	// CreateJob cria um job com um item pendente para cada cliente informado. Clientes repetidos ou que já
+	// estão na fila (com um item pendente ou em processamento em outro job) não recebem outro item.
+	CreateJob(clientIDs []uint) (models.GeocodingJob, error)
+
+	// FindJob busca o job com a quantidade de itens em cada situação. Retorna ErrNotFound caso ele não exista.
+	FindJob(id uint) (models.GeocodingJob, error)
+
+	// ListItems retorna os itens do job, ordenados por ID. Com status vazio, retorna todos os itens.
+	ListItems(jobID uint, status string) ([]models.GeocodingJobItem, error)
+
+	// ClaimPending marca até `limit` itens pendentes (os mais antigos primeiro) como em processamento,
+	// incrementa suas tentativas e os retorna.
+	ClaimPending(limit int) ([]models.GeocodingJobItem, error)
+
+	// FinishItem registra a nova situação do item e o motivo da falha (vazio quando não houve falha).
+	FinishItem(id uint, status, reason string) error
+
+	// RetryFailed devolve os itens do job que falharam para a fila e retorna a quantidade reenfileirada.
+	// Os clientes que já estão na fila de outro job ou que foram processados por um job mais novo não são
+	// reenfileirados. Retorna ErrNotFound caso o job não exista.
+	RetryFailed(jobID uint) (int64, error)
+
+	// RequeueExpired devolve para a fila os itens em processamento reservados há mais de `lease` (por
+	// exemplo, quando o servidor que os reservou foi encerrado durante o geocoding) e retorna a quantidade
+	// reenfileirada. Os itens reservados há menos tempo continuam com o worker que os reservou, mesmo que ele
+	// esteja em outra instância do servidor.
+	RequeueExpired(lease time.Duration) (int64, error)
+}
+
+// GormGeocodingJobStore implementa GeocodingJobStore nas tabelas `geocoding_jobs` e `geocoding_job_items`.
+type GormGeocodingJobStore struct {
+	db *gorm.DB
+}
+
+// NewGormGeocodingJobStore cria um armazenamento de jobs de geocoding na conexão GORM informada.
+func NewGormGeocodingJobStore(db *gorm.DB) *GormGeocodingJobStore {
+	return &GormGeocodingJobStore{db: db}
+}
+
+// CreateJob insere o job e os itens dos clientes que ainda não estão na fila na mesma transação.
+func (s *GormGeocodingJobStore) CreateJob(clientIDs []uint) (models.GeocodingJob, error) {
+	var job models.GeocodingJob
+	var items []models.GeocodingJobItem
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&job).Error; err != nil {
+			return fmt.Errorf("erro ao criar o job de geocoding: %w", err)
+		}
+
+		// Buscar os clientes que já têm um item pendente ou em processamento
+		queued, err := clientsWithItems(tx, clientIDs, "status IN ?", queuedItemStatuses)
+		if err != nil {
+			return err
+		}
+
+		items = newGeocodingJobItems(job.ID, clientIDs, queued, time.Time{})
+		if len(items) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(&items, DefaultArchiveBatchSize).Error; err != nil {
+			return fmt.Errorf("erro ao criar os itens do job de geocoding: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.GeocodingJob{}, err
+	}
+	job.Items.Pending = int64(len(items))
+	job.Summarize()
+	return job, nil
+}
+
+// FindJob busca o job e conta os itens por situação.
+func (s *GormGeocodingJobStore) FindJob(id uint) (models.GeocodingJob, error) {
+	var job models.GeocodingJob
+	if err := s.db.Where("id = ?", id).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.GeocodingJob{}, ErrNotFound
+		}
+		return models.GeocodingJob{}, fmt.Errorf("erro ao buscar o job de geocoding: %w", err)
+	}
+
+	var counts []struct {
+		Status string
+		Total  int64
+	}
+	err := s.db.Model(&models.GeocodingJobItem{}).Select("status, COUNT(*) AS total").
+		Where("job_id = ?", id).Group("status").Scan(&counts).Error
+	if err != nil {
+		return models.GeocodingJob{}, fmt.Errorf("erro ao contar os itens do job de geocoding: %w", err)
+	}
+	for _, count := range counts {
+		addItemCount(&job.Items, count.Status, count.Total)
+	}
+	job.Summarize()
+	return job, nil
+}
+
+// ListItems busca os itens do job, opcionalmente filtrados pela situação.
+func (s *GormGeocodingJobStore) ListItems(jobID uint, status string) ([]models.GeocodingJobItem, error) {
+	query := s.db.Where("job_id = ?", jobID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var items []models.GeocodingJobItem
+	if err := query.Order("id").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar os itens do job de geocoding: %w", err)
+	}
+	return items, nil
+}
+
+// ClaimPending reserva os itens pendentes mais antigos na mesma transação.
+//
+// No MySQL 8 e no PostgreSQL os itens são lidos com `FOR UPDATE SKIP LOCKED`, de modo que workers
+// concorrentes (inclusive de outras instâncias do servidor) reservam itens diferentes sem esperar uns
+// pelos outros; o SQLite já serializa as escritas. Cada item só é retornado se a atualização para
+// `running` de fato o alterou, descartando os que outro worker reservou entre a leitura e a atualização.
+func (s *GormGeocodingJobStore) ClaimPending(limit int) ([]models.GeocodingJobItem, error) {
+	var claimed []models.GeocodingJobItem
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("status = ?", models.GeocodingItemPending).Order("id").Limit(limit)
+		if name := tx.Dialector.Name(); name == "mysql" || name == "postgres" {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+		var items []models.GeocodingJobItem
+		if err := query.Find(&items).Error; err != nil {
+			return fmt.Errorf("erro ao buscar itens pendentes de geocoding: %w", err)
+		}
+
+		now := time.Now()
+		for _, item := range items {
+			result := tx.Model(&models.GeocodingJobItem{}).Where("id = ? AND status = ?", item.ID, models.GeocodingItemPending).
+				Updates(map[string]interface{}{
+					"status":     models.GeocodingItemRunning,
+					"attempts":   gorm.Expr("attempts + 1"),
+					"updated_at": now,
+				})
+			if result.Error != nil {
+				return fmt.Errorf("erro ao reservar o item de geocoding %d: %w", item.ID, result.Error)
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			item.Status = models.GeocodingItemRunning
+			item.Attempts++
+			item.UpdatedAt = now
+			claimed = append(claimed, item)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// FinishItem grava a situação e o motivo da falha do item.
+func (s *GormGeocodingJobStore) FinishItem(id uint, status, reason string) error {
+	result := s.db.Model(&models.GeocodingJobItem{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     status,
+		"error":      truncate(reason, 1024),
+		"updated_at": time.Now(),
+	})
+	if result.Error != nil {
+		return fmt.Errorf("erro ao gravar o resultado do item de geocoding: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RetryFailed devolve os itens que falharam para a fila na mesma transação, limpando o motivo da falha.
+// Os itens dos clientes que estão na fila de outro job ou que foram processados por um job mais novo
+// continuam como falha.
+func (s *GormGeocodingJobStore) RetryFailed(jobID uint) (int64, error) {
+	var requeued int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", jobID).First(&models.GeocodingJob{}).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return fmt.Errorf("erro ao buscar o job de geocoding: %w", err)
+		}
+
+		var failed []models.GeocodingJobItem
+		if err := tx.Where("job_id = ? AND status = ?", jobID, models.GeocodingItemFailed).Order("id").Find(&failed).Error; err != nil {
+			return fmt.Errorf("erro ao buscar os itens do job de geocoding que falharam: %w", err)
+		}
+		clientIDs := make([]uint, len(failed))
+		for i, item := range failed {
+			clientIDs[i] = item.ClientID
+		}
+		superseded, err := clientsWithItems(tx, clientIDs, "job_id <> ? AND (status IN ? OR job_id > ?)", jobID, queuedItemStatuses, jobID)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]uint, 0, len(failed))
+		for _, item := range failed {
+			if !superseded[item.ClientID] {
+				ids = append(ids, item.ID)
+			}
+		}
+		now := time.Now()
+		for start := 0; start < len(ids); start += DefaultArchiveBatchSize {
+			result := tx.Model(&models.GeocodingJobItem{}).
+				Where("id IN ? AND status = ?", ids[start:min(start+DefaultArchiveBatchSize, len(ids))], models.GeocodingItemFailed).
+				Updates(map[string]interface{}{"status": models.GeocodingItemPending, "error": "", "updated_at": now})
+			if result.Error != nil {
+				return fmt.Errorf("erro ao reenfileirar os itens do job de geocoding: %w", result.Error)
+			}
+			requeued += result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return requeued, nil
+}
+
+// RequeueExpired devolve para a fila os itens em processamento cuja reserva expirou. A reserva começa em
+// `updated_at`, gravado por ClaimPending, e só é renovada quando o item volta para a fila.
+func (s *GormGeocodingJobStore) RequeueExpired(lease time.Duration) (int64, error) {
+	now := time.Now()
+	result := s.db.Model(&models.GeocodingJobItem{}).
+		Where("status = ? AND updated_at <= ?", models.GeocodingItemRunning, now.Add(-lease)).
+		Updates(map[string]interface{}{"status": models.GeocodingItemPending, "updated_at": now})
+	if result.Error != nil {
+		return 0, fmt.Errorf("erro ao reenfileirar os itens de geocoding com a reserva expirada: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// MemoryGeocodingJobStore implementa GeocodingJobStore em memória, para testes e para o modo de desenvolvimento.
+type MemoryGeocodingJobStore struct {
+	mu         sync.Mutex
+	nextJobID  uint
+	nextItemID uint
+	jobs       map[uint]models.GeocodingJob
+	items      map[uint]models.GeocodingJobItem
+}
+
+// NewMemoryGeocodingJobStore cria um armazenamento de jobs de geocoding em memória vazio.
+func NewMemoryGeocodingJobStore() *MemoryGeocodingJobStore {
+	return &MemoryGeocodingJobStore{
+		nextJobID:  1,
+		nextItemID: 1,
+		jobs:       make(map[uint]models.GeocodingJob),
+		items:      make(map[uint]models.GeocodingJobItem),
+	}
+}
+
+// CreateJob cria o job e um item pendente para cada cliente que ainda não está na fila.
+func (s *MemoryGeocodingJobStore) CreateJob(clientIDs []uint) (models.GeocodingJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	job := models.GeocodingJob{ID: s.nextJobID, CreatedAt: now, UpdatedAt: now}
+	s.jobs[job.ID] = job
+	s.nextJobID++
+
+	queued := make(map[uint]bool)
+	for _, item := range s.items {
+		if isQueued(item) {
+			queued[item.ClientID] = true
+		}
+	}
+	items := newGeocodingJobItems(job.ID, clientIDs, queued, now)
+	for _, item := range items {
+		item.ID = s.nextItemID
+		s.items[item.ID] = item
+		s.nextItemID++
+	}
+	job.Items.Pending = int64(len(items))
+	job.Summarize()
+	return job, nil
+}
+
+// FindJob busca o job e conta os itens por situação.
+func (s *MemoryGeocodingJobStore) FindJob(id uint) (models.GeocodingJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return models.GeocodingJob{}, ErrNotFound
+	}
+	for _, item := range s.items {
+		if item.JobID == id {
+			addItemCount(&job.Items, item.Status, 1)
+		}
+	}
+	job.Summarize()
+	return job, nil
+}
+
+// ListItems retorna os itens do job, opcionalmente filtrados pela situação.
+func (s *MemoryGeocodingJobStore) ListItems(jobID uint, status string) ([]models.GeocodingJobItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := []models.GeocodingJobItem{}
+	for _, item := range s.sortedItems() {
+		if item.JobID == jobID && (status == "" || item.Status == status) {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// ClaimPending reserva os itens pendentes mais antigos.
+func (s *MemoryGeocodingJobStore) ClaimPending(limit int) ([]models.GeocodingJobItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var claimed []models.GeocodingJobItem
+	for _, item := range s.sortedItems() {
+		if len(claimed) == limit {
+			break
+		}
+		if item.Status != models.GeocodingItemPending {
+			continue
+		}
+		item.Status = models.GeocodingItemRunning
+		item.Attempts++
+		item.UpdatedAt = time.Now()
+		s.items[item.ID] = item
+		claimed = append(claimed, item)
+	}
+	return claimed, nil
+}
+
+// FinishItem grava a situação e o motivo da falha do item.
+func (s *MemoryGeocodingJobStore) FinishItem(id uint, status, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[id]
+	if !ok {
+		return ErrNotFound
+	}
+	item.Status = status
+	item.Error = truncate(reason, 1024)
+	item.UpdatedAt = time.Now()
+	s.items[id] = item
+	return nil
+}
+
+// RetryFailed devolve os itens que falharam para a fila, exceto os dos clientes que estão na fila de outro
+// job ou que foram processados por um job mais novo.
+func (s *MemoryGeocodingJobStore) RetryFailed(jobID uint) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[jobID]; !ok {
+		return 0, ErrNotFound
+	}
+	superseded := make(map[uint]bool)
+	for _, item := range s.items {
+		if item.JobID != jobID && (isQueued(item) || item.JobID > jobID) {
+			superseded[item.ClientID] = true
+		}
+	}
+	return s.requeue(func(item models.GeocodingJobItem) bool {
+		return item.JobID == jobID && item.Status == models.GeocodingItemFailed && !superseded[item.ClientID]
+	}), nil
+}
+
+// RequeueExpired devolve para a fila os itens em processamento cuja reserva expirou.
+func (s *MemoryGeocodingJobStore) RequeueExpired(lease time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiry := time.Now().Add(-lease)
+	return s.requeue(func(item models.GeocodingJobItem) bool {
+		return item.Status == models.GeocodingItemRunning && !item.UpdatedAt.After(expiry)
+	}), nil
+}
+
+// requeue devolve para a fila os itens selecionados. Deve ser chamado com o mutex bloqueado.
+func (s *MemoryGeocodingJobStore) requeue(selected func(models.GeocodingJobItem) bool) int64 {
+	var requeued int64
+	for id, item := range s.items {
+		if selected(item) {
+			item.Status = models.GeocodingItemPending
+			item.Error = ""
+			item.UpdatedAt = time.Now()
+			s.items[id] = item
+			requeued++
+		}
+	}
+	return requeued
+}
+
+// sortedItems retorna todos os itens ordenados por ID. Deve ser chamado com o mutex bloqueado.
+func (s *MemoryGeocodingJobStore) sortedItems() []models.GeocodingJobItem {
+	items := make([]models.GeocodingJobItem, 0, len(s.items))
+	for _, item := range s.items {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items
+}
+
+// queuedItemStatuses são as situações dos itens que ainda estão na fila.
+var queuedItemStatuses = []string{models.GeocodingItemPending, models.GeocodingItemRunning}
+
+// isQueued informa se o item ainda está na fila (pendente ou em processamento).
+func isQueued(item models.GeocodingJobItem) bool {
+	return item.Status == models.GeocodingItemPending || item.Status == models.GeocodingItemRunning
+}
+
+// clientsWithItems busca, em lotes de DefaultArchiveBatchSize, os clientes de `clientIDs` que têm algum item
+// que atende à condição informada.
+func clientsWithItems(tx *gorm.DB, clientIDs []uint, condition string, args ...interface{}) (map[uint]bool, error) {
+	found := make(map[uint]bool)
+	for start := 0; start < len(clientIDs); start += DefaultArchiveBatchSize {
+		var ids []uint
+		err := tx.Model(&models.GeocodingJobItem{}).Distinct("client_id").
+			Where("client_id IN ?", clientIDs[start:min(start+DefaultArchiveBatchSize, len(clientIDs))]).
+			Where(condition, args...).
+			Pluck("client_id", &ids).Error
+		if err != nil {
+			return nil, fmt.Errorf("erro ao buscar os clientes já enfileirados para geocoding: %w", err)
+		}
+		for _, id := range ids {
+			found[id] = true
+		}
+	}
+	return found, nil
+}
+
+// newGeocodingJobItems cria os itens pendentes do job, um por cliente, ignorando os clientes repetidos e os
+// que já estão na fila (`queued`).
+func newGeocodingJobItems(jobID uint, clientIDs []uint, queued map[uint]bool, now time.Time) []models.GeocodingJobItem {
+	items := make([]models.GeocodingJobItem, 0, len(clientIDs))
+	for _, clientID := range clientIDs {
+		if queued[clientID] {
+			continue
+		}
+		queued[clientID] = true
+		items = append(items, models.GeocodingJobItem{
+			JobID:     jobID,
+			ClientID:  clientID,
+			Status:    models.GeocodingItemPending,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+	return items
+}
+
+// addItemCount soma `total` à quantidade de itens da situação informada.
+func addItemCount(counts *models.GeocodingJobItemCounts, status string, total int64) {
+	switch status {
+	case models.GeocodingItemPending:
+		counts.Pending += total
+	case models.GeocodingItemRunning:
+		counts.Running += total
+	case models.GeocodingItemDone:
+		counts.Done += total
+	case models.GeocodingItemFailed:
+		counts.Failed += total
+	}
+}
+
+// truncate limita o texto a `size` bytes, sem cortar um caractere UTF-8 ao meio.
+func truncate(text string, size int) string {
+	if len(text) <= size {
+		return text
+	}
+	for size > 0 && !utf8.RuneStart(text[size]) {
+		size--
+	}
+	return text[:size]
+}
@@ -0,0 +1,378 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"myapi/geocoding"
+	"myapi/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultArchiveBatchSize é o tamanho padrão dos lotes usados por ArchiveAll e PurgeArchived.
+const DefaultArchiveBatchSize = 1000
+
+// GormRepository implementa DeliveryRepository sobre uma conexão GORM (MySQL, PostgreSQL ou SQLite).
+type GormRepository struct {
+	db *gorm.DB
+
+	// ArchiveBatchSize é a quantidade de clientes lida e arquivada (ou removida) por lote em ArchiveAll e PurgeArchived.
+	ArchiveBatchSize int
+}
+
+// NewGormRepository cria um repositório que persiste os clientes na conexão GORM informada.
+//
+// Exemplo de uso:
+//
+//	config.ConnectDB()
+//	repo := repository.NewGormRepository(config.DB)
+func NewGormRepository(db *gorm.DB) *GormRepository {
+	return &GormRepository{db: db, ArchiveBatchSize: DefaultArchiveBatchSize}
+}
+
+// Create insere um novo cliente no banco de dados.
+func (r *GormRepository) Create(client *models.Client) error {
+	client.Version = 1
+	if err := r.db.Create(client).Error; err != nil {
+		log.Println("Erro ao inserir o cliente no MySQL:", err)
+		return err
+	}
+	return nil
+}
+
+// FindByID busca um cliente pelo ID na tabela principal.
+func (r *GormRepository) FindByID(id uint) (models.Client, error) {
+	var client models.Client
+	if err := r.db.Where("id = ?", id).First(&client).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Client{}, ErrNotFound
+		}
+		return models.Client{}, fmt.Errorf("erro ao buscar cliente: %w", err)
+	}
+	return client, nil
+}
+
+// List conta e busca os clientes ordenados por ID, aplicando o filtro de cidade e a paginação.
+func (r *GormRepository) List(filter ClientFilter) ([]models.Client, int64, error) {
+	// Conta o total de clientes com filtro de cidade, se fornecido
+	var total int64
+	countQuery := r.db.Model(&models.Client{})
+	if filter.City != "" {
+		countQuery = countQuery.Where("city = ?", filter.City)
+	}
+	if err := countQuery.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("erro ao contar clientes: %w", err)
+	}
+
+	// Busca os clientes com o limit e offset definidos
+	var clients []models.Client
+	findQuery := r.db.Order("id").Offset(filter.Offset)
+	if filter.Limit > 0 {
+		findQuery = findQuery.Limit(filter.Limit)
+	}
+	if filter.City != "" {
+		findQuery = findQuery.Where("city = ?", filter.City)
+	}
+	if err := findQuery.Find(&clients).Error; err != nil {
+		return nil, 0, fmt.Errorf("erro ao buscar clientes: %w", err)
+	}
+
+	return clients, total, nil
+}
+
+// Update aplica os campos informados ao cliente e retorna os dados atualizados.
+// A atualização é condicional: o `UPDATE` filtra pela versão esperada (quando informada) e incrementa
+// a versão na mesma instrução, de modo que duas alterações concorrentes não se sobrescrevem.
+func (r *GormRepository) Update(id uint, expectedVersion uint, fields map[string]interface{}) (models.Client, error) {
+	updates := make(map[string]interface{}, len(fields)+1)
+	for name, value := range fields {
+		updates[name] = value
+	}
+	updates["Version"] = gorm.Expr("version + 1")
+
+	// Executa a atualização no banco de dados
+	query := r.db.Model(&models.Client{}).Where("id = ?", id)
+	if expectedVersion > 0 {
+		query = query.Where("version = ?", expectedVersion)
+	}
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return models.Client{}, result.Error
+	}
+
+	// Nenhuma linha alterada: o cliente não existe ou está em outra versão
+	if result.RowsAffected == 0 {
+		if _, err := r.FindByID(id); err != nil {
+			return models.Client{}, err
+		}
+		return models.Client{}, ErrVersionMismatch
+	}
+
+	// Busca os dados atualizados do cliente
+	updated, err := r.FindByID(id)
+	if err != nil {
+		return models.Client{}, fmt.Errorf("erro ao buscar cliente atualizado: %w", err)
+	}
+	return updated, nil
+}
+
+// ArchiveByID arquiva e exclui um cliente específico com base no ID fornecido.
+// A cópia para `archived_clients` e a exclusão em `clients` são feitas na mesma transação:
+// se qualquer etapa falhar, nenhuma das duas tabelas é alterada.
+func (r *GormRepository) ArchiveByID(id uint, expectedVersion uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var client models.Client
+		if err := tx.Where("id = ?", id).First(&client).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			log.Println("Erro ao encontrar o cliente:", err)
+			return fmt.Errorf("erro ao buscar cliente: %w", err)
+		}
+		if expectedVersion > 0 && client.Version != expectedVersion {
+			return ErrVersionMismatch
+		}
+
+		// Inserir na tabela de arquivados
+		archivedClient := toArchivedClient(client, time.Now())
+		if err := tx.Table("archived_clients").Create(&archivedClient).Error; err != nil {
+			log.Println("Erro ao arquivar cliente:", err)
+			return fmt.Errorf("erro ao arquivar o cliente com ID %d: %w", id, err)
+		}
+
+		// Deletar o cliente da tabela principal
+		result := tx.Table("clients").Delete(&models.Client{}, "id = ? AND version = ?", id, client.Version)
+		if result.Error != nil {
+			log.Println("Erro ao deletar o cliente:", result.Error)
+			return fmt.Errorf("erro ao deletar o cliente com ID %d: %w", id, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			// O cliente foi alterado ou removido por outra operação entre a leitura e a exclusão
+			if expectedVersion > 0 {
+				return ErrVersionMismatch
+			}
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+// ArchiveAll arquiva e exclui todos os clientes da tabela principal, retornando quantos foram arquivados.
+//
+// Os clientes são percorridos em lotes de ArchiveBatchSize registros, ordenados por ID, de modo que a memória
+// utilizada não depende do tamanho da tabela. Todos os lotes são processados em uma única transação:
+// se qualquer lote falhar, nenhum cliente é arquivado ou excluído.
+func (r *GormRepository) ArchiveAll() (int64, error) {
+	batchSize := r.ArchiveBatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultArchiveBatchSize
+	}
+
+	var archived int64
+	archivedAt := time.Now()
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var lastID uint
+		for {
+			// Buscar o próximo lote de clientes na tabela principal
+			var clients []models.Client
+			if err := tx.Where("id > ?", lastID).Order("id").Limit(batchSize).Find(&clients).Error; err != nil {
+				log.Println("Erro ao buscar clientes para arquivar:", err)
+				return fmt.Errorf("erro ao buscar clientes para arquivar: %w", err)
+			}
+			if len(clients) == 0 {
+				return nil
+			}
+
+			// Inserir o lote na tabela de arquivados
+			archivedClients := make([]models.ArchivedClient, 0, len(clients))
+			ids := make([]uint, 0, len(clients))
+			for _, client := range clients {
+				archivedClients = append(archivedClients, toArchivedClient(client, archivedAt))
+				ids = append(ids, client.ID)
+			}
+			if err := tx.Table("archived_clients").Create(&archivedClients).Error; err != nil {
+				log.Println("Erro ao arquivar lote de clientes:", err)
+				return fmt.Errorf("erro ao arquivar clientes a partir do ID %d: %w", ids[0], err)
+			}
+
+			// Deletar o lote da tabela principal
+			if err := tx.Table("clients").Where("id IN ?", ids).Delete(&models.Client{}).Error; err != nil {
+				log.Println("Erro ao deletar lote de clientes:", err)
+				return fmt.Errorf("erro ao deletar clientes a partir do ID %d: %w", ids[0], err)
+			}
+
+			archived += int64(len(clients))
+			lastID = ids[len(ids)-1]
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if archived == 0 {
+		log.Println("Nenhum cliente encontrado para excluir")
+	}
+	return archived, nil
+}
+
+// ListArchived conta e busca os clientes arquivados aplicando o filtro de cidade e a paginação.
+func (r *GormRepository) ListArchived(filter ClientFilter) ([]models.ArchivedClient, int64, error) {
+	// Conta o total de clientes arquivados com filtro de cidade, se fornecido
+	var total int64
+	countQuery := r.db.Table("archived_clients")
+	if filter.City != "" {
+		countQuery = countQuery.Where("city = ?", filter.City)
+	}
+	if err := countQuery.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("erro ao contar clientes arquivados: %w", err)
+	}
+
+	// Busca os clientes arquivados com o limit e offset definidos
+	var archived []models.ArchivedClient
+	findQuery := r.db.Table("archived_clients").Order("id").Offset(filter.Offset)
+	if filter.Limit > 0 {
+		findQuery = findQuery.Limit(filter.Limit)
+	}
+	if filter.City != "" {
+		findQuery = findQuery.Where("city = ?", filter.City)
+	}
+	if err := findQuery.Find(&archived).Error; err != nil {
+		return nil, 0, fmt.Errorf("erro ao buscar clientes arquivados: %w", err)
+	}
+
+	return archived, total, nil
+}
+
+// RestoreArchived move um cliente arquivado de volta para a tabela principal com o ID e os timestamps originais.
+// A inserção em `clients` e a exclusão em `archived_clients` são feitas na mesma transação.
+func (r *GormRepository) RestoreArchived(id uint) (models.Client, error) {
+	var restored models.Client
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var archived models.ArchivedClient
+		if err := tx.Table("archived_clients").Where("id = ?", id).First(&archived).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			log.Println("Erro ao encontrar o cliente arquivado:", err)
+			return fmt.Errorf("erro ao buscar cliente arquivado: %w", err)
+		}
+
+		// O ID original pode ter sido reutilizado por um novo cliente
+		var existing int64
+		if err := tx.Model(&models.Client{}).Where("id = ?", id).Count(&existing).Error; err != nil {
+			return fmt.Errorf("erro ao verificar o ID %d na tabela de clientes: %w", id, err)
+		}
+		if existing > 0 {
+			return fmt.Errorf("o ID %d já está em uso por outro cliente: %w", id, ErrConflict)
+		}
+
+		// Inserir de volta na tabela principal, preservando os timestamps originais
+		client := fromArchivedClient(archived)
+		if err := tx.Create(&client).Error; err != nil {
+			log.Println("Erro ao restaurar cliente:", err)
+			return fmt.Errorf("erro ao restaurar o cliente com ID %d: %w", id, err)
+		}
+
+		// Remover da tabela de arquivados
+		if err := tx.Table("archived_clients").Where("id = ?", id).Delete(&models.ArchivedClient{}).Error; err != nil {
+			log.Println("Erro ao remover cliente arquivado:", err)
+			return fmt.Errorf("erro ao remover o cliente arquivado com ID %d: %w", id, err)
+		}
+
+		restored = client
+		return nil
+	})
+	if err != nil {
+		return models.Client{}, err
+	}
+	return restored, nil
+}
+
+// ListIDsNeedingGeocoding busca os IDs dos clientes com latitude e longitude iguais a 0 ou com
+// coordenadas desatualizadas.
+func (r *GormRepository) ListIDsNeedingGeocoding() ([]uint, error) {
+	var ids []uint
+	query := r.db.Model(&models.Client{}).Where("(latitude = 0 AND longitude = 0) OR coordinates_stale = ?", true)
+	if err := query.Order("id").Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar clientes sem coordenadas: %w", err)
+	}
+	return ids, nil
+}
+
+// SearchAddresses busca os endereços em `clients` e, se o limite ainda não foi atingido, em `archived_clients`.
+// A comparação usa LOWER(address) LIKE, com os curingas da busca escapados.
+func (r *GormRepository) SearchAddresses(query string, limit int) ([]models.ClientAddress, error) {
+	found := newAddressSet(limit)
+	pattern := likePattern(geocoding.NormalizeAddress(query))
+	located := "(latitude <> 0 OR longitude <> 0) AND coordinates_stale = ?"
+
+	var clients []models.Client
+	if err := r.db.Where("LOWER(address) LIKE ? ESCAPE '!'", pattern).Where(located, false).
+		Order("updated_at DESC").Limit(limit * addressSearchScan).Find(&clients).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar endereços dos clientes: %w", err)
+	}
+	for _, client := range clients {
+		if !found.add(clientAddress(client)) {
+			return found.addresses, nil
+		}
+	}
+
+	var archived []models.ArchivedClient
+	if err := r.db.Where("LOWER(address) LIKE ? ESCAPE '!'", pattern).Where(located, false).
+		Order("updated_at DESC").Limit(limit * addressSearchScan).Find(&archived).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar endereços dos clientes arquivados: %w", err)
+	}
+	for _, client := range archived {
+		if !found.add(archivedClientAddress(client)) {
+			break
+		}
+	}
+	return found.addresses, nil
+}
+
+// PurgeArchived remove de `archived_clients` os registros arquivados antes de `before`.
+//
+// Os registros são percorridos em lotes de ArchiveBatchSize IDs, ordenados por ID, e cada lote é lido e
+// removido na sua própria transação: a memória utilizada e o tempo de cada transação não dependem do
+// tamanho da tabela.
+func (r *GormRepository) PurgeArchived(before time.Time, dryRun bool, onBatch func(PurgeBatch)) (int, error) {
+	batchSize := r.ArchiveBatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultArchiveBatchSize
+	}
+
+	purged, lastID := 0, 0
+	for {
+		var ids []int
+		err := r.db.Transaction(func(tx *gorm.DB) error {
+			// Buscar o próximo lote de clientes arquivados antes do corte
+			if err := tx.Table("archived_clients").Where("deleted_at < ? AND id > ?", before, lastID).
+				Order("id").Limit(batchSize).Pluck("id", &ids).Error; err != nil {
+				return fmt.Errorf("erro ao buscar clientes arquivados para remover: %w", err)
+			}
+			if len(ids) == 0 || dryRun {
+				return nil
+			}
+
+			if err := tx.Table("archived_clients").Where("id IN ?", ids).Delete(&models.ArchivedClient{}).Error; err != nil {
+				log.Println("Erro ao remover lote de clientes arquivados:", err)
+				return fmt.Errorf("erro ao remover clientes arquivados a partir do ID %d: %w", ids[0], err)
+			}
+			return nil
+		})
+		if err != nil {
+			return purged, err
+		}
+		if len(ids) == 0 {
+			return purged, nil
+		}
+
+		purged += len(ids)
+		lastID = ids[len(ids)-1]
+		if onBatch != nil {
+			onBatch(newPurgeBatch(ids))
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"myapi/models"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// IdempotencyStore define o armazenamento das chaves de idempotência (cabeçalho Idempotency-Key).
+//
+// Uma chave é primeiro reservada, enquanto a requisição original é processada, e depois concluída com a
+// resposta enviada. Chaves expiradas são descartadas e podem ser reutilizadas.
+type IdempotencyStore interface {
+	// Reserve registra a chave como em andamento. Caso já exista um registro não expirado para a chave,
+	// ele é retornado com reserved igual a `false` e nada é alterado. Uma reserva ainda em andamento criada
+	// há mais de `lease` foi abandonada (por exemplo, o servidor caiu durante a requisição original) e é
+	// substituída pela nova; com `lease` igual a 0 as reservas só são descartadas ao expirar.
+	Reserve(record models.IdempotencyKey, lease time.Duration) (existing models.IdempotencyKey, reserved bool, err error)
+
+	// Complete grava a resposta da requisição original para a chave reservada.
+	Complete(key string, statusCode int, contentType string, body []byte) error
+
+	// Release remove a reserva da chave, permitindo que a requisição seja repetida (por exemplo, após um erro interno).
+	Release(key string) error
+}
+
+// GormIdempotencyStore implementa IdempotencyStore na tabela `idempotency_keys`.
+type GormIdempotencyStore struct {
+	db *gorm.DB
+}
+
+// NewGormIdempotencyStore cria um armazenamento de chaves de idempotência na conexão GORM informada.
+func NewGormIdempotencyStore(db *gorm.DB) *GormIdempotencyStore {
+	return &GormIdempotencyStore{db: db}
+}
+
+// Reserve descarta as chaves expiradas e a reserva abandonada da chave e insere a nova chave, retornando o
+// registro existente caso ela já esteja em uso.
+func (s *GormIdempotencyStore) Reserve(record models.IdempotencyKey, lease time.Duration) (models.IdempotencyKey, bool, error) {
+	var existing models.IdempotencyKey
+	reserved := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at <= ?", record.CreatedAt).Delete(&models.IdempotencyKey{}).Error; err != nil {
+			return fmt.Errorf("erro ao remover chaves de idempotência expiradas: %w", err)
+		}
+		if lease > 0 {
+			if err := tx.Where("idempotency_key = ? AND status_code = 0 AND created_at <= ?", record.Key, record.CreatedAt.Add(-lease)).
+				Delete(&models.IdempotencyKey{}).Error; err != nil {
+				return fmt.Errorf("erro ao remover a reserva abandonada da chave de idempotência: %w", err)
+			}
+		}
+
+		err := tx.Where("idempotency_key = ?", record.Key).First(&existing).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("erro ao buscar a chave de idempotência: %w", err)
+		}
+
+		if err := tx.Create(&record).Error; err != nil {
+			return fmt.Errorf("erro ao reservar a chave de idempotência: %w", err)
+		}
+		reserved = true
+		return nil
+	})
+	if err != nil {
+		// Outra requisição pode ter reservado a mesma chave entre a busca e a inserção
+		if lookupErr := s.db.Where("idempotency_key = ?", record.Key).First(&existing).Error; lookupErr == nil {
+			return existing, false, nil
+		}
+		return models.IdempotencyKey{}, false, err
+	}
+	if reserved {
+		return record, true, nil
+	}
+	return existing, false, nil
+}
+
+// Complete grava a resposta da requisição original.
+func (s *GormIdempotencyStore) Complete(key string, statusCode int, contentType string, body []byte) error {
+	result := s.db.Model(&models.IdempotencyKey{}).Where("idempotency_key = ?", key).Updates(map[string]interface{}{
+		"status_code":  statusCode,
+		"content_type": contentType,
+		"body":         body,
+	})
+	if result.Error != nil {
+		return fmt.Errorf("erro ao gravar a resposta da chave de idempotência: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Release remove a reserva da chave.
+func (s *GormIdempotencyStore) Release(key string) error {
+	if err := s.db.Where("idempotency_key = ?", key).Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return fmt.Errorf("erro ao liberar a chave de idempotência: %w", err)
+	}
+	return nil
+}
+
+// MemoryIdempotencyStore implementa IdempotencyStore em memória, para testes e para o modo de desenvolvimento.
+type MemoryIdempotencyStore struct {
+	mu   sync.Mutex
+	keys map[string]models.IdempotencyKey
+}
+
+// NewMemoryIdempotencyStore cria um armazenamento de chaves de idempotência em memória vazio.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{keys: make(map[string]models.IdempotencyKey)}
+}
+
+// Reserve descarta as chaves expiradas e reserva a nova chave, caso ela não esteja em uso ou a reserva
+// existente tenha sido abandonada.
+func (s *MemoryIdempotencyStore) Reserve(record models.IdempotencyKey, lease time.Duration) (models.IdempotencyKey, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, existing := range s.keys {
+		if !existing.ExpiresAt.After(record.CreatedAt) {
+			delete(s.keys, key)
+		}
+	}
+	existing, ok := s.keys[record.Key]
+	abandoned := lease > 0 && existing.InProgress() && !existing.CreatedAt.After(record.CreatedAt.Add(-lease))
+	if ok && !abandoned {
+		return existing, false, nil
+	}
+	s.keys[record.Key] = record
+	return record, true, nil
+}
+
+// Complete grava a resposta da requisição original.
+func (s *MemoryIdempotencyStore) Complete(key string, statusCode int, contentType string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.keys[key]
+	if !ok {
+		return ErrNotFound
+	}
+	record.StatusCode = statusCode
+	record.ContentType = contentType
+	record.Body = append([]byte(nil), body...)
+	s.keys[key] = record
+	return nil
+}
+
+// Release remove a reserva da chave.
+func (s *MemoryIdempotencyStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, key)
+	return nil
+}
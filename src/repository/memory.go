@@ -0,0 +1,301 @@
+package repository
+
+import (
+	"fmt"
+	"myapi/geocoding"
+	"myapi/models"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementa DeliveryRepository mantendo os clientes em memória.
+// É útil para testes e para executar a API sem um banco de dados disponível.
+// Todas as operações são protegidas por um mutex e podem ser usadas concorrentemente.
+type MemoryRepository struct {
+	mu       sync.RWMutex
+	nextID   uint
+	clients  map[uint]models.Client
+	archived map[int]models.ArchivedClient
+}
+
+// NewMemoryRepository cria um repositório em memória vazio.
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		nextID:   1,
+		clients:  make(map[uint]models.Client),
+		archived: make(map[int]models.ArchivedClient),
+	}
+}
+
+// Create insere um novo cliente, atribuindo o próximo ID disponível e os timestamps.
+func (r *MemoryRepository) Create(client *models.Client) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	client.ID = r.nextID
+	client.Version = 1
+	client.CreatedAt = now
+	client.UpdatedAt = now
+	r.clients[client.ID] = *client
+	r.nextID++
+	return nil
+}
+
+// FindByID busca um cliente pelo ID.
+func (r *MemoryRepository) FindByID(id uint) (models.Client, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	client, ok := r.clients[id]
+	if !ok {
+		return models.Client{}, ErrNotFound
+	}
+	return client, nil
+}
+
+// List retorna os clientes ordenados por ID, aplicando o filtro de cidade e a paginação.
+func (r *MemoryRepository) List(filter ClientFilter) ([]models.Client, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matched := make([]models.Client, 0, len(r.clients))
+	for _, client := range r.clients {
+		if filter.City != "" && client.City != filter.City {
+			continue
+		}
+		matched = append(matched, client)
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID < matched[j].ID })
+
+	total := int64(len(matched))
+	if filter.Offset >= len(matched) {
+		return []models.Client{}, total, nil
+	}
+	matched = matched[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(matched) {
+		matched = matched[:filter.Limit]
+	}
+	return matched, total, nil
+}
+
+// Update aplica os campos informados ao cliente usando reflexão sobre models.Client.
+func (r *MemoryRepository) Update(id uint, expectedVersion uint, fields map[string]interface{}) (models.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	client, ok := r.clients[id]
+	if !ok {
+		return models.Client{}, ErrNotFound
+	}
+	if expectedVersion > 0 && client.Version != expectedVersion {
+		return models.Client{}, ErrVersionMismatch
+	}
+
+	target := reflect.ValueOf(&client).Elem()
+	for name, value := range fields {
+		field := target.FieldByName(name)
+		if !field.IsValid() || !field.CanSet() {
+			return models.Client{}, fmt.Errorf("campo desconhecido para atualização: %s", name)
+		}
+		if value == nil {
+			field.Set(reflect.Zero(field.Type()))
+			continue
+		}
+		newValue := reflect.ValueOf(value)
+		if !newValue.Type().ConvertibleTo(field.Type()) {
+			return models.Client{}, fmt.Errorf("tipo inválido para o campo %s", name)
+		}
+		field.Set(newValue.Convert(field.Type()))
+	}
+
+	client.Version++
+	client.UpdatedAt = time.Now()
+	r.clients[id] = client
+	return client, nil
+}
+
+// ArchiveByID move o cliente para a coleção de arquivados. Assim como a chave primária de
+// `archived_clients` no GormRepository, um ID já arquivado retorna um erro com ErrConflict.
+func (r *MemoryRepository) ArchiveByID(id uint, expectedVersion uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	client, ok := r.clients[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if expectedVersion > 0 && client.Version != expectedVersion {
+		return ErrVersionMismatch
+	}
+	if _, exists := r.archived[int(id)]; exists {
+		return fmt.Errorf("o cliente com ID %d já está arquivado: %w", id, ErrConflict)
+	}
+	r.archived[int(id)] = toArchivedClient(client, time.Now())
+	delete(r.clients, id)
+	return nil
+}
+
+// ArchiveAll move todos os clientes para a coleção de arquivados. Se algum ID já estiver arquivado,
+// retorna um erro com ErrConflict sem arquivar nenhum cliente.
+func (r *MemoryRepository) ArchiveAll() (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id := range r.clients {
+		if _, exists := r.archived[int(id)]; exists {
+			return 0, fmt.Errorf("o cliente com ID %d já está arquivado: %w", id, ErrConflict)
+		}
+	}
+
+	archivedAt := time.Now()
+	archived := int64(len(r.clients))
+	for id, client := range r.clients {
+		r.archived[int(id)] = toArchivedClient(client, archivedAt)
+		delete(r.clients, id)
+	}
+	return archived, nil
+}
+
+// ListArchived retorna os clientes arquivados ordenados por ID, aplicando o filtro de cidade e a paginação.
+func (r *MemoryRepository) ListArchived(filter ClientFilter) ([]models.ArchivedClient, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matched := make([]models.ArchivedClient, 0, len(r.archived))
+	for _, client := range r.archived {
+		if filter.City != "" && client.City != filter.City {
+			continue
+		}
+		matched = append(matched, client)
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID < matched[j].ID })
+
+	total := int64(len(matched))
+	if filter.Offset >= len(matched) {
+		return []models.ArchivedClient{}, total, nil
+	}
+	matched = matched[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(matched) {
+		matched = matched[:filter.Limit]
+	}
+	return matched, total, nil
+}
+
+// RestoreArchived move o cliente arquivado de volta para a coleção principal.
+func (r *MemoryRepository) RestoreArchived(id uint) (models.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	archived, ok := r.archived[int(id)]
+	if !ok {
+		return models.Client{}, ErrNotFound
+	}
+	if _, used := r.clients[id]; used {
+		return models.Client{}, fmt.Errorf("o ID %d já está em uso por outro cliente: %w", id, ErrConflict)
+	}
+
+	client := fromArchivedClient(archived)
+	r.clients[id] = client
+	delete(r.archived, int(id))
+	if id >= r.nextID {
+		r.nextID = id + 1
+	}
+	return client, nil
+}
+
+// ListIDsNeedingGeocoding retorna os IDs dos clientes sem coordenadas ou com coordenadas desatualizadas,
+// em ordem crescente.
+func (r *MemoryRepository) ListIDsNeedingGeocoding() ([]uint, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := []uint{}
+	for id, client := range r.clients {
+		if client.NeedsGeocoding() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// SearchAddresses busca os endereços nos clientes ativos e arquivados em memória.
+func (r *MemoryRepository) SearchAddresses(query string, limit int) ([]models.ClientAddress, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	query = geocoding.NormalizeAddress(query)
+	matches := func(address string, located, stale bool) bool {
+		return located && !stale && strings.Contains(geocoding.NormalizeAddress(address), query)
+	}
+
+	clients := []models.Client{}
+	for _, client := range r.clients {
+		if matches(client.Address, client.HasCoordinates(), client.CoordinatesStale) {
+			clients = append(clients, client)
+		}
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		if !clients[i].UpdatedAt.Equal(clients[j].UpdatedAt) {
+			return clients[i].UpdatedAt.After(clients[j].UpdatedAt)
+		}
+		return clients[i].ID > clients[j].ID
+	})
+
+	archived := []models.ArchivedClient{}
+	for _, client := range r.archived {
+		if matches(client.Address, client.Latitude != 0 || client.Longitude != 0, client.CoordinatesStale) {
+			archived = append(archived, client)
+		}
+	}
+	sort.Slice(archived, func(i, j int) bool {
+		if !archived[i].UpdatedAt.Equal(archived[j].UpdatedAt) {
+			return archived[i].UpdatedAt.After(archived[j].UpdatedAt)
+		}
+		return archived[i].ID > archived[j].ID
+	})
+
+	found := newAddressSet(limit)
+	for _, client := range clients {
+		if !found.add(clientAddress(client)) {
+			return found.addresses, nil
+		}
+	}
+	for _, client := range archived {
+		if !found.add(archivedClientAddress(client)) {
+			break
+		}
+	}
+	return found.addresses, nil
+}
+
+// PurgeArchived remove os clientes arquivados antes de `before`, informando `onBatch` em lotes de
+// DefaultArchiveBatchSize IDs.
+func (r *MemoryRepository) PurgeArchived(before time.Time, dryRun bool, onBatch func(PurgeBatch)) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := []int{}
+	for id, client := range r.archived {
+		if client.DeletedAt.Before(before) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	for start := 0; start < len(ids); start += DefaultArchiveBatchSize {
+		batch := ids[start:min(start+DefaultArchiveBatchSize, len(ids))]
+		if !dryRun {
+			for _, id := range batch {
+				delete(r.archived, id)
+			}
+		}
+		if onBatch != nil {
+			onBatch(newPurgeBatch(batch))
+		}
+	}
+	return len(ids), nil
+}
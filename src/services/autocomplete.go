@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"myapi/geocoding"
+	"myapi/models"
+	"myapi/repository"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limites do autocomplete de endereços.
+const (
+	AutocompleteMinLength    = 3  // Caracteres mínimos da busca (sem contar espaços extras)
+	AutocompleteDefaultLimit = 5  // Sugestões retornadas quando o limite não é informado
+	AutocompleteMaxLimit     = 20 // Maior limite aceito
+
+	// autocompleteCacheSize é a quantidade máxima de buscas mantidas no cache; ao atingi-la, as entradas
+	// expiradas são descartadas e, se ainda não houver espaço, o cache é esvaziado.
+	autocompleteCacheSize = 1000
+)
+
+// autocompleteEntry é uma resposta do autocomplete mantida em cache até expiresAt.
+type autocompleteEntry struct {
+	suggestions []models.AddressSuggestion
+	expiresAt   time.Time
+}
+
+// Autocompleter sugere endereços enquanto o usuário digita.
+//
+// Primeiro são buscados os endereços já cadastrados em clientes ativos e arquivados (com as coordenadas
+// que já foram usadas em entregas); quando eles não preenchem o limite, o provedor de geocoding completa
+// a lista. As respostas ficam em memória por `ttl`, de modo que as requisições repetidas enquanto o
+// usuário digita (ou apaga) não consultam o banco nem o provedor novamente.
+type Autocompleter struct {
+	repo     repository.DeliveryRepository
+	geocoder geocoding.Geocoder
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	entries map[string]autocompleteEntry
+}
+
+// NewAutocompleter cria o autocomplete de endereços.
+//
+// Parâmetros:
+// - repo (repository.DeliveryRepository): Repositório com os endereços dos clientes.
+// - geocoder (geocoding.Geocoder): Provedor consultado quando o histórico não preenche o limite.
+// - ttl (time.Duration): Tempo de vida das respostas em memória (0 desativa o cache).
+//
+// Exemplo de uso:
+//
+//	autocomplete := services.NewAutocompleter(repo, geocoder, settings.Geocoding.AutocompleteCacheTTL)
+func NewAutocompleter(repo repository.DeliveryRepository, geocoder geocoding.Geocoder, ttl time.Duration) *Autocompleter {
+	return &Autocompleter{
+		repo:     repo,
+		geocoder: geocoder,
+		ttl:      ttl,
+		entries:  map[string]autocompleteEntry{},
+	}
+}
+
+// Suggest retorna até `limit` sugestões para o texto digitado, com o histórico antes do provedor.
+// O texto deve ter ao menos AutocompleteMinLength caracteres (validado pelo controller).
+//
+// No histórico, os endereços que começam com o texto digitado vêm antes dos que apenas o contêm.
+// Sugestões do provedor que repetem um endereço do histórico são descartadas. Se o provedor falhar,
+// o histórico encontrado é retornado (sem ser armazenado no cache); sem histórico, o erro é retornado.
+//
+// Retorno:
+// - []models.AddressSuggestion: Sugestões encontradas (lista vazia quando não há nenhuma).
+// - error: Erro do repositório, ou ErrUpstream nas falhas do provedor.
+//
+// Exemplo de uso:
+//
+//	suggestions, err := autocomplete.Suggest(r.Context(), "rua tes", 5)
+func (a *Autocompleter) Suggest(ctx context.Context, query string, limit int) ([]models.AddressSuggestion, error) {
+	normalized := geocoding.NormalizeAddress(query)
+	key := fmt.Sprintf("%d|%s", limit, normalized)
+	if suggestions, ok := a.cached(key); ok {
+		return suggestions, nil
+	}
+
+	history, err := a.repo.SearchAddresses(normalized, limit)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		return hasAddressPrefix(history[i], normalized) && !hasAddressPrefix(history[j], normalized)
+	})
+
+	suggestions := make([]models.AddressSuggestion, 0, limit)
+	seen := map[string]bool{}
+	for _, address := range history {
+		seen[geocoding.NormalizeAddress(address.Address)] = true
+		suggestions = append(suggestions, models.AddressSuggestion{ClientAddress: address, Source: models.SuggestionSourceHistory})
+	}
+
+	if len(suggestions) < limit {
+		results, err := a.geocoder.Geocode(ctx, query)
+		if err != nil && !errors.Is(err, geocoding.ErrNoResults) {
+			if len(suggestions) == 0 {
+				return nil, err
+			}
+			slog.Warn("Falha no provedor de geocoding; retornando apenas o histórico de endereços",
+				slog.String("q", normalized), slog.String("error", err.Error()))
+			return suggestions, nil
+		}
+		for _, result := range results {
+			if len(suggestions) >= limit {
+				break
+			}
+			address := AddressFromResult(result)
+			key := geocoding.NormalizeAddress(address.Address)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			suggestions = append(suggestions, models.AddressSuggestion{ClientAddress: address, Source: models.SuggestionSourceProvider})
+		}
+	}
+
+	slog.Info("Sugestões de endereço encontradas", slog.String("q", normalized),
+		slog.Int("historico", len(history)), slog.Int("total", len(suggestions)))
+	a.store(key, suggestions)
+	return suggestions, nil
+}
+
+// hasAddressPrefix indica se o endereço normalizado começa com o texto digitado.
+func hasAddressPrefix(address models.ClientAddress, normalizedQuery string) bool {
+	return strings.HasPrefix(geocoding.NormalizeAddress(address.Address), normalizedQuery)
+}
+
+// cached retorna a resposta armazenada para a busca, caso ainda não tenha expirado.
+func (a *Autocompleter) cached(key string) ([]models.AddressSuggestion, bool) {
+	if a.ttl <= 0 {
+		return nil, false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entry, ok := a.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.suggestions, true
+}
+
+// store armazena a resposta da busca por ttl.
+func (a *Autocompleter) store(key string, suggestions []models.AddressSuggestion) {
+	if a.ttl <= 0 {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	if len(a.entries) >= autocompleteCacheSize {
+		for k, entry := range a.entries {
+			if now.After(entry.expiresAt) {
+				delete(a.entries, k)
+			}
+		}
+		if len(a.entries) >= autocompleteCacheSize {
+			a.entries = map[string]autocompleteEntry{}
+		}
+	}
+	a.entries[key] = autocompleteEntry{suggestions: suggestions, expiresAt: now.Add(a.ttl)}
+}
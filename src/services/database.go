@@ -1,20 +1,21 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"log/slog"
-	"myapi/config"
+	"maps"
+	"myapi/geocoding"
 	"myapi/models"
+	"myapi/repository"
 	"reflect"
-	"time"
-
-	"gorm.io/gorm"
 )
 
-// InsertData insere um novo cliente no banco de dados.
+// InsertData insere um novo cliente no armazenamento.
 //
 // Parâmetros:
+// - repo (repository.DeliveryRepository): Repositório onde o cliente será persistido.
 // - client (models.Client): Estrutura contendo os dados do cliente a ser inserido.
 //
 // Retorno:
@@ -22,21 +23,13 @@ import (
 // - error: Retorna um erro caso a operação de inserção falhe.
 //
 // Detalhes:
-// - A função utiliza o método `Create` do GORM para salvar os dados do cliente na tabela correspondente no banco de dados.
-// - Caso ocorra um erro durante a inserção, um log será gerado e o erro será retornado.
-// func InsertData(client models.Client) (models.Client, error) {
-//	if err := config.DB.Create(&client).Error; err != nil {
-//		log.Println("Erro ao inserir o cliente no MySQL:", err)
-//		return models.Client{}, err // Retorna estrutura vazia e erro
-//	}
-
-// Retorna o objeto client com todos os campos preenchidos após a inserção
-//	return client, nil
-//}
+// - A função utiliza o método `Create` do repositório para salvar os dados do cliente.
+// - O CEP é gravado no formato 00000-000.
+// - Caso ocorra um erro durante a inserção, o erro será retornado.
 
-func InsertData(client models.Client) (models.Client, error) {
-	if err := config.DB.Create(&client).Error; err != nil {
-		log.Println("Erro ao inserir o cliente no MySQL:", err)
+func InsertData(repo repository.DeliveryRepository, client models.Client) (models.Client, error) {
+	client.PostalCode, _ = geocoding.FormatPostalCode(client.PostalCode)
+	if err := repo.Create(&client); err != nil {
 		return models.Client{}, err // Retorna estrutura vazia e erro
 	}
 
@@ -44,37 +37,37 @@ func InsertData(client models.Client) (models.Client, error) {
 	return client, nil
 }
 
-// UpdateClientData atualiza os dados de um cliente existente no banco de dados.
+// UpdateClientData atualiza os dados de um cliente existente no armazenamento.
 //
 // Parâmetros:
+// - repo (repository.DeliveryRepository): Repositório onde o cliente está persistido.
 // - client (models.ClientUpdate): Estrutura contendo os campos a serem atualizados. O campo "ID" é obrigatório
 //   e identifica o cliente que será atualizado.
+// - expectedVersion (uint): Versão esperada do cliente (If-Match). Com 0, a versão não é verificada.
 //
 // Retorno:
 // - models.ClientResponse: Estrutura contendo os dados do cliente atualizados no formato correto.
 // - error: Um erro será retornado nos seguintes casos:
-//   - O campo "ID" não foi fornecido no parâmetro client.
-//   - O cliente com o ID especificado não foi encontrado no banco de dados.
+//   - O cliente com o ID especificado não foi encontrado (repository.ErrNotFound).
+//   - O cliente está em outra versão (repository.ErrVersionMismatch).
+//   - Sem If-Match, o endereço foi alterado e o cliente continuou sendo alterado por escritas concorrentes
+//     (repository.ErrConflict).
 //   - Não há campos válidos para atualizar.
 //   - A operação de atualização ou busca dos dados atualizados falhou.
 //
 // Detalhes:
-// - A função verifica se o cliente existe antes de realizar a atualização.
 // - Apenas os campos que não possuem valor zero (não inicializados) serão atualizados, ignorando os campos de metadados
 //   como "CreatedAt", "UpdatedAt" e "DeletedAt".
-// - Após a atualização, os dados do cliente são buscados novamente para retornar as informações atualizadas no formato esperado.
-
-func UpdateClientData(client models.ClientUpdate) (models.ClientResponse, error) {
-
-	// Verifica se o cliente com o ID fornecido existe
-	var existingClient models.Client
-	if err := config.DB.Where("id = ?", client.ID).First(&existingClient).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return models.ClientResponse{}, fmt.Errorf("cliente com ID %d não encontrado", client.ID)
-		}
-		return models.ClientResponse{}, fmt.Errorf("erro ao verificar cliente: %v", err)
-	}
-
+// - A atualização é condicional à versão do cliente e incrementa essa versão, de modo que alterações
+//   concorrentes não se sobrescrevem silenciosamente.
+// - Quando algum campo do endereço muda sem que Latitude e Longitude sejam enviadas, as coordenadas
+//   armazenadas são marcadas como desatualizadas (CoordinatesStale) até o re-geocoding. Enviar as
+//   coordenadas na mesma requisição as confirma explicitamente.
+// - Coordenadas enviadas sem `location_type` foram posicionadas manualmente: a precisão e a confiança do
+//   geocoding anterior são descartadas.
+// - Após a atualização, os dados do cliente são retornados no formato esperado.
+
+func UpdateClientData(repo repository.DeliveryRepository, client models.ClientUpdate, expectedVersion uint) (models.ClientResponse, error) {
 	updateData := map[string]interface{}{}
 
 	// Usa reflexão para iterar sobre os campos do struct ClientUpdate
@@ -86,7 +79,7 @@ func UpdateClientData(client models.ClientUpdate) (models.ClientResponse, error)
 		fieldName := clientType.Field(i).Name
 
 		// Ignora campos de gorm.Model (ID, CreatedAt, etc)
-		if fieldName == "ID" || fieldName == "CreatedAt" || fieldName == "UpdatedAt" || fieldName == "DeletedAt" {
+		if fieldName == "Model" || fieldName == "ID" || fieldName == "CreatedAt" || fieldName == "UpdatedAt" || fieldName == "DeletedAt" {
 			continue
 		}
 
@@ -100,17 +93,22 @@ func UpdateClientData(client models.ClientUpdate) (models.ClientResponse, error)
 	if len(updateData) == 0 {
 		return models.ClientResponse{}, fmt.Errorf("nenhum campo válido foi enviado para atualização")
 	}
-
-	// Executa a atualização no banco de dados
-	err := config.DB.Model(&models.Client{}).Where("id = ?", client.ID).Updates(updateData).Error
-	if err != nil {
-		return models.ClientResponse{}, err
+	if postalCode, ok := updateData["PostalCode"].(string); ok {
+		updateData["PostalCode"], _ = geocoding.FormatPostalCode(postalCode)
 	}
 
-	// Busca os dados atualizados do cliente
+	resetGeocodePrecision(updateData)
+
+	// Executa a atualização condicional no armazenamento
 	var updatedClient models.Client
-	if err := config.DB.Where("id = ?", client.ID).First(&updatedClient).Error; err != nil {
-		return models.ClientResponse{}, fmt.Errorf("erro ao buscar cliente atualizado: %v", err)
+	var err error
+	if hasAddressField(updateData) {
+		updatedClient, err = updateAddress(repo, client.ID, expectedVersion, updateData)
+	} else {
+		updatedClient, err = repo.Update(client.ID, expectedVersion, updateData)
+	}
+	if err != nil {
+		return models.ClientResponse{}, fmt.Errorf("cliente com ID %d: %w", client.ID, err)
 	}
 
 	// Cria um struct de resposta com a ordem correta dos campos
@@ -125,8 +123,15 @@ func UpdateClientData(client models.ClientUpdate) (models.ClientResponse, error)
 		City:         updatedClient.City,
 		State:        updatedClient.State,
 		Country:      updatedClient.Country,
+		PostalCode:   updatedClient.PostalCode,
 		Latitude:     updatedClient.Latitude,
 		Longitude:    updatedClient.Longitude,
+		Version:      updatedClient.Version,
+
+		CoordinatesStale: updatedClient.CoordinatesStale,
+
+		LocationType:      updatedClient.LocationType,
+		GeocodeConfidence: updatedClient.GeocodeConfidence,
 	}
 
 	// Retorna os dados formatados
@@ -137,32 +142,26 @@ func UpdateClientData(client models.ClientUpdate) (models.ClientResponse, error)
 // GetClientByID busca um cliente pelo ID e retorna as informações do cliente encontrado.
 //
 // Parâmetros:
-// - id (uint8): O ID do cliente que será buscado no banco de dados.
+// - repo (repository.DeliveryRepository): Repositório onde o cliente está persistido.
+// - id (uint): O ID do cliente que será buscado.
 //
 // Retorno:
-// - models.ClientUpdate: Estrutura contendo as informações do cliente encontrado.
-// - error: Retorna um erro se o cliente não for encontrado ou ocorrer algum problema na consulta.
-//
-// Comportamento:
-// - Realiza uma consulta no banco de dados para buscar o cliente com o ID especificado.
-// - Se o cliente for encontrado, retorna os dados do cliente e `nil` para o erro.
-// - Se não for encontrado ou ocorrer um erro, retorna um cliente vazio e o erro correspondente.
+// - models.Client: Estrutura contendo as informações do cliente encontrado.
+// - error: Retorna repository.ErrNotFound se o cliente não for encontrado, ou o erro da consulta.
 //
 // Exemplo de uso:
 //
-//	client, err := GetClientByID(1)
+//	client, err := GetClientByID(repo, 1)
 //	if err != nil {
 //		log.Println("Erro ao buscar cliente:", err)
 //	} else {
 //		log.Printf("Cliente encontrado: %+v\n", client)
 //	}
 
-func GetClientByID(id uint8) (models.ClientUpdate, error) {
-	// Declara uma variável client para armazenar o cliente encontrado
-	var client models.ClientUpdate
-
-	// Tenta encontrar o cliente no banco de dados pelo id
-	if err := config.DB.First(&client, id).Error; err != nil {
+func GetClientByID(repo repository.DeliveryRepository, id uint) (models.Client, error) {
+	// Tenta encontrar o cliente pelo id
+	client, err := repo.FindByID(id)
+	if err != nil {
 		log.Println("Erro ao buscar o cliente:", err)
 		return client, err // Retorna o cliente vazio e o erro
 	}
@@ -171,6 +170,93 @@ func GetClientByID(id uint8) (models.ClientUpdate, error) {
 	return client, nil
 }
 
+// addressUpdateAttempts é a quantidade de vezes que updateAddress refaz a leitura e a comparação do endereço
+// quando outra escrita altera o cliente ao mesmo tempo.
+const addressUpdateAttempts = 3
+
+// updateAddress atualiza o cliente quando a alteração inclui algum campo do endereço.
+//
+// Alterações no endereço sem novas coordenadas marcam as coordenadas como desatualizadas, e essa comparação
+// vale para a versão lida: a atualização é condicional a ela. Com If-Match (`expectedVersion` maior que 0),
+// uma versão diferente retorna repository.ErrVersionMismatch. Sem If-Match, uma escrita concorrente entre a
+// leitura e a atualização não é um erro do cliente da API: a leitura e a comparação são refeitas e, se o
+// cliente continuar sendo alterado, o erro envolve repository.ErrConflict.
+func updateAddress(repo repository.DeliveryRepository, id uint, expectedVersion uint, updateData map[string]interface{}) (models.Client, error) {
+	for attempt := 1; ; attempt++ {
+		current, err := repo.FindByID(id)
+		if err != nil {
+			return models.Client{}, err
+		}
+		version := expectedVersion
+		if version == 0 {
+			version = current.Version
+		}
+
+		fields := maps.Clone(updateData)
+		markStaleCoordinates(current, fields)
+		updated, err := repo.Update(id, version, fields)
+		if expectedVersion > 0 || !errors.Is(err, repository.ErrVersionMismatch) {
+			return updated, err
+		}
+		if attempt == addressUpdateAttempts {
+			return models.Client{}, fmt.Errorf("o cliente foi alterado durante a atualização: %w", repository.ErrConflict)
+		}
+		slog.Info("Cliente alterado durante a atualização do endereço; repetindo a comparação", slog.Int("client_id", int(id)), slog.Int("tentativa", attempt))
+	}
+}
+
+// hasAddressField indica se a atualização inclui algum dos campos de endereço (models.AddressFields).
+func hasAddressField(updateData map[string]interface{}) bool {
+	for _, field := range models.AddressFields {
+		if _, ok := updateData[field]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// markStaleCoordinates ajusta CoordinatesStale na atualização do cliente.
+//
+// Coordenadas enviadas junto com a atualização prevalecem e deixam de estar desatualizadas. Sem elas,
+// a alteração de algum campo do endereço marca as coordenadas armazenadas como desatualizadas, para que
+// o pin não continue apontando para o endereço anterior. Campos reenviados com o mesmo valor não contam
+// como alteração.
+func markStaleCoordinates(current models.Client, updateData map[string]interface{}) {
+	_, hasLatitude := updateData["Latitude"]
+	_, hasLongitude := updateData["Longitude"]
+	if hasLatitude || hasLongitude {
+		if current.CoordinatesStale {
+			updateData["CoordinatesStale"] = false
+		}
+		return
+	}
+
+	currentValue := reflect.ValueOf(current)
+	for _, field := range models.AddressFields {
+		value, ok := updateData[field]
+		if ok && !reflect.DeepEqual(currentValue.FieldByName(field).Interface(), value) {
+			if current.HasCoordinates() && !current.CoordinatesStale {
+				updateData["CoordinatesStale"] = true
+				slog.Info("Endereço do cliente alterado sem novas coordenadas", slog.Int("client_id", int(current.ID)), slog.String("field", field))
+			}
+			return
+		}
+	}
+}
+
+// resetGeocodePrecision descarta a precisão e a confiança do geocoding quando as coordenadas são alteradas
+// sem elas: o pin foi posicionado manualmente e a precisão do geocoding anterior deixou de valer.
+func resetGeocodePrecision(updateData map[string]interface{}) {
+	_, hasLatitude := updateData["Latitude"]
+	_, hasLongitude := updateData["Longitude"]
+	_, hasLocationType := updateData["LocationType"]
+	_, hasConfidence := updateData["GeocodeConfidence"]
+	if (hasLatitude || hasLongitude) && !hasLocationType && !hasConfidence {
+		updateData["LocationType"] = ""
+		updateData["GeocodeConfidence"] = 0.0
+	}
+}
+
 // isZeroValue verifica se o valor de um campo é considerado zero ou não inicializado.
 //
 // Esta função é utilizada para determinar se um campo deve ser ignorado em operações como atualizações
@@ -208,159 +294,98 @@ func isZeroValue(value reflect.Value) bool {
 
 // DeleteAllClients arquiva e exclui todos os clientes da tabela principal (`clients`).
 //
-// Esta função realiza as seguintes etapas:
-// 1. Busca todos os registros de clientes na tabela principal.
-// 2. Converte os clientes encontrados para o formato de arquivamento (`archived_clients`),
+// Esta função delega ao repositório as seguintes etapas, executadas em uma única transação:
+// 1. Percorre os registros de clientes da tabela principal em lotes, ordenados por ID.
+// 2. Converte cada lote para o formato de arquivamento (`archived_clients`),
 //    incluindo o timestamp de arquivamento (`DeletedAt`).
-// 3. Insere os registros convertidos na tabela de arquivados em lote.
-// 4. Remove todos os registros da tabela principal (`clients`) em lote.
+// 3. Insere o lote convertido na tabela de arquivados.
+// 4. Remove o lote da tabela principal (`clients`).
 //
 // Retorno:
-// - error: Retorna `nil` se a operação for bem-sucedida ou um erro descritivo caso ocorra falha
-//   em qualquer etapa do processo.
-//
-// Erros possíveis:
-// - Falha ao buscar os clientes na tabela principal.
-// - Falha ao inserir os registros na tabela de arquivados.
-// - Falha ao deletar os registros da tabela principal.
-//
-// Observação:
-// - Caso não existam clientes na tabela principal, a função retorna `nil` e registra uma mensagem
-//   indicando que não há clientes para excluir.
+//   - int64: Quantidade de clientes arquivados.
+//   - error: Retorna `nil` se a operação for bem-sucedida ou um erro descritivo caso ocorra falha
+//     em qualquer etapa do processo. Em caso de erro, nenhum cliente é arquivado ou excluído.
 //
 // Exemplo de uso:
 //
-//	err := DeleteAllClients()
+//	archived, err := DeleteAllClients(repo)
 //	if err != nil {
 //		log.Printf("Erro ao arquivar e excluir todos os clientes: %v", err)
 //	} else {
-//		log.Println("Todos os clientes foram processados com sucesso")
+//		log.Printf("%d clientes foram processados com sucesso", archived)
 //	}
 
-func DeleteAllClients() error {
-	var clients []models.Client
-
-	// Buscar todos os clientes na tabela principal
-	if err := config.DB.Find(&clients).Error; err != nil {
-		log.Println("Erro ao buscar todos os clientes:", err)
-		return fmt.Errorf("erro ao buscar todos os clientes")
-	}
-
-	// Verificar se existem clientes para arquivar
-	if len(clients) == 0 {
-		log.Println("Nenhum cliente encontrado para excluir")
-		return nil
-	}
-
-	// Converter os clientes para o formato de arquivamento
-	var archivedClients []models.ArchivedClient
-	for _, client := range clients {
-		archivedClients = append(archivedClients, models.ArchivedClient{
-			ID:           int(client.ID), // Conversão de uint para int
-			Name:         client.Name,
-			WeightKg:     client.WeightKg,
-			Address:      client.Address,
-			Street:       client.Street,
-			Number:       client.Number, // Conversão de int para string
-			Neighborhood: client.Neighborhood,
-			Complement:   client.Complement,
-			City:         client.City,
-			State:        client.State,
-			Country:      client.Country,
-			Latitude:     client.Latitude,
-			Longitude:    client.Longitude,
-			CreatedAt:    client.CreatedAt,
-			UpdatedAt:    client.UpdatedAt,
-			DeletedAt:    time.Now(),
-		})
-	}
-
-	// Inserir todos os clientes na tabela de arquivados em lote
-	for _, archivedClient := range archivedClients {
-		if err := config.DB.Save(&archivedClient).Error; err != nil {
-			log.Println("Erro ao arquivar cliente:", archivedClient.ID, err)
-			return fmt.Errorf("erro ao arquivar o cliente com ID %d", archivedClient.ID)
-		}
-	}
-
-	// Deletar todos os clientes da tabela principal em lote
-	if err := config.DB.Exec("DELETE FROM clients").Error; err != nil {
-		log.Println("Erro ao deletar todos os clientes:", err)
-		return fmt.Errorf("erro ao deletar todos os clientes")
+func DeleteAllClients(repo repository.DeliveryRepository) (int64, error) {
+	archived, err := repo.ArchiveAll()
+	if err != nil {
+		return 0, err
 	}
 
-	log.Println("Todos os clientes foram arquivados e excluídos com sucesso")
-	return nil
+	log.Printf("%d clientes foram arquivados e excluídos com sucesso", archived)
+	return archived, nil
 }
 
 // DeleteClientByID arquiva e exclui um cliente específico com base no ID fornecido.
 //
-// Esta função realiza as seguintes etapas:
+// Esta função delega ao repositório as seguintes etapas, executadas em uma única transação:
 // 1. Busca um cliente na tabela principal (`clients`) com o ID especificado.
 // 2. Cria um registro do cliente na tabela de arquivados (`archived_clients`), incluindo
 //    informações completas do cliente e o timestamp de arquivamento (`DeletedAt`).
 // 3. Remove o cliente da tabela principal (`clients`).
 //
 // Parâmetros:
+// - repo (repository.DeliveryRepository): Repositório onde o cliente está persistido.
 // - clientID (int): ID do cliente a ser arquivado e excluído.
+// - expectedVersion (uint): Versão esperada do cliente (If-Match). Com 0, a versão não é verificada.
 //
 // Retorno:
 // - error: Retorna `nil` se a operação for bem-sucedida ou um erro descritivo caso ocorra falha
-//   em qualquer etapa do processo.
-//
-// Erros possíveis:
-// - Caso o cliente não seja encontrado na tabela principal.
-// - Falha ao inserir o registro na tabela de arquivados.
-// - Falha ao deletar o cliente da tabela principal.
+//   em qualquer etapa do processo. Caso o cliente não exista, o erro envolve repository.ErrNotFound;
+//   caso esteja em outra versão, repository.ErrVersionMismatch.
 //
 // Exemplo de uso:
 //
-//	err := DeleteClientByID(123)
+//	err := DeleteClientByID(repo, 123, 0)
 //	if err != nil {
 //		log.Printf("Erro ao arquivar e excluir cliente: %v", err)
 //	}
 
-func DeleteClientByID(clientID int) error {
-	var client models.Client
-
-	// Buscar o cliente pelo ID na tabela principal
-	if err := config.DB.Table("clients").First(&client, "id = ?", clientID).Error; err != nil {
-		log.Println("Erro ao encontrar o cliente:", err)
-		return fmt.Errorf("erro ao encontrar o cliente com ID %d", clientID)
+func DeleteClientByID(repo repository.DeliveryRepository, clientID int, expectedVersion uint) error {
+	if err := repo.ArchiveByID(uint(clientID), expectedVersion); err != nil {
+		return fmt.Errorf("erro ao excluir o cliente com ID %d: %w", clientID, err)
 	}
 
-	// Criar um registro do cliente arquivado
-	archivedClient := models.ArchivedClient{
-		ID:           int(client.ID), // Garante compatibilidade caso ID do ArchivedClient seja int
-		Name:         client.Name,
-		WeightKg:     client.WeightKg,
-		Address:      client.Address,
-		Street:       client.Street,
-		Number:       client.Number,
-		Neighborhood: client.Neighborhood,
-		Complement:   client.Complement,
-		City:         client.City,
-		State:        client.State,
-		Country:      client.Country,
-		Latitude:     client.Latitude,
-		Longitude:    client.Longitude,
-		CreatedAt:    client.CreatedAt,
-		UpdatedAt:    client.UpdatedAt,
-		DeletedAt:    time.Now(), // Adiciona o timestamp atual para arquivamento
-	}
+	log.Printf("Cliente com ID %d foi arquivado e excluído com sucesso", clientID)
+	return nil
+}
 
-	// Inserir na tabela de arquivados
-	if err := config.DB.Table("archived_clients").Create(&archivedClient).Error; err != nil {
-		log.Println("Erro ao excluir cliente:", err)
-		return fmt.Errorf("erro ao excluir o cliente com ID %d", clientID)
-	}
+// RestoreArchivedClient restaura um cliente arquivado para a tabela principal (`clients`).
+//
+// O cliente volta com o mesmo ID e os mesmos timestamps de criação e atualização que possuía
+// antes do arquivamento, e o registro é removido de `archived_clients` na mesma transação.
+//
+// Parâmetros:
+// - repo (repository.DeliveryRepository): Repositório onde o cliente está persistido.
+// - clientID (uint): ID do cliente arquivado.
+//
+// Retorno:
+//   - models.Client: O cliente restaurado.
+//   - error: Envolve repository.ErrNotFound caso o cliente não esteja arquivado ou
+//     repository.ErrConflict caso o ID já tenha sido reutilizado por outro cliente.
+//
+// Exemplo de uso:
+//
+//	client, err := RestoreArchivedClient(repo, 123)
+//	if errors.Is(err, repository.ErrConflict) {
+//		log.Printf("O ID 123 já está em uso")
+//	}
 
-	// Deletar o cliente da tabela principal
-	if err := config.DB.Table("clients").Delete(&models.Client{}, "id = ?", clientID).Error; err != nil {
-		log.Println("Erro ao deletar o cliente:", err)
-		return fmt.Errorf("erro ao deletar o cliente com ID %d", clientID)
+func RestoreArchivedClient(repo repository.DeliveryRepository, clientID uint) (models.Client, error) {
+	client, err := repo.RestoreArchived(clientID)
+	if err != nil {
+		return models.Client{}, fmt.Errorf("erro ao restaurar o cliente com ID %d: %w", clientID, err)
 	}
 
-	log.Printf("Cliente com ID %d foi arquivado e excluído com sucesso", clientID)
-	return nil
+	log.Printf("Cliente com ID %d foi restaurado com sucesso", clientID)
+	return client, nil
 }
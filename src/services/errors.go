@@ -0,0 +1,35 @@
+package services
+
+import (
+	"errors"
+	"myapi/geocoding"
+	"myapi/repository"
+)
+
+// Erros retornados pelos serviços e handlers. Todos são comparados com errors.Is e traduzidos pela camada
+// HTTP (pacote controller) em um status e um código de erro estável.
+//
+// ErrNotFound, ErrConflict e ErrVersionMismatch são os próprios erros do repositório, de modo que os erros
+// de persistência envolvidos com `%w` correspondem a eles sem que os chamadores importem o pacote repository.
+var (
+	// ErrNotFound é retornado quando o recurso solicitado não existe.
+	ErrNotFound = repository.ErrNotFound
+
+	// ErrConflict é retornado quando a operação conflita com o estado atual do recurso.
+	ErrConflict = repository.ErrConflict
+
+	// ErrVersionMismatch é retornado quando a versão esperada (If-Match) não corresponde à versão atual.
+	ErrVersionMismatch = repository.ErrVersionMismatch
+
+	// ErrValidation é retornado quando os dados recebidos são inválidos. Todo *ValidationError corresponde a ele.
+	ErrValidation = errors.New("dados inválidos")
+
+	// ErrUpstream é retornado quando um serviço externo (por exemplo, o provedor de geocoding) falha ou
+	// responde de forma inesperada.
+	ErrUpstream = geocoding.ErrUpstream
+
+	// ErrUnavailable é retornado quando o serviço externo está indisponível: sem acessá-lo, enquanto o circuit
+	// breaker está aberto (caso em que também corresponde a ErrUpstream), ou quando a consulta é interrompida
+	// pelo prazo da requisição.
+	ErrUnavailable = geocoding.ErrUnavailable
+)
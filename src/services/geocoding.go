@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"myapi/geocoding"
+	"myapi/models"
+	"myapi/repository"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// GeocodeAddress busca as coordenadas do endereço no provedor de geocoding e retorna o resultado mais relevante.
+//
+// Parâmetros:
+// - ctx (context.Context): Contexto da requisição; o cancelamento interrompe a consulta ao provedor.
+// - geocoder (geocoding.Geocoder): Provedor de geocoding configurado.
+// - address (string): Endereço a ser consultado.
+//
+// Retorno:
+// - geocoding.Result: Coordenadas, endereço formatado e componentes do endereço.
+// - error: ErrNotFound quando o provedor não encontra o endereço, ou ErrUpstream nas falhas do provedor.
+//
+// Exemplo de uso:
+//
+//	result, err := GeocodeAddress(r.Context(), geocoder, "Av. Paulista, 1000, São Paulo")
+func GeocodeAddress(ctx context.Context, geocoder geocoding.Geocoder, address string) (geocoding.Result, error) {
+	results, err := geocoder.Geocode(ctx, address)
+	if errors.Is(err, geocoding.ErrNoResults) || (err == nil && len(results) == 0) {
+		slog.Info("Nenhum resultado de geocoding para o endereço", slog.String("endereco", address))
+		return geocoding.Result{}, fmt.Errorf("%w: nenhum resultado encontrado para o endereço", ErrNotFound)
+	}
+	if err != nil {
+		return geocoding.Result{}, err
+	}
+
+	result := geocoding.Normalize(results[0])
+	slog.Info("Dados do endereço extraídos com sucesso", slog.String("endereco", address),
+		slog.Float64("latitude", result.Latitude), slog.Float64("longitude", result.Longitude),
+		slog.String("location_type", result.LocationType), slog.Float64("confidence", result.Confidence))
+	return result, nil
+}
+
+// GeocodeCacheStats contém as métricas do cache de geocoding desde o início do servidor.
+type GeocodeCacheStats struct {
+	Hits     int64   `json:"hits"`      // Consultas respondidas pelo cache
+	Misses   int64   `json:"misses"`    // Consultas encaminhadas ao provedor
+	HitRatio float64 `json:"hit_ratio"` // Proporção de consultas respondidas pelo cache (0 a 1)
+	Entries  int64   `json:"entries"`   // Entradas não expiradas no cache
+	TTL      string  `json:"ttl"`       // Tempo de vida de cada entrada
+}
+
+// CachedGeocoder é um geocoding.Geocoder que consulta o cache antes do provedor e grava os resultados
+// encontrados por TTL. Endereços sem resultados e falhas do provedor não são armazenados.
+//
+// Como as entradas são separadas pelo nome do provedor principal, só são armazenados os resultados do
+// próprio provedor principal com a confiança mínima: os resultados de um provedor de fallback da cadeia ou
+// com baixa confiança são retornados sem passar pelo cache, e o provedor principal é consultado de novo na
+// próxima busca.
+//
+// Falhas do cache não interrompem a busca: são registradas no log e a consulta segue para o provedor.
+type CachedGeocoder struct {
+	next          geocoding.Geocoder
+	cache         repository.GeocodeCache
+	provider      string
+	minConfidence float64
+	ttl           time.Duration
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// NewCachedGeocoder envolve o provedor de geocoding com o cache informado.
+//
+// Parâmetros:
+// - next (geocoding.Geocoder): Provedor consultado quando o endereço não está no cache.
+// - cache (repository.GeocodeCache): Armazenamento do cache.
+// - provider (string): Nome do provedor principal, que separa as entradas de provedores diferentes.
+// - minConfidence (float64): Confiança mínima (0 a 1) do resultado mais relevante para ser armazenado.
+// - ttl (time.Duration): Tempo de vida de cada entrada.
+//
+// Exemplo de uso:
+//
+//	geocoder := NewCachedGeocoder(chain, repository.NewGormGeocodeCache(db), "distancematrix", 0.5, 30*24*time.Hour)
+func NewCachedGeocoder(next geocoding.Geocoder, cache repository.GeocodeCache, provider string, minConfidence float64, ttl time.Duration) *CachedGeocoder {
+	return &CachedGeocoder{next: next, cache: cache, provider: provider, minConfidence: minConfidence, ttl: ttl}
+}
+
+// GeocodeCacheKey retorna a chave do endereço no cache: o SHA-256 do endereço normalizado.
+func GeocodeCacheKey(address string) string {
+	sum := sha256.Sum256([]byte(geocoding.NormalizeAddress(address)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Geocode retorna os resultados do cache ou, se o endereço não estiver nele, consulta o provedor e grava os resultados.
+func (c *CachedGeocoder) Geocode(ctx context.Context, address string) ([]geocoding.Result, error) {
+	key := GeocodeCacheKey(address)
+	now := time.Now()
+
+	entry, found, err := c.cache.Get(c.provider, key, now)
+	if err != nil {
+		slog.Warn("Erro ao consultar o cache de geocoding", slog.String("error", err.Error()))
+	}
+	if found {
+		var results []geocoding.Result
+		if err := json.Unmarshal(entry.Results, &results); err == nil && len(results) > 0 {
+			c.hits.Add(1)
+			slog.Info("Endereço encontrado no cache de geocoding", slog.String("endereco", address))
+			return results, nil
+		}
+		slog.Warn("Entrada inválida no cache de geocoding", slog.String("endereco", address))
+	}
+
+	c.misses.Add(1)
+	results, err := c.next.Geocode(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	if !c.cacheable(results) {
+		slog.Info("Resultado de geocoding não armazenado no cache", slog.String("endereco", address),
+			slog.String("provedor", results[0].Provider), slog.Float64("confidence", geocoding.Normalize(results[0]).Confidence))
+		return results, nil
+	}
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		slog.Warn("Erro ao serializar os resultados de geocoding", slog.String("error", err.Error()))
+		return results, nil
+	}
+	err = c.cache.Put(models.GeocodeCacheEntry{
+		Provider:   c.provider,
+		AddressKey: key,
+		Address:    geocoding.NormalizeAddress(address),
+		Results:    data,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(c.ttl),
+	})
+	if err != nil {
+		slog.Warn("Erro ao gravar o endereço no cache de geocoding", slog.String("error", err.Error()))
+	}
+	return results, nil
+}
+
+// cacheable indica se os resultados podem ser armazenados: o resultado mais relevante veio do provedor
+// principal (ou de um provedor fora da cadeia, sem nome) e tem a confiança mínima.
+func (c *CachedGeocoder) cacheable(results []geocoding.Result) bool {
+	if len(results) == 0 {
+		return false
+	}
+	provider := results[0].Provider
+	return (provider == "" || provider == c.provider) && geocoding.Normalize(results[0]).Confidence >= c.minConfidence
+}
+
+// Reverse encaminha o geocoding reverso ao provedor, sem passar pelo cache: coordenadas de um pin
+// raramente se repetem.
+func (c *CachedGeocoder) Reverse(ctx context.Context, latitude, longitude float64) ([]geocoding.Result, error) {
+	return c.next.Reverse(ctx, latitude, longitude)
+}
+
+// Invalidate remove o endereço do cache, ou todas as entradas quando o endereço é vazio, e retorna
+// a quantidade de entradas removidas.
+//
+// Exemplo de uso:
+//
+//	removed, err := geocoder.Invalidate("Av. Paulista, 1000, São Paulo")
+func (c *CachedGeocoder) Invalidate(address string) (int64, error) {
+	key := ""
+	if geocoding.NormalizeAddress(address) != "" {
+		key = GeocodeCacheKey(address)
+	}
+	removed, err := c.cache.Invalidate(key)
+	if err != nil {
+		return 0, err
+	}
+	slog.Info("Cache de geocoding invalidado", slog.String("endereco", address), slog.Int64("removidas", removed))
+	return removed, nil
+}
+
+// Stats retorna as métricas de acerto do cache e a quantidade de entradas válidas.
+func (c *CachedGeocoder) Stats() (GeocodeCacheStats, error) {
+	entries, err := c.cache.Count(time.Now())
+	if err != nil {
+		return GeocodeCacheStats{}, err
+	}
+	stats := GeocodeCacheStats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Entries: entries,
+		TTL:     c.ttl.String(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+	return stats, nil
+}
+
+// ParseCoordinates converte os parâmetros de latitude e longitude recebidos na query string.
+// Todos os problemas são reportados de uma só vez em um *ValidationError, com os nomes dos parâmetros
+// como campos.
+//
+// Exemplo de uso:
+//
+//	lat, lng, err := ParseCoordinates("lat", query.Get("lat"), "lng", query.Get("lng"))
+func ParseCoordinates(latField, latValue, lngField, lngValue string) (float64, float64, error) {
+	result := &ValidationError{}
+	latitude := parseCoordinate(result, latField, latValue, 90)
+	longitude := parseCoordinate(result, lngField, lngValue, 180)
+	return latitude, longitude, result.Err()
+}
+
+// parseCoordinate converte uma coordenada e verifica se ela está entre -limit e limit.
+func parseCoordinate(result *ValidationError, field, value string, limit float64) float64 {
+	if strings.TrimSpace(value) == "" {
+		result.Add(field, CodeRequired, "validation.required", value)
+		return 0
+	}
+	coordinate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(coordinate) || math.IsInf(coordinate, 0) {
+		result.Add(field, CodeInvalid, "validation.invalid_number", value)
+		return 0
+	}
+	if coordinate < -limit || coordinate > limit {
+		result.Add(field, CodeOutOfRange, "validation.out_of_range", coordinate, -limit, limit)
+	}
+	return coordinate
+}
+
+// ReverseGeocode busca o endereço nas coordenadas e o converte nos campos de endereço do cliente.
+//
+// Parâmetros:
+// - ctx (context.Context): Contexto da requisição; o cancelamento interrompe a consulta ao provedor.
+// - geocoder (geocoding.Geocoder): Provedor de geocoding configurado.
+// - latitude, longitude (float64): Coordenadas do pin no mapa.
+//
+// Retorno:
+// - models.ClientAddress: Rua, número, bairro, cidade, estado e país do endereço mais próximo.
+// - error: ErrNotFound quando o provedor não encontra endereço nas coordenadas, ou ErrUpstream nas falhas do provedor.
+//
+// Exemplo de uso:
+//
+//	address, err := ReverseGeocode(r.Context(), geocoder, -22.9519, -43.2105)
+func ReverseGeocode(ctx context.Context, geocoder geocoding.Geocoder, latitude, longitude float64) (models.ClientAddress, error) {
+	results, err := geocoder.Reverse(ctx, latitude, longitude)
+	if errors.Is(err, geocoding.ErrNoResults) || (err == nil && len(results) == 0) {
+		slog.Info("Nenhum endereço encontrado nas coordenadas", slog.Float64("latitude", latitude), slog.Float64("longitude", longitude))
+		return models.ClientAddress{}, fmt.Errorf("%w: nenhum endereço encontrado nas coordenadas", ErrNotFound)
+	}
+	if err != nil {
+		return models.ClientAddress{}, err
+	}
+
+	address := AddressFromResult(results[0])
+	slog.Info("Endereço encontrado nas coordenadas", slog.String("endereco", address.Address))
+	return address, nil
+}
+
+// AddressFromResult converte os componentes de um resultado de geocoding nos campos de endereço do cliente.
+// Os tipos seguem a DistanceMatrix (e o Google Geocoding); o Nominatim é convertido para os mesmos tipos.
+// Quando não há um componente de bairro, é usado o segundo componente `route`, como faz a interface web.
+func AddressFromResult(result geocoding.Result) models.ClientAddress {
+	result = geocoding.Normalize(result)
+	address := models.ClientAddress{
+		Address:   result.DisplayName,
+		Latitude:  result.Latitude,
+		Longitude: result.Longitude,
+
+		LocationType:      result.LocationType,
+		GeocodeConfidence: result.Confidence,
+	}
+
+	var routes []string
+	for _, component := range result.Address {
+		for _, componentType := range component.Types {
+			switch componentType {
+			case "route":
+				routes = append(routes, component.LongName)
+			case "street_number":
+				if number, err := strconv.Atoi(strings.TrimSpace(component.LongName)); err == nil && address.Number == 0 {
+					address.Number = number
+				}
+			case "neighborhood", "sublocality", "sublocality_level_1":
+				setOnce(&address.Neighborhood, component.LongName)
+			case "locality", "administrative_area_level_2":
+				setOnce(&address.City, component.LongName)
+			case "state", "administrative_area_level_1":
+				setOnce(&address.State, component.LongName)
+			case "country":
+				setOnce(&address.Country, component.LongName)
+			case "postal_code":
+				// Apenas CEPs brasileiros, no formato aceito pelo cadastro do cliente
+				if postalCode, ok := geocoding.FormatPostalCode(component.LongName); ok {
+					setOnce(&address.PostalCode, postalCode)
+				}
+			default:
+				continue
+			}
+			break
+		}
+	}
+
+	if len(routes) > 0 {
+		address.Street = routes[0]
+	}
+	if address.Neighborhood == "" && len(routes) > 1 {
+		address.Neighborhood = routes[1]
+	}
+	return address
+}
+
+// setOnce atribui o valor ao campo apenas se ele ainda estiver vazio, mantendo o componente mais específico.
+func setOnce(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"myapi/config"
+	"myapi/geocoding"
+	"myapi/models"
+	"myapi/repository"
+	"time"
+)
+
+// geocodingPollInterval é o intervalo em que o dispatcher procura itens pendentes quando não é acordado
+// por um novo job (por exemplo, itens reenfileirados por outra instância do servidor).
+const geocodingPollInterval = 30 * time.Second
+
+// geocodingClaimLease é o tempo máximo de um item em processamento. Depois dele, o item é considerado
+// abandonado (por exemplo, pelo encerramento da instância que o reservou) e volta para a fila; o limite é bem
+// maior que o processamento de um item, que inclui as retentativas e a espera por um provedor indisponível.
+const geocodingClaimLease = 5 * time.Minute
+
+// geocodingUnavailableDelay é a espera de um worker antes de devolver o item para a fila quando o circuit
+// breaker do provedor está aberto, evitando consumir a fila inteira como falha enquanto o provedor se recupera.
+const geocodingUnavailableDelay = 10 * time.Second
+
+// GeocodingWorker preenche Latitude e Longitude das entregas cadastradas apenas com o endereço e
+// atualiza as coordenadas desatualizadas das entregas cujo endereço foi alterado (CoordinatesStale).
+//
+// As entregas são enfileiradas em jobs (repository.GeocodingJobStore) e processadas por um pool de
+// `workers` goroutines. As consultas ao provedor são espaçadas por `interval`, somando todos os workers,
+// para respeitar o limite de requisições do provedor. Cada item termina como `done` ou `failed`, com o
+// motivo da falha; os itens que falharam podem ser reenfileirados com Retry.
+type GeocodingWorker struct {
+	repo     repository.DeliveryRepository
+	jobs     repository.GeocodingJobStore
+	geocoder geocoding.Geocoder
+	workers  int
+	interval time.Duration
+
+	wake chan struct{}
+}
+
+// NewGeocodingWorker cria o pool de geocoding assíncrono. Os itens só são processados depois de Run.
+//
+// Parâmetros:
+// - repo (repository.DeliveryRepository): Repositório das entregas a serem geocodificadas.
+// - jobs (repository.GeocodingJobStore): Armazenamento dos jobs e de seus itens.
+// - geocoder (geocoding.Geocoder): Provedor de geocoding.
+// - settings (config.GeocodingSettings): Quantidade de workers e intervalo mínimo entre as consultas.
+//
+// Exemplo de uso:
+//
+//	worker := services.NewGeocodingWorker(repo, jobs, geocoder, settings.Geocoding)
+//	go worker.Run(ctx)
+func NewGeocodingWorker(repo repository.DeliveryRepository, jobs repository.GeocodingJobStore, geocoder geocoding.Geocoder, settings config.GeocodingSettings) *GeocodingWorker {
+	return &GeocodingWorker{
+		repo:     repo,
+		jobs:     jobs,
+		geocoder: geocoder,
+		workers:  max(settings.Workers, 1),
+		interval: settings.RequestInterval,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Enqueue cria um job para as entregas informadas e acorda os workers. As entregas que já estão na fila
+// de outro job não são enfileiradas novamente.
+//
+// Exemplo de uso:
+//
+//	job, err := worker.Enqueue([]uint{client.ID})
+func (g *GeocodingWorker) Enqueue(clientIDs []uint) (models.GeocodingJob, error) {
+	job, err := g.jobs.CreateJob(clientIDs)
+	if err != nil {
+		return models.GeocodingJob{}, err
+	}
+	slog.Info("Job de geocoding enfileirado", slog.Uint64("job_id", uint64(job.ID)), slog.Int64("itens", job.Items.Total))
+	g.notify()
+	return job, nil
+}
+
+// EnqueueMissing cria um job com todas as entregas sem coordenadas ou com coordenadas desatualizadas que
+// ainda não estão na fila.
+func (g *GeocodingWorker) EnqueueMissing() (models.GeocodingJob, error) {
+	ids, err := g.repo.ListIDsNeedingGeocoding()
+	if err != nil {
+		return models.GeocodingJob{}, err
+	}
+	return g.Enqueue(ids)
+}
+
+// Job retorna o job com a quantidade de itens em cada situação, ou ErrNotFound.
+func (g *GeocodingWorker) Job(id uint) (models.GeocodingJob, error) {
+	return g.jobs.FindJob(id)
+}
+
+// Items retorna os itens do job, opcionalmente filtrados pela situação (por exemplo, `failed`).
+// Retorna ErrNotFound caso o job não exista.
+func (g *GeocodingWorker) Items(jobID uint, status string) ([]models.GeocodingJobItem, error) {
+	if _, err := g.jobs.FindJob(jobID); err != nil {
+		return nil, err
+	}
+	return g.jobs.ListItems(jobID, status)
+}
+
+// Retry reenfileira os itens do job que falharam e retorna o job atualizado. As entregas que já estão na fila
+// de outro job ou que foram geocodificadas por um job mais novo não são reenfileiradas.
+func (g *GeocodingWorker) Retry(jobID uint) (models.GeocodingJob, error) {
+	requeued, err := g.jobs.RetryFailed(jobID)
+	if err != nil {
+		return models.GeocodingJob{}, err
+	}
+	slog.Info("Itens do job de geocoding reenfileirados", slog.Uint64("job_id", uint64(jobID)), slog.Int64("itens", requeued))
+	if requeued > 0 {
+		g.notify()
+	}
+	return g.jobs.FindJob(jobID)
+}
+
+// Run processa os itens pendentes até o contexto ser cancelado.
+//
+// Na inicialização e a cada geocodingPollInterval, os itens em processamento há mais de geocodingClaimLease
+// voltam para a fila; os reservados por workers ativos, inclusive de outras instâncias, não são alterados.
+// Um item interrompido pelo cancelamento do contexto também volta para a fila.
+//
+// Exemplo de uso:
+//
+//	go worker.Run(ctx)
+func (g *GeocodingWorker) Run(ctx context.Context) {
+	g.requeueExpired()
+	slog.Info("Geocoding assíncrono iniciado", slog.Int("workers", g.workers), slog.Duration("intervalo", g.interval))
+
+	limiter := time.NewTicker(g.interval)
+	defer limiter.Stop()
+
+	items := make(chan models.GeocodingJobItem)
+	done := make(chan struct{})
+	for i := 0; i < g.workers; i++ {
+		go func() {
+			for item := range items {
+				g.process(ctx, limiter.C, item)
+			}
+			done <- struct{}{}
+		}()
+	}
+	defer func() {
+		close(items)
+		for i := 0; i < g.workers; i++ {
+			<-done
+		}
+	}()
+
+	poll := time.NewTicker(geocodingPollInterval)
+	defer poll.Stop()
+	for {
+		claimed, err := g.jobs.ClaimPending(g.workers)
+		if err != nil {
+			slog.Error("Erro ao buscar itens pendentes de geocoding", slog.String("error", err.Error()))
+		}
+		for _, item := range claimed {
+			select {
+			case items <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if len(claimed) > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-g.wake:
+		case <-poll.C:
+			g.requeueExpired()
+		}
+	}
+}
+
+// requeueExpired devolve para a fila os itens cuja reserva expirou.
+func (g *GeocodingWorker) requeueExpired() {
+	if requeued, err := g.jobs.RequeueExpired(geocodingClaimLease); err != nil {
+		slog.Error("Erro ao reenfileirar itens de geocoding abandonados", slog.String("error", err.Error()))
+	} else if requeued > 0 {
+		slog.Info("Itens de geocoding abandonados reenfileirados", slog.Int64("itens", requeued))
+	}
+}
+
+// process geocodifica o endereço da entrega e grava as coordenadas, registrando o resultado no item.
+func (g *GeocodingWorker) process(ctx context.Context, limiter <-chan time.Time, item models.GeocodingJobItem) {
+	logger := slog.With(slog.Uint64("job_id", uint64(item.JobID)), slog.Uint64("client_id", uint64(item.ClientID)))
+
+	client, err := g.repo.FindByID(item.ClientID)
+	if err != nil {
+		g.finish(logger, item, models.GeocodingItemFailed, err)
+		return
+	}
+	if !client.NeedsGeocoding() {
+		// As coordenadas foram informadas ou confirmadas depois que a entrega foi enfileirada
+		g.finish(logger, item, models.GeocodingItemDone, nil)
+		return
+	}
+
+	// Respeita o intervalo mínimo entre as consultas ao provedor
+	select {
+	case <-limiter:
+	case <-ctx.Done():
+		g.finish(logger, item, models.GeocodingItemPending, nil)
+		return
+	}
+
+	result, err := GeocodeAddress(ctx, g.geocoder, client.Address)
+	if err != nil {
+		if ctx.Err() != nil {
+			g.finish(logger, item, models.GeocodingItemPending, nil)
+			return
+		}
+		if errors.Is(err, ErrUnavailable) {
+			// O provedor está indisponível: o item volta para a fila depois de uma pausa
+			logger.Warn("Provedor de geocoding indisponível; item devolvido para a fila", slog.Duration("espera", geocodingUnavailableDelay))
+			select {
+			case <-time.After(geocodingUnavailableDelay):
+			case <-ctx.Done():
+			}
+			g.finish(logger, item, models.GeocodingItemPending, nil)
+			return
+		}
+		g.finish(logger, item, models.GeocodingItemFailed, err)
+		return
+	}
+
+	fields := map[string]interface{}{
+		"Latitude":          result.Latitude,
+		"Longitude":         result.Longitude,
+		"CoordinatesStale":  false,
+		"LocationType":      result.LocationType,
+		"GeocodeConfidence": result.Confidence,
+	}
+	if _, err := g.repo.Update(client.ID, client.Version, fields); err != nil {
+		if errors.Is(err, ErrVersionMismatch) {
+			// A entrega foi alterada durante o geocoding: o item volta para a fila e o endereço é relido
+			g.finish(logger, item, models.GeocodingItemPending, nil)
+			return
+		}
+		g.finish(logger, item, models.GeocodingItemFailed, err)
+		return
+	}
+	g.finish(logger, item, models.GeocodingItemDone, nil)
+}
+
+// finish grava a situação do item, com o motivo da falha quando houver.
+func (g *GeocodingWorker) finish(logger *slog.Logger, item models.GeocodingJobItem, status string, cause error) {
+	reason := ""
+	if cause != nil {
+		reason = cause.Error()
+		logger.Warn("Falha no geocoding da entrega", slog.Int("tentativa", item.Attempts), slog.String("error", reason))
+	} else {
+		logger.Info("Item de geocoding processado", slog.String("status", status))
+	}
+	if err := g.jobs.FinishItem(item.ID, status, reason); err != nil {
+		logger.Error("Erro ao gravar o resultado do item de geocoding", slog.String("error", err.Error()))
+	}
+	if status == models.GeocodingItemPending {
+		g.notify()
+	}
+}
+
+// notify acorda o dispatcher sem bloquear, caso ele esteja aguardando novos itens.
+func (g *GeocodingWorker) notify() {
+	select {
+	case g.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"myapi/geocoding"
+	"myapi/models"
+	"myapi/repository"
+	"reflect"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// Tipos de mídia aceitos pelo PATCH de clientes.
+const (
+	MergePatchMediaType = "application/merge-patch+json" // JSON Merge Patch (RFC 7396)
+	JSONPatchMediaType  = "application/json-patch+json"  // JSON Patch (RFC 6902)
+)
+
+// ErrInvalidPatch é retornado quando o documento de patch está malformado, usa um tipo de mídia
+// não suportado ou tenta alterar campos que não podem ser modificados (ID, timestamps e versão).
+var ErrInvalidPatch = errors.New("patch inválido")
+
+// ErrInvalidClient é retornado quando o cliente resultante do patch não passa na validação.
+// O erro retornado também envolve o *ValidationError e, portanto, corresponde a ErrValidation.
+var ErrInvalidClient = errors.New("dados inválidos do cliente")
+
+// readOnlyFields são as chaves JSON do cliente que não podem ser alteradas por um patch.
+var readOnlyFields = []string{"ID", "CreatedAt", "UpdatedAt", "DeletedAt", "version", "coordinates_stale"}
+
+// PatchClientData aplica um JSON Merge Patch ou um JSON Patch ao cliente e salva o resultado.
+//
+// Diferente de UpdateClientData, valores explícitos são respeitados: `null` (ou a operação `remove`)
+// limpa o campo e strings vazias ou zeros são gravados como enviados. O cliente resultante é validado
+// com ValidateCommonClientFields antes de ser salvo. Assim como no PUT, alterar o endereço sem alterar
+// as coordenadas as marca como desatualizadas (CoordinatesStale), e alterar as coordenadas sem `location_type`
+// descarta a precisão do geocoding anterior.
+//
+// Parâmetros:
+// - repo (repository.DeliveryRepository): Repositório onde o cliente está persistido.
+// - id (uint): ID do cliente a ser alterado.
+// - expectedVersion (uint): Versão esperada do cliente (If-Match). Com 0, a versão não é verificada.
+// - mediaType (string): MergePatchMediaType ou JSONPatchMediaType.
+// - patch ([]byte): Documento de patch recebido.
+//
+// Retorno:
+//   - models.Client: O cliente após a aplicação do patch.
+//   - error: Envolve repository.ErrNotFound, repository.ErrVersionMismatch, ErrInvalidPatch ou
+//     ErrInvalidClient, conforme o caso.
+//
+// Exemplo de uso:
+//
+//	client, err := PatchClientData(repo, 1, 0, MergePatchMediaType, []byte(`{"complement": null}`))
+func PatchClientData(repo repository.DeliveryRepository, id uint, expectedVersion uint, mediaType string, patch []byte) (models.Client, error) {
+	current, err := repo.FindByID(id)
+	if err != nil {
+		return models.Client{}, fmt.Errorf("cliente com ID %d: %w", id, err)
+	}
+	if expectedVersion > 0 && current.Version != expectedVersion {
+		return models.Client{}, fmt.Errorf("cliente com ID %d: %w", id, repository.ErrVersionMismatch)
+	}
+
+	// Documento original, apenas com os campos editáveis
+	original, err := editableDocument(current)
+	if err != nil {
+		return models.Client{}, err
+	}
+
+	// Aplica o patch de acordo com o tipo de mídia
+	var patched []byte
+	switch mediaType {
+	case MergePatchMediaType:
+		patched, err = jsonpatch.MergePatch(original, patch)
+	case JSONPatchMediaType:
+		var operations jsonpatch.Patch
+		operations, err = jsonpatch.DecodePatch(patch)
+		if err == nil {
+			patched, err = operations.Apply(original)
+		}
+	default:
+		return models.Client{}, fmt.Errorf("%w: tipo de mídia não suportado %q", ErrInvalidPatch, mediaType)
+	}
+	if err != nil {
+		return models.Client{}, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	// Campos somente leitura não podem aparecer no resultado, nem mesmo com o valor zero
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(patched, &document); err != nil {
+		return models.Client{}, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	for _, field := range readOnlyFields {
+		if _, ok := document[field]; ok {
+			return models.Client{}, fmt.Errorf("%w: ID, timestamps, versão e coordinates_stale não podem ser alterados", ErrInvalidPatch)
+		}
+	}
+
+	// Decodifica o resultado em um cliente vazio: campos removidos ou nulos ficam com o valor zero
+	var merged models.Client
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&merged); err != nil {
+		return models.Client{}, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	if merged.ID != 0 || !merged.CreatedAt.IsZero() || !merged.UpdatedAt.IsZero() || merged.DeletedAt != nil || merged.Version != 0 || merged.CoordinatesStale {
+		return models.Client{}, fmt.Errorf("%w: ID, timestamps, versão e coordinates_stale não podem ser alterados", ErrInvalidPatch)
+	}
+	merged.Model = current.Model
+	merged.Version = current.Version
+	merged.CoordinatesStale = current.CoordinatesStale
+	merged.PostalCode, _ = geocoding.FormatPostalCode(merged.PostalCode)
+
+	// Revalida o cliente resultante
+	if err := ValidateCommonClientFields(merged); err != nil {
+		return models.Client{}, fmt.Errorf("%w: %w", ErrInvalidClient, err)
+	}
+
+	// Monta a atualização apenas com os campos alterados
+	updateData := changedFields(current, merged)
+	markStaleCoordinates(current, updateData)
+	resetGeocodePrecision(updateData)
+	if len(updateData) == 0 {
+		slog.Info("Patch sem alterações no cliente", slog.Int("client_id", int(id)))
+		return current, nil
+	}
+
+	// A atualização é condicional à versão lida, para não sobrescrever uma alteração concorrente
+	updated, err := repo.Update(id, current.Version, updateData)
+	if err != nil {
+		return models.Client{}, fmt.Errorf("cliente com ID %d: %w", id, err)
+	}
+	slog.Info("Patch aplicado ao cliente", slog.Int("client_id", int(id)), slog.Int("fields", len(updateData)))
+	return updated, nil
+}
+
+// editableDocument serializa o cliente em JSON sem os campos somente leitura.
+func editableDocument(client models.Client) ([]byte, error) {
+	raw, err := json.Marshal(client)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar o cliente: %w", err)
+	}
+	var document map[string]interface{}
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, fmt.Errorf("erro ao serializar o cliente: %w", err)
+	}
+	for _, field := range readOnlyFields {
+		delete(document, field)
+	}
+	return json.Marshal(document)
+}
+
+// changedFields compara os campos do cliente (exceto gorm.Model e a versão) e retorna os que foram alterados,
+// chaveados pelo nome do campo em models.Client, no formato esperado por DeliveryRepository.Update.
+func changedFields(current, merged models.Client) map[string]interface{} {
+	changed := map[string]interface{}{}
+	currentValue := reflect.ValueOf(current)
+	mergedValue := reflect.ValueOf(merged)
+	clientType := currentValue.Type()
+
+	for i := 0; i < clientType.NumField(); i++ {
+		fieldName := clientType.Field(i).Name
+		if fieldName == "Model" || fieldName == "Version" {
+			continue
+		}
+		if !reflect.DeepEqual(currentValue.Field(i).Interface(), mergedValue.Field(i).Interface()) {
+			changed[fieldName] = mergedValue.Field(i).Interface()
+		}
+	}
+	return changed
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"myapi/geocoding"
+	"myapi/models"
+	"strings"
+)
+
+// LookupPostalCode busca o endereço de um CEP no provedor de consulta de CEP.
+//
+// Parâmetros:
+// - ctx (context.Context): Contexto da requisição; o cancelamento interrompe a consulta ao provedor.
+// - lookup (geocoding.PostalCodeLookup): Provedor de consulta de CEP configurado (nil quando não configurado).
+// - postalCode (string): CEP no formato 00000-000 ou 00000000.
+//
+// Retorno:
+// - models.PostalCodeAddress: Rua, bairro, cidade, estado e país do CEP.
+// - error: *ValidationError (CEP malformado), ErrNotFound (CEP inexistente) ou ErrUpstream (falha do provedor).
+//
+// Exemplo de uso:
+//
+//	address, err := LookupPostalCode(r.Context(), lookup, "01310-100")
+func LookupPostalCode(ctx context.Context, lookup geocoding.PostalCodeLookup, postalCode string) (models.PostalCodeAddress, error) {
+	formatted, ok := geocoding.FormatPostalCode(postalCode)
+	if !ok {
+		result := &ValidationError{}
+		result.Add("cep", CodeInvalid, "validation.invalid_postal_code", postalCode)
+		return models.PostalCodeAddress{}, result
+	}
+	if lookup == nil {
+		return models.PostalCodeAddress{}, fmt.Errorf("%w: consulta de CEP não configurada", ErrUpstream)
+	}
+
+	address, err := lookup.LookupPostalCode(ctx, formatted)
+	if errors.Is(err, geocoding.ErrNoResults) {
+		slog.Info("CEP não encontrado", slog.String("cep", formatted))
+		return models.PostalCodeAddress{}, fmt.Errorf("%w: CEP %s não encontrado", ErrNotFound, formatted)
+	}
+	if err != nil {
+		return models.PostalCodeAddress{}, err
+	}
+	slog.Info("Endereço do CEP encontrado", slog.String("cep", formatted), slog.String("cidade", address.City), slog.String("estado", address.State))
+	return address, nil
+}
+
+// CheckPostalCode confere se o CEP do cliente existe e pertence à cidade e ao estado informados.
+// Cidades e estados são comparados sem maiúsculas e acentos, e o estado pode ser a sigla ou o nome
+// (`SP` ou `São Paulo`).
+//
+// Clientes sem CEP, com o CEP malformado (reportado por ValidateCommonClientFields) ou sem a cidade e o
+// estado não são conferidos. Falhas do provedor também não impedem o cadastro: são registradas no log e o
+// cliente segue sem a conferência.
+//
+// Retorna um *ValidationError com `postal_code` (CEP inexistente) ou `city` e `state` (divergentes do CEP),
+// ou nil.
+//
+// Exemplo de uso:
+//
+//	if err := CheckPostalCode(r.Context(), lookup, client); err != nil {
+//		return err
+//	}
+func CheckPostalCode(ctx context.Context, lookup geocoding.PostalCodeLookup, client models.Client) error {
+	postalCode, ok := geocoding.FormatPostalCode(client.PostalCode)
+	if lookup == nil || !ok || (strings.TrimSpace(client.City) == "" && strings.TrimSpace(client.State) == "") {
+		return nil
+	}
+
+	result := &ValidationError{}
+	address, err := lookup.LookupPostalCode(ctx, postalCode)
+	switch {
+	case errors.Is(err, geocoding.ErrNoResults):
+		result.Add("postal_code", CodeNotFound, "validation.postal_code_not_found", client.PostalCode)
+		return result
+	case err != nil:
+		slog.Warn("Não foi possível conferir o CEP do cliente; o cadastro segue sem a conferência",
+			slog.String("cep", postalCode), slog.String("error", err.Error()))
+		return nil
+	}
+
+	if strings.TrimSpace(client.City) != "" && address.City != "" && !geocoding.SamePlace(client.City, address.City) {
+		result.Add("city", CodeMismatch, "validation.postal_code_mismatch", client.City, address.City)
+	}
+	if strings.TrimSpace(client.State) != "" && address.State != "" && !geocoding.SameState(client.State, address.State) {
+		result.Add("state", CodeMismatch, "validation.postal_code_mismatch", client.State, address.State)
+	}
+	return result.Err()
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"myapi/config"
+	"myapi/repository"
+	"time"
+)
+
+// PurgeResult descreve o resultado de uma limpeza de clientes arquivados.
+type PurgeResult struct {
+	Cutoff time.Time // Clientes arquivados antes deste instante foram (ou seriam) removidos
+	DryRun bool      // Indica que nada foi removido, apenas contado
+	Purged int       // Quantidade de clientes arquivados removidos (ou que seriam removidos)
+}
+
+// Count retorna a quantidade de clientes arquivados afetados pela limpeza.
+func (p PurgeResult) Count() int {
+	return p.Purged
+}
+
+// PurgeArchivedClients remove definitivamente os clientes arquivados há mais de `retentionDays` dias.
+//
+// Cada lote removido gera um registro de auditoria no log, com a quantidade e o menor e o maior ID do lote,
+// e cada execução um registro com o instante de corte e o total. No modo dryRun nada é removido e o
+// resultado informa o que seria removido.
+//
+// Parâmetros:
+// - repo (repository.DeliveryRepository): Repositório onde os clientes arquivados estão persistidos.
+// - retentionDays (int): Dias que um cliente arquivado deve ser mantido.
+// - now (time.Time): Instante de referência para o cálculo do corte.
+// - dryRun (bool): Quando `true`, apenas conta os clientes que seriam removidos.
+//
+// Retorno:
+// - PurgeResult: Instante de corte e quantidade de clientes afetados.
+// - error: Erro retornado pelo repositório. Os lotes removidos antes do erro não são restaurados.
+//
+// Exemplo de uso:
+//
+//	result, err := PurgeArchivedClients(repo, 90, time.Now(), true)
+//	if err == nil {
+//		log.Printf("%d clientes arquivados seriam removidos", result.Count())
+//	}
+func PurgeArchivedClients(repo repository.DeliveryRepository, retentionDays int, now time.Time, dryRun bool) (PurgeResult, error) {
+	result := PurgeResult{Cutoff: now.AddDate(0, 0, -retentionDays), DryRun: dryRun}
+
+	purged, err := repo.PurgeArchived(result.Cutoff, dryRun, func(batch repository.PurgeBatch) {
+		// Registro de auditoria de cada lote
+		slog.Info("Auditoria: lote de clientes arquivados removido",
+			slog.String("audit", "archived_clients.purge_batch"),
+			slog.Bool("dry_run", dryRun),
+			slog.Int("count", batch.Count),
+			slog.Int("min_id", batch.MinID),
+			slog.Int("max_id", batch.MaxID),
+		)
+	})
+	if err != nil {
+		slog.Error("Erro ao remover clientes arquivados",
+			slog.Time("cutoff", result.Cutoff),
+			slog.Bool("dry_run", dryRun),
+			slog.Int("count", purged),
+			slog.String("error", err.Error()),
+		)
+		return PurgeResult{}, err
+	}
+	result.Purged = purged
+
+	// Registro de auditoria da limpeza
+	slog.Info("Auditoria: limpeza de clientes arquivados",
+		slog.String("audit", "archived_clients.purge"),
+		slog.Time("cutoff", result.Cutoff),
+		slog.Int("retention_days", retentionDays),
+		slog.Bool("dry_run", dryRun),
+		slog.Int("count", result.Count()),
+	)
+	return result, nil
+}
+
+// RunRetentionScheduler executa a limpeza dos clientes arquivados periodicamente até o contexto ser cancelado.
+//
+// A primeira limpeza acontece logo na inicialização e as seguintes a cada `settings.Interval`.
+// Erros são registrados no log e não interrompem o agendamento. Se a retenção estiver desativada
+// (`archived_days` igual a 0), a função retorna imediatamente.
+//
+// Exemplo de uso:
+//
+//	go services.RunRetentionScheduler(ctx, repo, settings.Retention)
+func RunRetentionScheduler(ctx context.Context, repo repository.DeliveryRepository, settings config.RetentionSettings) {
+	if !settings.Enabled() {
+		slog.Info("Limpeza de clientes arquivados desativada")
+		return
+	}
+	slog.Info("Limpeza de clientes arquivados agendada",
+		slog.Int("retention_days", settings.ArchivedDays),
+		slog.Duration("interval", settings.Interval),
+	)
+
+	ticker := time.NewTicker(settings.Interval)
+	defer ticker.Stop()
+	for {
+		// O erro já foi registrado no log por PurgeArchivedClients
+		_, _ = PurgeArchivedClients(repo, settings.ArchivedDays, time.Now(), false)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
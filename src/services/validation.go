@@ -1,18 +1,111 @@
 package services
 
 import (
-	"fmt"
 	"log/slog"
+	"myapi/geocoding"
+	"myapi/i18n"
 	"myapi/models"
+	"strings"
 )
 
+// Códigos de erro de validação, estáveis para consumo por máquinas.
+const (
+	CodeRequired       = "required"         // Campo obrigatório ausente ou vazio
+	CodeMustBePositive = "must_be_positive" // Valor numérico deve ser maior que 0
+	CodeOutOfRange     = "out_of_range"     // Valor fora do intervalo permitido
+	CodeInvalid        = "invalid"          // Valor malformado ou de tipo incorreto
+	CodeTooLong        = "too_long"         // Valor maior que o tamanho permitido
+	CodeTooShort       = "too_short"        // Valor menor que o tamanho mínimo
+	CodeNotFound       = "not_found"        // Valor bem formado, mas inexistente (por exemplo, um CEP)
+	CodeMismatch       = "mismatch"         // Valor divergente de outro campo (por exemplo, a cidade do CEP)
+)
+
+// FieldError descreve um campo inválido.
+type FieldError struct {
+	Field   string `json:"field"`   // Caminho do campo no JSON (por exemplo, "weight_kg")
+	Code    string `json:"code"`    // Código do erro (CodeRequired, CodeMustBePositive, ...)
+	Message string `json:"message"` // Mensagem legível, no idioma padrão (veja Localize)
+
+	key  string        // Chave da mensagem no catálogo (pacote i18n)
+	args []interface{} // Argumentos da mensagem
+}
+
+// NewFieldError cria um FieldError com a mensagem `key` do catálogo, formatada com `args`.
+//
+// Exemplo de uso:
+//
+//	services.NewFieldError("id", services.CodeInvalid, "request.invalid_id")
+func NewFieldError(field, code, key string, args ...interface{}) FieldError {
+	return FieldError{
+		Field:   field,
+		Code:    code,
+		Message: i18n.T(i18n.Default, key, args...),
+		key:     key,
+		args:    args,
+	}
+}
+
+// Localize retorna o FieldError com a mensagem traduzida para o idioma informado.
+func (f FieldError) Localize(lang i18n.Lang) FieldError {
+	if f.key != "" {
+		f.Message = i18n.T(lang, f.key, f.args...)
+	}
+	return f
+}
+
+// ValidationError reúne todos os campos inválidos de uma validação.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+// Error retorna as mensagens de todos os campos inválidos, separadas por ponto e vírgula.
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fieldError := range e.Errors {
+		messages = append(messages, fieldError.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Is faz com que todo *ValidationError corresponda a ErrValidation em errors.Is.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Localize retorna os campos inválidos com as mensagens traduzidas para o idioma informado.
+func (e *ValidationError) Localize(lang i18n.Lang) []FieldError {
+	localized := make([]FieldError, 0, len(e.Errors))
+	for _, fieldError := range e.Errors {
+		localized = append(localized, fieldError.Localize(lang))
+	}
+	return localized
+}
+
+// Add registra um campo inválido e loga o erro. A mensagem `key` do catálogo recebe o caminho do campo
+// como primeiro argumento, seguido de `args`.
+func (e *ValidationError) Add(field, code, key string, value interface{}, args ...interface{}) {
+	if code == CodeRequired {
+		slog.Error("Missing required field", "field", field, "value", value)
+	} else {
+		slog.Error("Invalid field value", "field", field, "code", code, "value", value)
+	}
+	e.Errors = append(e.Errors, NewFieldError(field, code, key, append([]interface{}{field}, args...)...))
+}
+
+// Err retorna a própria validação como erro, ou nil caso nenhum campo seja inválido.
+func (e *ValidationError) Err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
 // ValidateCommonClientFields valida os campos obrigatórios de um cliente.
-// Esta função verifica se todos os campos do cliente são válidos antes de prosseguir para outras operações.
-// Caso algum campo seja inválido ou ausente, ela loga um erro e retorna uma mensagem indicando o campo faltante ou inválido.
+// Esta função verifica todos os campos do cliente antes de prosseguir para outras operações e reúne
+// cada campo faltante ou inválido em um *ValidationError, com o caminho do campo, o código e a mensagem.
 //
 // Campos validados:
 // - Name: não pode ser vazio.
-// - TestTechnical: não pode ser vazio.
 // - WeightKg: deve ser maior que 0.
 // - Address: não pode ser vazio.
 // - Street: não pode ser vazio.
@@ -21,79 +114,91 @@ import (
 // - City: não pode ser vazio.
 // - State: não pode ser vazio.
 // - Country: não pode ser vazio.
-// - Latitude: deve ser um valor válido (diferente de 0).
-// - Longitude: deve ser um valor válido (diferente de 0).
+// - PostalCode: vazio ou um CEP válido (00000-000 ou 00000000).
+// - Latitude: deve ser um valor válido (diferente de 0, entre -90 e 90).
+// - Longitude: deve ser um valor válido (diferente de 0, entre -180 e 180).
+// - LocationType: vazio ou uma das precisões do geocoding (ROOFTOP, APPROXIMATE, ...).
+// - GeocodeConfidence: entre 0 e 1.
+//
+// Latitude e longitude ambas iguais a 0 indicam uma entrega cadastrada apenas com o endereço, que é
+// aceita e geocodificada de forma assíncrona (veja GeocodingWorker).
 //
-// Retorna um erro caso algum campo seja inválido.
+// Retorna um *ValidationError caso algum campo seja inválido, ou nil.
 func ValidateCommonClientFields(client models.Client) error {
-	// Validando o campo 'Name'
-	if client.Name == "" {
-		slog.Error("Missing required field", "field", "name", "value", client.Name)
-		return fmt.Errorf("name is required")
+	result := &ValidationError{}
+
+	// Campos de texto obrigatórios
+	required := []struct {
+		field string
+		value string
+	}{
+		{"name", client.Name},
+		{"address", client.Address},
+		{"street", client.Street},
+		{"neighborhood", client.Neighborhood},
+		{"city", client.City},
+		{"state", client.State},
+		{"country", client.Country},
+	}
+	for _, r := range required {
+		if strings.TrimSpace(r.value) == "" {
+			result.Add(r.field, CodeRequired, "validation.required", r.value)
+		}
 	}
 
 	// Validando o campo 'WeightKg'
 	if client.WeightKg <= 0 {
-		slog.Error("Invalid weight value", "field", "WeightKg", "value", client.WeightKg)
-		return fmt.Errorf("weightKg must be greater than 0")
+		result.Add("weight_kg", CodeMustBePositive, "validation.must_be_positive", client.WeightKg)
 	}
 
-	// Validando o campo 'Address'
-	if client.Address == "" {
-		slog.Error("Missing required field", "field", "Address", "value", client.Address)
-		return fmt.Errorf("address is required")
+	// Validando o campo 'Number'
+	if client.Number <= 0 {
+		result.Add("number", CodeMustBePositive, "validation.must_be_positive", client.Number)
 	}
 
-	// Validando o campo 'Street'
-	if client.Street == "" {
-		slog.Error("Missing required field", "field", "Street", "value", client.Street)
-		return fmt.Errorf("street is required")
-	}
+	// Validando o CEP (opcional)
+	validatePostalCode(result, client.PostalCode)
 
-	// Validando o campo 'Number'
-	if client.Number == 0 {
-		slog.Error("Missing required field", "field", "Number", "value", client.Number)
-		return fmt.Errorf("number is required")
-	}
+	// Validando as coordenadas (opcionais quando ambas estão ausentes)
+	validateCoordinates(result, client.Latitude, client.Longitude, client.HasCoordinates())
+	validateGeocodePrecision(result, client.LocationType, client.GeocodeConfidence)
 
-	// Validando o campo 'Neighborhood'
-	if client.Neighborhood == "" {
-		slog.Error("Missing required field", "field", "Neighborhood", "value", client.Neighborhood)
-		return fmt.Errorf("neighborhood is required")
-	}
+	return result.Err()
+}
 
-	// Validando o campo 'City'
-	if client.City == "" {
-		slog.Error("Missing required field", "field", "City", "value", client.City)
-		return fmt.Errorf("city is required")
+// validateCoordinates verifica o intervalo da latitude e da longitude. Com `required`, coordenadas iguais a 0
+// também são consideradas inválidas.
+func validateCoordinates(result *ValidationError, latitude, longitude float64, required bool) {
+	switch {
+	case required && latitude == 0:
+		result.Add("latitude", CodeRequired, "validation.invalid_number", latitude)
+	case latitude < -90 || latitude > 90:
+		result.Add("latitude", CodeOutOfRange, "validation.out_of_range", latitude, -90, 90)
 	}
 
-	// Validando o campo 'State'
-	if client.State == "" {
-		slog.Error("Missing required field", "field", "State", "value", client.State)
-		return fmt.Errorf("state is required")
+	switch {
+	case required && longitude == 0:
+		result.Add("longitude", CodeRequired, "validation.invalid_number", longitude)
+	case longitude < -180 || longitude > 180:
+		result.Add("longitude", CodeOutOfRange, "validation.out_of_range", longitude, -180, 180)
 	}
+}
 
-	// Validando o campo 'Country'
-	if client.Country == "" {
-		slog.Error("Missing required field", "field", "Country", "value", client.Country)
-		return fmt.Errorf("country is required")
+// validatePostalCode verifica o formato do CEP, quando informado.
+func validatePostalCode(result *ValidationError, postalCode string) {
+	if _, ok := geocoding.FormatPostalCode(postalCode); postalCode != "" && !ok {
+		result.Add("postal_code", CodeInvalid, "validation.invalid_postal_code", postalCode)
 	}
+}
 
-	// Validando o campo 'Latitude'
-	if client.Latitude == 0 {
-		slog.Error("Invalid latitude value", "field", "Latitude", "value", client.Latitude)
-		return fmt.Errorf("latitude must be a valid number")
+// validateGeocodePrecision verifica a precisão e a confiança do geocoding enviadas junto com as coordenadas.
+func validateGeocodePrecision(result *ValidationError, locationType string, confidence float64) {
+	if locationType != "" && !geocoding.IsLocationType(locationType) {
+		result.Add("location_type", CodeInvalid, "validation.invalid_location_type", locationType)
 	}
-
-	// Validando o campo 'Longitude'
-	if client.Longitude == 0 {
-		slog.Error("Invalid longitude value", "field", "Longitude", "value", client.Longitude)
-		return fmt.Errorf("longitude must be a valid number")
+	if confidence < 0 || confidence > 1 {
+		result.Add("geocode_confidence", CodeOutOfRange, "validation.out_of_range", confidence, 0, 1)
 	}
-
-	// Se todos os campos estiverem válidos, retorna nil
-	return nil
 }
 
 // CreateClientCheckValues valida o cliente usando a função ValidateCommonClientFields e retorna um mapa com o status da validação.
@@ -123,24 +228,35 @@ func CreateClientCheckValues(client models.Client) (map[string]interface{}, erro
 }
 
 // ValidateClientUpdate valida os dados para atualização de um cliente.
-// Verifica se o ID do cliente é válido. Se não for, retorna um erro indicando que o ID é obrigatório para a atualização.
-// Caso contrário, a validação é considerada bem-sucedida e retorna um status de sucesso.
+// Verifica se o ID do cliente é válido e se os campos numéricos enviados (diferentes de zero) estão
+// dentro dos limites permitidos. Campos vazios ou zero não são alterados pelo PUT e, por isso, não são validados.
 //
 // Retorna um mapa contendo:
 // - "status": O status da validação ("valid" ou outro status de erro).
 // - "message": Uma mensagem detalhada sobre o status da validação.
 //
-// Caso haja erro na validação, retorna um erro com a mensagem correspondente.
+// Caso haja erro na validação, retorna um *ValidationError com todos os campos inválidos.
 func ValidateClientUpdate(client models.ClientUpdate) (map[string]interface{}, error) {
+	result := &ValidationError{}
+
 	// Validação específica de atualização
 	if client.ID <= 0 {
-		// Erro na validação do ID
-		slog.Error("Missing or invalid ID for client update", "field", "ID", "value", client.ID)
-		return nil, fmt.Errorf("ID do cliente é obrigatório para atualização")
+		result.Add("id", CodeRequired, "validation.required", client.ID)
+	}
+	if client.WeightKg < 0 {
+		result.Add("weight_kg", CodeMustBePositive, "validation.must_be_positive", client.WeightKg)
+	}
+	if client.Number < 0 {
+		result.Add("number", CodeMustBePositive, "validation.must_be_positive", client.Number)
+	}
+	validatePostalCode(result, client.PostalCode)
+	validateCoordinates(result, client.Latitude, client.Longitude, false)
+	validateGeocodePrecision(result, client.LocationType, client.GeocodeConfidence)
+
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 
-	// Após validar o ID, os dados serão validados no momento da persistência (quando os dados forem gravados)
-	// Não há mais necessidade de validar os campos nesta etapa
 	slog.Info("Client update validated", "field", "ID", "value", client.ID)
 	return map[string]interface{}{
 		"status":  "valid",
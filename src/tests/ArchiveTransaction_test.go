@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"myapi/models"
+	"myapi/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveAllInBatches(t *testing.T) {
+	repo, db := newSQLiteRepository(t)
+	repo.ArchiveBatchSize = 2
+
+	for i := 0; i < 5; i++ {
+		client := validClient()
+		assert.NoError(t, repo.Create(&client))
+	}
+
+	archived, err := repo.ArchiveAll()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), archived)
+
+	_, total, err := repo.List(repository.ClientFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+
+	var archivedTotal int64
+	assert.NoError(t, db.Model(&models.ArchivedClient{}).Count(&archivedTotal).Error)
+	assert.Equal(t, int64(5), archivedTotal)
+}
+
+func TestArchiveAllRollsBackOnFailure(t *testing.T) {
+	repo, db := newSQLiteRepository(t)
+	repo.ArchiveBatchSize = 2
+
+	for i := 0; i < 5; i++ {
+		client := validClient()
+		assert.NoError(t, repo.Create(&client))
+	}
+
+	// Um registro arquivado com o mesmo ID do último cliente faz o último lote falhar
+	assert.NoError(t, db.Create(&models.ArchivedClient{ID: 5, Name: "Conflito"}).Error)
+
+	archived, err := repo.ArchiveAll()
+	assert.Error(t, err)
+	assert.Equal(t, int64(0), archived)
+
+	// Nenhum cliente foi removido e nenhum lote anterior ficou arquivado
+	_, total, err := repo.List(repository.ClientFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+
+	var archivedTotal int64
+	assert.NoError(t, db.Model(&models.ArchivedClient{}).Count(&archivedTotal).Error)
+	assert.Equal(t, int64(1), archivedTotal)
+}
+
+func TestArchiveByIDRollsBackOnFailure(t *testing.T) {
+	repo, db := newSQLiteRepository(t)
+
+	client := validClient()
+	assert.NoError(t, repo.Create(&client))
+	assert.NoError(t, db.Create(&models.ArchivedClient{ID: int(client.ID), Name: "Conflito"}).Error)
+
+	assert.Error(t, repo.ArchiveByID(client.ID, 0))
+
+	// O cliente continua na tabela principal
+	_, err := repo.FindByID(client.ID)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, repo.ArchiveByID(999, 0), repository.ErrNotFound)
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"encoding/json"
+	"myapi/geocoding"
+	"myapi/models"
+	"myapi/repository"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clientAt retorna um cliente válido com o endereço e as coordenadas informadas.
+func clientAt(address string, latitude, longitude float64) models.Client {
+	client := validClient()
+	client.Address = address
+	client.Latitude, client.Longitude = latitude, longitude
+	return client
+}
+
+func TestAutocompleteCombinesHistoryAndProvider(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	contains := clientAt("Travessa da Rua Teste, 5", -22.7, -43.2)
+	prefix := clientAt("Rua Teste, 123", -22.619, -43.164)
+	duplicate := clientAt("RUA TESTE,  123", -22.619, -43.164)
+	archived := clientAt("Rua Testemunha, 8", -22.8, -43.3)
+	pending := addressOnlyClient("Rua Teste, 999")
+	for _, client := range []*models.Client{&contains, &prefix, &duplicate, &archived, &pending} {
+		assert.NoError(t, repo.Create(client))
+	}
+	assert.NoError(t, repo.ArchiveByID(archived.ID, 0))
+
+	fake := geocoding.NewFake()
+	fake.Add("rua te",
+		geocoding.Result{DisplayName: "Rua Teste, 123", Latitude: -22.619, Longitude: -43.164},
+		geocoding.Result{DisplayName: "Rua Tereza, 10", Latitude: -22.5, Longitude: -43.1},
+	)
+	upstream := &countingGeocoder{Geocoder: fake}
+	router := newTestRouterWithGeocoder(repo, upstream)
+
+	suggest := func(url string) []models.AddressSuggestion {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, url, nil))
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var response struct {
+			Suggestions []models.AddressSuggestion `json:"suggestions"`
+		}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		return response.Suggestions
+	}
+	addresses := func(suggestions []models.AddressSuggestion) (result []string) {
+		for _, suggestion := range suggestions {
+			result = append(result, suggestion.Source+": "+suggestion.Address)
+		}
+		return result
+	}
+
+	// Histórico sem repetições (os que começam com o texto primeiro, ativos antes de arquivados) e depois o provedor;
+	// clientes sem coordenadas não são sugeridos
+	suggestions := suggest("/deliveries/geocoding/autocomplete?q=Rua+Te")
+	assert.Equal(t, []string{
+		"history: RUA TESTE,  123",
+		"history: Rua Testemunha, 8",
+		"history: Travessa da Rua Teste, 5",
+		"provider: Rua Tereza, 10",
+	}, addresses(suggestions))
+	assert.Equal(t, -22.619, suggestions[0].Latitude)
+	assert.Equal(t, 1, upstream.calls)
+
+	// A mesma busca, com outra grafia, é respondida pelo cache
+	suggest("/deliveries/geocoding/autocomplete?q=rua++te")
+	assert.Equal(t, 1, upstream.calls)
+
+	// Com o limite preenchido pelo histórico, o provedor não é consultado
+	suggestions = suggest("/deliveries/geocoding/autocomplete?q=rua+tes&limit=2")
+	assert.Len(t, suggestions, 2)
+	assert.Equal(t, 1, upstream.calls)
+
+	// Nenhum resultado no histórico nem no provedor
+	assert.Empty(t, suggest("/deliveries/geocoding/autocomplete?q=Avenida+Inexistente"))
+
+	// Parâmetros inválidos
+	for _, url := range []string{
+		"/deliveries/geocoding/autocomplete?q=ru",
+		"/deliveries/geocoding/autocomplete?q=rua+te&limit=0",
+		"/deliveries/geocoding/autocomplete?q=rua+te&limit=50",
+	} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, url, nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, url)
+	}
+}
+
+func TestGormSearchAddresses(t *testing.T) {
+	repo, _ := newSQLiteRepository(t)
+	located := clientAt("Rua Teste, 123", -22.619, -43.164)
+	wildcard := clientAt("Rua 100% Teste", -22.7, -43.2)
+	archived := clientAt("Avenida Rua Teste, 9", -22.8, -43.3)
+	pending := addressOnlyClient("Rua Teste, 999")
+	for _, client := range []*models.Client{&located, &wildcard, &archived, &pending} {
+		assert.NoError(t, repo.Create(client))
+	}
+	assert.NoError(t, repo.ArchiveByID(archived.ID, 0))
+
+	addresses, err := repo.SearchAddresses("RUA TESTE", 10)
+	assert.NoError(t, err)
+	if assert.Len(t, addresses, 2) {
+		assert.Equal(t, "Rua Teste, 123", addresses[0].Address)
+		assert.Equal(t, -22.619, addresses[0].Latitude)
+		assert.Equal(t, "Avenida Rua Teste, 9", addresses[1].Address)
+	}
+
+	// Os curingas do LIKE são tratados como texto
+	addresses, err = repo.SearchAddresses("100%", 10)
+	assert.NoError(t, err)
+	if assert.Len(t, addresses, 1) {
+		assert.Equal(t, "Rua 100% Teste", addresses[0].Address)
+	}
+	addresses, err = repo.SearchAddresses("rua_", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, addresses)
+}
@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"myapi/controller"
+	"myapi/geocoding"
+	"myapi/repository"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteClientArchivesInMemory(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	controller := controller.NewAPIController(repo, geocoding.NewFake())
+
+	first, second := validClient(), validClient()
+	assert.NoError(t, repo.Create(&first))
+	assert.NoError(t, repo.Create(&second))
+
+	// Exclui um cliente específico pelo ID
+	req := httptest.NewRequest(http.MethodDelete, "/deliveries?id=1", nil)
+	rr := httptest.NewRecorder()
+	controller.DeleteHandler(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	_, err := repo.FindByID(first.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	archived, _, err := repo.ListArchived(repository.ClientFilter{})
+	assert.NoError(t, err)
+	if assert.Len(t, archived, 1) {
+		assert.Equal(t, int(first.ID), archived[0].ID)
+		assert.Equal(t, first.Name, archived[0].Name)
+		assert.False(t, archived[0].DeletedAt.IsZero())
+	}
+
+	// Exclui todos os clientes restantes
+	req = httptest.NewRequest(http.MethodDelete, "/deliveries?deleteAll=true", nil)
+	rr = httptest.NewRecorder()
+	controller.DeleteHandler(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	_, total, err := repo.List(repository.ClientFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	_, archivedTotal, err := repo.ListArchived(repository.ClientFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), archivedTotal)
+}
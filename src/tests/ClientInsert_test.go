@@ -3,9 +3,8 @@ package tests
 import (
 	"bytes"
 	"encoding/json"
-	"myapi/config"
 	"myapi/controller"
-
+	"myapi/geocoding"
 	"myapi/models"
 	"net/http"
 	"net/http/httptest"
@@ -15,11 +14,11 @@ import (
 )
 
 func TestCreateClient(t *testing.T) {
-	// Conecta ao banco de dados.
-	config.ConnectDB()
+	// Cria o repositório (em memória por padrão, ou MySQL com DB_BACKEND=mysql).
+	repo := newTestRepository(t)
 
 	// Instancia o controller
-	controller := &controller.APIController{}
+	controller := controller.NewAPIController(repo, geocoding.NewFake())
 
 	// Cria um cliente fictício para o teste.
 	client := models.Client{
@@ -57,9 +56,11 @@ func TestCreateClient(t *testing.T) {
 	// Verifica se a resposta HTTP foi bem-sucedida
 	assert.Equal(t, http.StatusOK, rr.Code)
 
-	// Verifica se o cliente foi criado no banco.
-	var createdClient models.Client
-	err = config.DB.First(&createdClient, "name = ?", "Teste Cliente").Error
+	// Verifica se o cliente foi criado no repositório.
+	var response map[string]interface{}
+	err = json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	createdClient, err := repo.FindByID(uint(response["operationID"].(float64)))
 	assert.NoError(t, err)
 	assert.Equal(t, "Teste Cliente", createdClient.Name)
 	assert.Equal(t, float64(70), createdClient.WeightKg)
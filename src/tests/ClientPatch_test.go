@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"myapi/repository"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// patchRequest monta uma requisição PATCH /deliveries/{id} com o tipo de mídia informado.
+func patchRequest(path, mediaType, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPatch, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", mediaType)
+	return req
+}
+
+func TestPatchClientWithMergePatch(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	router := newTestRouter(repo)
+
+	client := validClient()
+	client.Complement = "Apto 101"
+	assert.NoError(t, repo.Create(&client))
+
+	// null limpa o complemento e os demais campos enviados são alterados
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, patchRequest("/deliveries/1", "application/merge-patch+json", `{"complement": null, "name": "Novo nome"}`))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	updated, err := repo.FindByID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "", updated.Complement)
+	assert.Equal(t, "Novo nome", updated.Name)
+	assert.Equal(t, client.City, updated.City)
+
+	// Limpar um campo obrigatório falha na validação e não altera o cliente
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, patchRequest("/deliveries/1", "application/merge-patch+json", `{"city": ""}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	unchanged, err := repo.FindByID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, client.City, unchanged.City)
+}
+
+func TestPatchClientWithJSONPatch(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	router := newTestRouter(repo)
+
+	client := validClient()
+	client.Complement = "Casa"
+	assert.NoError(t, repo.Create(&client))
+
+	body := `[
+		{"op": "test", "path": "/complement", "value": "Casa"},
+		{"op": "replace", "path": "/complement", "value": ""},
+		{"op": "replace", "path": "/weight_kg", "value": 12.5}
+	]`
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, patchRequest("/deliveries/1", "application/json-patch+json", body))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	updated, err := repo.FindByID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "", updated.Complement)
+	assert.Equal(t, 12.5, updated.WeightKg)
+
+	// Uma operação `test` que falha rejeita o patch inteiro
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, patchRequest("/deliveries/1", "application/json-patch+json", `[{"op": "test", "path": "/complement", "value": "Casa"}]`))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestPatchClientRejectsInvalidRequests(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	router := newTestRouter(repo)
+
+	client := validClient()
+	assert.NoError(t, repo.Create(&client))
+
+	cases := []struct {
+		name      string
+		path      string
+		mediaType string
+		body      string
+		status    int
+	}{
+		{"tipo de mídia não suportado", "/deliveries/1", "application/json", `{"name": "x"}`, http.StatusUnsupportedMediaType},
+		{"campo desconhecido", "/deliveries/1", "application/merge-patch+json", `{"unknown": 1}`, http.StatusBadRequest},
+		{"alteração do ID", "/deliveries/1", "application/merge-patch+json", `{"ID": 2}`, http.StatusBadRequest},
+		{"JSON malformado", "/deliveries/1", "application/json-patch+json", `{`, http.StatusBadRequest},
+		{"cliente inexistente", "/deliveries/99", "application/merge-patch+json", `{"name": "x"}`, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, patchRequest(tc.path, tc.mediaType, tc.body))
+		assert.Equal(t, tc.status, rr.Code, tc.name)
+	}
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"errors"
+	"myapi/handlers"
+	"myapi/models"
+	"myapi/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// validClient retorna um cliente com todos os campos obrigatórios preenchidos.
+func validClient() models.Client {
+	return models.Client{
+		Name:         "Teste Cliente",
+		WeightKg:     70,
+		Address:      "Rua Teste, 123",
+		Street:       "Rua Teste",
+		Number:       123,
+		Neighborhood: "Bairro Teste",
+		City:         "Cidade Teste",
+		State:        "Estado Teste",
+		Country:      "Brasil",
+		Latitude:     -22.619,
+		Longitude:    -43.164,
+	}
+}
+
+func TestProcessClientWithMemoryRepository(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+
+	response, err := handlers.ProcessClient(repo, validClient())
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), response["operationID"])
+
+	stored, err := repo.FindByID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Teste Cliente", stored.Name)
+
+	// Um cliente sem os campos obrigatórios não deve ser persistido
+	_, err = handlers.ProcessClient(repo, models.Client{Name: "Incompleto"})
+	assert.Error(t, err)
+	_, total, _ := repo.List(repository.ClientFilter{})
+	assert.Equal(t, int64(1), total)
+}
+
+func TestProcessClientUpdateWithMemoryRepository(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	client := validClient()
+	assert.NoError(t, repo.Create(&client))
+
+	updated, err := handlers.ProcessClientUpdate(repo, models.ClientUpdate{ID: client.ID, Name: "Cliente Atualizado", WeightKg: 75}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "Cliente Atualizado", updated["name"])
+	assert.Equal(t, float64(75), updated["weight_kg"])
+	assert.Equal(t, "Rua Teste", updated["street"])
+
+	_, err = handlers.ProcessClientUpdate(repo, models.ClientUpdate{ID: 99, Name: "Inexistente"}, 0)
+	assert.True(t, errors.Is(err, repository.ErrNotFound))
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"encoding/json"
+	"myapi/repository"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListArchivedClients(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	router := newTestRouter(repo)
+
+	for _, city := range []string{"Uberlândia", "Uberaba", "Uberlândia"} {
+		client := validClient()
+		client.City = city
+		assert.NoError(t, repo.Create(&client))
+	}
+	_, err := repo.ArchiveAll()
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries/archived?city=Uberl%C3%A2ndia&limit=1", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Clients     []map[string]interface{} `json:"clients"`
+		Total       int64                    `json:"total"`
+		TotalPages  int                      `json:"totalPages"`
+		NextPageURL *string                  `json:"nextPageURL"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, int64(2), response.Total)
+	assert.Equal(t, 2, response.TotalPages)
+	assert.Len(t, response.Clients, 1)
+	assert.Equal(t, "Uberlândia", response.Clients[0]["city"])
+	if assert.NotNil(t, response.NextPageURL) {
+		assert.Contains(t, *response.NextPageURL, "/deliveries/archived?limit=1&offset=1")
+	}
+}
+
+func TestRestoreArchivedClient(t *testing.T) {
+	repo, _ := newSQLiteRepository(t)
+	router := newTestRouter(repo)
+
+	client := validClient()
+	assert.NoError(t, repo.Create(&client))
+	original, err := repo.FindByID(client.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.ArchiveByID(client.ID, 0))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/deliveries/archived/1/restore", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	restored, err := repo.FindByID(client.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, original.Name, restored.Name)
+	assert.True(t, original.CreatedAt.Equal(restored.CreatedAt))
+	assert.True(t, original.UpdatedAt.Equal(restored.UpdatedAt))
+
+	_, archivedTotal, err := repo.ListArchived(repository.ClientFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), archivedTotal)
+
+	// Restaurar novamente retorna 404, pois o cliente não está mais arquivado
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/deliveries/archived/1/restore", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestRestoreArchivedClientWithReusedID(t *testing.T) {
+	repo, db := newSQLiteRepository(t)
+	router := newTestRouter(repo)
+
+	client := validClient()
+	assert.NoError(t, repo.Create(&client))
+	assert.NoError(t, repo.ArchiveByID(client.ID, 0))
+
+	// Outro cliente passa a ocupar o mesmo ID (por exemplo, após uma importação manual)
+	reused := validClient()
+	reused.ID = client.ID
+	assert.NoError(t, db.Create(&reused).Error)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/deliveries/archived/1/restore", nil))
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	// O cliente continua arquivado
+	_, archivedTotal, err := repo.ListArchived(repository.ClientFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), archivedTotal)
+}
@@ -1,18 +1,17 @@
 package tests
 
 import (
-	"log"
-	"myapi/config"
+	"errors"
 	"myapi/models"
+	"myapi/repository"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	"gorm.io/gorm"
 )
 
 func TestUpdateClient(t *testing.T) {
-	// 1. Conectar ao banco
-	config.ConnectDB()
+	// 1. Criar o repositório (em memória por padrão, ou MySQL com DB_BACKEND=mysql)
+	repo := newTestRepository(t)
 
 	// 2. Criar um cliente de teste
 	client := models.Client{
@@ -21,34 +20,34 @@ func TestUpdateClient(t *testing.T) {
 		Address:  "Rua Teste, 123",
 	}
 
-	// Inserir o cliente no banco
-	if err := config.DB.Create(&client).Error; err != nil {
-		log.Fatalf("Erro ao inserir cliente no banco: %v", err)
+	// Inserir o cliente no repositório
+	if err := repo.Create(&client); err != nil {
+		t.Fatalf("Erro ao inserir cliente no banco: %v", err)
 	}
 	t.Logf("Cliente criado: %v", client)
 
-	// 3. Atualizar os dados do cliente com o modelo Client
-	client.Name = "Cliente Atualizado"
-	client.WeightKg = 75
-	client.Address = "Rua Teste Atualizada, 456"
-
-	// Atualizar no banco
-	if err := config.DB.Save(&client).Error; err != nil {
-		log.Fatalf("Erro ao atualizar cliente no banco: %v", err)
+	// 3. Atualizar os dados do cliente
+	updated, err := repo.Update(client.ID, 0, map[string]interface{}{
+		"Name":     "Cliente Atualizado",
+		"WeightKg": 75.0,
+		"Address":  "Rua Teste Atualizada, 456",
+	})
+	if err != nil {
+		t.Fatalf("Erro ao atualizar cliente no banco: %v", err)
 	}
-	t.Logf("Cliente atualizado: %v", client)
+	t.Logf("Cliente atualizado: %v", updated)
 
-	// 4. Buscar o cliente no banco de dados após a atualização
-	var dbClient models.Client
-	if err := config.DB.First(&dbClient, client.ID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	// 4. Buscar o cliente no repositório após a atualização
+	dbClient, err := repo.FindByID(client.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			t.Fatalf("Cliente não encontrado no banco com ID %v", client.ID)
 		}
-		log.Fatalf("Erro ao buscar cliente atualizado: %v", err)
+		t.Fatalf("Erro ao buscar cliente atualizado: %v", err)
 	}
 
 	// 5. Verificar se os dados foram atualizados corretamente
-	assert.Equal(t, client.Name, dbClient.Name)
-	assert.Equal(t, client.WeightKg, dbClient.WeightKg)
-	assert.Equal(t, client.Address, dbClient.Address)
+	assert.Equal(t, "Cliente Atualizado", dbClient.Name)
+	assert.Equal(t, float64(75), dbClient.WeightKg)
+	assert.Equal(t, "Rua Teste Atualizada, 456", dbClient.Address)
 }
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"myapi/repository"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIfMatchOnDeliveryRoutes(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	router := newTestRouter(repo)
+
+	client := validClient()
+	assert.NoError(t, repo.Create(&client))
+
+	// GET expõe a versão no ETag
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries/1", nil))
+	assert.Equal(t, `"1"`, rr.Header().Get("ETag"))
+
+	// PUT com a versão atual é aceito e retorna o novo ETag
+	req := httptest.NewRequest(http.MethodPut, "/deliveries/1", strings.NewReader(`{"name": "Primeiro despachante"}`))
+	req.Header.Set("If-Match", `"1"`)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+
+	// Um segundo despachante com a versão antiga recebe 412 em PUT, PATCH e DELETE
+	requests := []*http.Request{
+		httptest.NewRequest(http.MethodPut, "/deliveries/1", strings.NewReader(`{"name": "Segundo despachante"}`)),
+		patchRequest("/deliveries/1", "application/merge-patch+json", `{"name": "Segundo despachante"}`),
+		httptest.NewRequest(http.MethodDelete, "/deliveries/1", nil),
+	}
+	for _, req := range requests {
+		req.Header.Set("If-Match", `"1"`)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code, req.Method)
+	}
+	current, err := repo.FindByID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Primeiro despachante", current.Name)
+
+	// ETag fraco nunca corresponde; lista com a versão atual e `*` são aceitos
+	req = patchRequest("/deliveries/1", "application/merge-patch+json", `{"complement": "Fundos"}`)
+	req.Header.Set("If-Match", `W/"2"`)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+	req = patchRequest("/deliveries/1", "application/merge-patch+json", `{"complement": "Fundos"}`)
+	req.Header.Set("If-Match", `"1", "2"`)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
+
+	req = httptest.NewRequest(http.MethodDelete, "/deliveries/1", nil)
+	req.Header.Set("If-Match", "*")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestConditionalUpdateOnSQLite(t *testing.T) {
+	repo, _ := newSQLiteRepository(t)
+
+	client := validClient()
+	assert.NoError(t, repo.Create(&client))
+	assert.Equal(t, uint(1), client.Version)
+
+	updated, err := repo.Update(client.ID, 1, map[string]interface{}{"Name": "Versão 2"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), updated.Version)
+
+	// A mesma versão esperada não pode ser usada duas vezes
+	_, err = repo.Update(client.ID, 1, map[string]interface{}{"Name": "Sobrescrita"})
+	assert.ErrorIs(t, err, repository.ErrVersionMismatch)
+	assert.ErrorIs(t, repo.ArchiveByID(client.ID, 1), repository.ErrVersionMismatch)
+
+	_, err = repo.Update(999, 1, map[string]interface{}{"Name": "Inexistente"})
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	// A versão é preservada no arquivamento e na restauração
+	assert.NoError(t, repo.ArchiveByID(client.ID, 2))
+	restored, err := repo.RestoreArchived(client.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), restored.Version)
+}
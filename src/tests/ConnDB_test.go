@@ -9,10 +9,18 @@ import (
 	"myapi/config"
 )
 
-// Testa a conexão com o banco de dados MySQL
+// Testa a conexão com o banco de dados MySQL.
+// O teste só é executado quando `DB_BACKEND=mysql`, já que por padrão os testes usam o armazenamento em memória.
 func TestDBConnection(t *testing.T) {
+	settings := loadTestSettings(t)
+	if settings.Database.Backend != config.BackendMySQL {
+		t.Skip("Teste de conexão ignorado: defina DB_BACKEND=mysql para executá-lo")
+	}
+
 	// Chama a função de conexão com o banco de dados do pacote config
-	config.ConnectDB()
+	if err := config.ConnectDB(settings.Database); err != nil {
+		t.Fatalf("Erro ao conectar ao banco de dados: %v", err)
+	}
 
 	// Verifica se a variável global DB foi configurada corretamente
 	if config.DB == nil {
@@ -20,8 +28,7 @@ func TestDBConnection(t *testing.T) {
 	}
 
 	// Verifica se a conexão pode ser "pingada" (confirmando se está ativa)
-	err := config.DB.Exec("SELECT 1").Error
-	if err != nil {
+	if err := config.DB.Exec("SELECT 1").Error; err != nil {
 		t.Fatalf("Erro ao tentar fazer ping no banco de dados: %v", err)
 	}
 
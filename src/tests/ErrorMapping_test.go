@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"myapi/config"
+	"myapi/controller"
+	"myapi/geocoding"
+	"myapi/handlers"
+	"myapi/repository"
+	"myapi/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newDistanceMatrixGeocoder cria o provedor DistanceMatrix apontando para um servidor de testes que sempre
+// responde `response`.
+func newDistanceMatrixGeocoder(t *testing.T, response string) geocoding.Geocoder {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+
+	settings := config.DefaultSettings().Geocoding
+	settings.BaseURL = server.URL
+	settings.APIKey = "chave-teste"
+	geocoder, err := config.NewGeocoder(settings, nil)
+	if err != nil {
+		t.Fatalf("Erro ao criar o provedor de geocoding: %v", err)
+	}
+
+	return geocoder
+}
+
+func TestErrorsCarryCodeAndRequestID(t *testing.T) {
+	router := newTestRouter(repository.NewMemoryRepository())
+
+	// O ID recebido é devolvido no cabeçalho e no corpo do erro
+	req := httptest.NewRequest(http.MethodGet, "/deliveries/42", nil)
+	req.Header.Set(controller.RequestIDHeader, "req-123")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "req-123", rr.Header().Get(controller.RequestIDHeader))
+	problem := decodeProblem(t, rr)
+	assert.Equal(t, "not_found", problem.Code)
+	assert.Equal(t, "req-123", problem.RequestID)
+
+	// Sem o cabeçalho, um ID é gerado
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/deliveries/archived/7/restore", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	problem = decodeProblem(t, rr)
+	assert.NotEmpty(t, problem.RequestID)
+	assert.Equal(t, rr.Header().Get(controller.RequestIDHeader), problem.RequestID)
+}
+
+func TestErrorCodesByStatus(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	router := newTestRouter(repo)
+	client := validClient()
+	assert.NoError(t, repo.Create(&client))
+
+	tests := []struct {
+		name    string
+		request *http.Request
+		status  int
+		code    string
+	}{
+		{"ID inválido", httptest.NewRequest(http.MethodGet, "/deliveries?id=abc", nil), http.StatusBadRequest, "invalid_request"},
+		{"tipo de mídia", patchRequest("/deliveries/1", "application/json", `{}`), http.StatusUnsupportedMediaType, "unsupported_media_type"},
+		{"patch malformado", patchRequest("/deliveries/1", "application/json-patch+json", `{`), http.StatusBadRequest, "invalid_patch"},
+		{"validação", patchRequest("/deliveries/1", "application/merge-patch+json", `{"name": ""}`), http.StatusUnprocessableEntity, "validation_failed"},
+		{"confirmação", httptest.NewRequest(http.MethodDelete, "/deliveries/all", nil), http.StatusPreconditionRequired, "confirmation_required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, tt.request)
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, tt.code, decodeProblem(t, rr).Code)
+		})
+	}
+
+	// If-Match com uma versão desatualizada
+	req := httptest.NewRequest(http.MethodDelete, "/deliveries/1", nil)
+	req.Header.Set("If-Match", `"9"`)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	assert.Equal(t, "precondition_failed", decodeProblem(t, rr).Code)
+}
+
+func TestSearchAddressMapsGeocodingErrors(t *testing.T) {
+	// Endereço sem resultados
+	router := newTestRouterWithGeocoder(repository.NewMemoryRepository(), newDistanceMatrixGeocoder(t, `{"status": "OK", "result": []}`))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries/geoconding/search?endereco=Rua+Inexistente", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "not_found", decodeProblem(t, rr).Code)
+
+	// Falha da API externa
+	router = newTestRouterWithGeocoder(repository.NewMemoryRepository(), newDistanceMatrixGeocoder(t, `{"status": "REQUEST_DENIED"}`))
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries/geoconding/search?endereco=Rua+Teste", nil))
+	assert.Equal(t, http.StatusBadGateway, rr.Code)
+	problem := decodeProblem(t, rr)
+	assert.Equal(t, "upstream_error", problem.Code)
+	assert.Empty(t, problem.Detail, "detalhes de erros 5xx não devem ser expostos")
+}
+
+func TestHandlersReturnTypedErrors(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+
+	response, err := handlers.ProcessClient(repo, validClient())
+	assert.NoError(t, err)
+	assert.NotNil(t, response)
+
+	invalid := validClient()
+	invalid.Name = ""
+	response, err = handlers.ProcessClient(repo, invalid)
+	assert.Nil(t, response)
+	assert.True(t, errors.Is(err, services.ErrValidation))
+
+	// Provedor sem chave de acesso configurada
+	geocoder := geocoding.NewDistanceMatrix(geocoding.DistanceMatrixURL, "", http.DefaultClient)
+	_, err = services.GeocodeAddress(context.Background(), geocoder, "Rua Teste")
+	assert.True(t, errors.Is(err, services.ErrUpstream))
+
+	_, err = services.GeocodeAddress(context.Background(), geocoding.NewFake(), "Rua Teste")
+	assert.True(t, errors.Is(err, services.ErrNotFound))
+}
@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"myapi/config"
+	"myapi/geocoding"
+	"myapi/repository"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newGazetteerGeocoder cria o provedor gazetteer configurado com o arquivo de exemplo do projeto.
+func newGazetteerGeocoder(t *testing.T) geocoding.Geocoder {
+	settings := config.DefaultSettings().Geocoding
+	settings.Provider = geocoding.ProviderGazetteer
+	settings.GazetteerPath = "../gazetteer.example.csv"
+	geocoder, err := config.NewGeocoder(settings, nil)
+	assert.NoError(t, err)
+	return geocoder
+}
+
+func TestGazetteerFuzzyGeocode(t *testing.T) {
+	geocoder := newGazetteerGeocoder(t)
+
+	for _, query := range []string{
+		"Avenida Paulista, 1000, São Paulo",
+		"av. paulista sao paulo",          // Abreviação e sem acentos
+		"Avenida Paulsita, Sao Paulo, SP", // Erro de digitação
+		"Av Pauli",                        // Busca incompleta (autocomplete)
+		"CEP 01310-100",                   // CEP
+		"01310100",
+	} {
+		results, err := geocoder.Geocode(context.Background(), query)
+		if assert.NoError(t, err, query) {
+			assert.Equal(t, "Avenida Paulista, Bela Vista, São Paulo, SP, 01310-100, Brasil", results[0].DisplayName, query)
+			assert.Equal(t, -23.5614, results[0].Latitude, query)
+		}
+	}
+
+	// A cidade vem antes das ruas da cidade
+	results, err := geocoder.Geocode(context.Background(), "Rio de Janeiro")
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, results) {
+		assert.Equal(t, "Rio de Janeiro, RJ, Brasil", results[0].DisplayName)
+	}
+
+	_, err = geocoder.Geocode(context.Background(), "Rua Inexistente, Curitiba")
+	assert.True(t, errors.Is(err, geocoding.ErrNoResults), err)
+}
+
+func TestGazetteerReverseAcrossGridCells(t *testing.T) {
+	gazetteer := geocoding.NewGazetteer([]geocoding.GazetteerEntry{
+		{Street: "Rua Longe", Latitude: -23.03, Longitude: -46.0},
+		{Street: "Rua Oeste", Latitude: -23.0005, Longitude: -46.019},
+		{Street: "Rua Sul", Latitude: -23.015, Longitude: -46.0},
+		{Street: "Rua Norte", Latitude: -22.9995, Longitude: -46.0},
+	})
+
+	// Lugares em células vizinhas da grade, inclusive do outro lado da borda, são encontrados
+	results, err := gazetteer.Reverse(context.Background(), -23.0005, -46.0)
+	assert.NoError(t, err)
+	names := []string{}
+	for _, result := range results {
+		names = append(names, result.DisplayName)
+	}
+	assert.Equal(t, []string{"Rua Norte", "Rua Sul", "Rua Oeste"}, names)
+
+	_, err = gazetteer.Reverse(context.Background(), -23.2, -46.0)
+	assert.True(t, errors.Is(err, geocoding.ErrNoResults), err)
+}
+
+func TestGazetteerReverseAndSearchRoutes(t *testing.T) {
+	router := newTestRouterWithGeocoder(repository.NewMemoryRepository(), newGazetteerGeocoder(t))
+
+	// A busca de endereços funciona sem acesso à rede
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries/geoconding/search?endereco=rua+augusta+sp", nil))
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var result geocoding.Result
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	assert.Equal(t, -23.5534, result.Latitude)
+
+	// O reverso retorna o lugar mais próximo, com os campos de endereço do cliente
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries/geocoding/reverse?lat=-22.9712&lng=-43.1823", nil))
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var address map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &address))
+	assert.Equal(t, "Avenida Atlântica", address["street"])
+	assert.Equal(t, "Copacabana", address["neighborhood"])
+	assert.Equal(t, "Rio de Janeiro", address["city"])
+
+	// Longe de qualquer lugar do gazetteer
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries/geocoding/reverse?lat=-10&lng=-30", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestLoadGazetteerGeoJSON(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "lugares.geojson")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [-43.164, -22.619]},
+		 "properties": {"street": "Rua Teste", "city": "Duque de Caxias", "state": "RJ", "postal_code": "25000-000"}}
+	]}`), 0o644))
+
+	gazetteer, err := geocoding.LoadGazetteer(path)
+	assert.NoError(t, err)
+	results, err := gazetteer.Geocode(context.Background(), "rua teste duque de caxias")
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, -22.619, results[0].Latitude)
+		assert.Equal(t, -43.164, results[0].Longitude)
+	}
+
+	// Arquivos inválidos impedem a inicialização
+	invalid := filepath.Join(dir, "invalido.csv")
+	assert.NoError(t, os.WriteFile(invalid, []byte("street,latitude,longitude\nRua Teste,abc,-43.1\n"), 0o644))
+	_, err = geocoding.LoadGazetteer(invalid)
+	assert.ErrorContains(t, err, "linha 2")
+	_, err = geocoding.LoadGazetteer(filepath.Join(dir, "lugares.txt"))
+	assert.ErrorContains(t, err, "formato do gazetteer")
+
+	t.Setenv("GEOCODING_PROVIDER", geocoding.ProviderGazetteer)
+	_, err = config.Load(nil)
+	assert.ErrorContains(t, err, "geocoding.gazetteer_path")
+}
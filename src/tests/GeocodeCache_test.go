@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"myapi/controller"
+	"myapi/geocoding"
+	"myapi/models"
+	"myapi/repository"
+	"myapi/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingGeocoder conta as consultas encaminhadas ao provedor.
+type countingGeocoder struct {
+	geocoding.Geocoder
+	calls int
+}
+
+func (g *countingGeocoder) Geocode(ctx context.Context, address string) ([]geocoding.Result, error) {
+	g.calls++
+	return g.Geocoder.Geocode(ctx, address)
+}
+
+func TestGeocodeCacheHitsAndInvalidation(t *testing.T) {
+	fake := geocoding.NewFake()
+	fake.Add("Rua Teste, 123", geocoding.Result{Latitude: -22.619, Longitude: -43.164, LocationType: geocoding.LocationRooftop})
+	upstream := &countingGeocoder{Geocoder: fake}
+	cached := services.NewCachedGeocoder(upstream, repository.NewMemoryGeocodeCache(), geocoding.ProviderFake, 0.5, time.Hour)
+	router := newTestRouterWithGeocoder(repository.NewMemoryRepository(), cached, func(api *controller.APIController) {
+		api.GeocodeCache = cached
+	})
+	search := func(address string) int {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries/geoconding/search?endereco="+address, nil))
+		return rr.Code
+	}
+
+	// A segunda busca (com outra grafia do mesmo endereço) é respondida pelo cache
+	assert.Equal(t, http.StatusOK, search("Rua+Teste,+123"))
+	assert.Equal(t, http.StatusOK, search("RUA+TESTE,++123"))
+	assert.Equal(t, 1, upstream.calls)
+
+	// Endereços sem resultados não são armazenados
+	assert.Equal(t, http.StatusNotFound, search("Rua+Inexistente"))
+	assert.Equal(t, http.StatusNotFound, search("Rua+Inexistente"))
+	assert.Equal(t, 3, upstream.calls)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/geocoding/cache", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var stats services.GeocodeCacheStats
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &stats))
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(3), stats.Misses)
+	assert.Equal(t, int64(1), stats.Entries)
+	assert.InDelta(t, 0.25, stats.HitRatio, 0.001)
+
+	// Invalida o endereço: a próxima busca consulta o provedor novamente
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/admin/geocoding/cache?endereco=rua+teste,+123", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"removed": 1}`, rr.Body.String())
+	assert.Equal(t, http.StatusOK, search("Rua+Teste,+123"))
+	assert.Equal(t, 4, upstream.calls)
+
+	// Parâmetro vazio não invalida o cache inteiro
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/admin/geocoding/cache?endereco=", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestGeocodeCacheStoresOnlyConfidentPrimaryResults(t *testing.T) {
+	primary := geocoding.NewFake()
+	primary.Add("Rua Teste, 123", geocoding.Result{Latitude: -22.619, Longitude: -43.164, LocationType: geocoding.LocationRooftop})
+	primary.Add("Centro", geocoding.Result{Latitude: -22.9, Longitude: -43.2, LocationType: geocoding.LocationApproximate})
+	fallback := geocoding.NewFake()
+	fallback.Add("Rua Reserva, 8", geocoding.Result{Latitude: -23.5, Longitude: -46.6, LocationType: geocoding.LocationRooftop})
+	upstream := &countingGeocoder{Geocoder: geocoding.NewChain(0.5,
+		geocoding.ChainProvider{Name: geocoding.ProviderFake, Geocoder: primary},
+		geocoding.ChainProvider{Name: geocoding.ProviderGazetteer, Geocoder: fallback},
+	)}
+	cache := repository.NewMemoryGeocodeCache()
+	cached := services.NewCachedGeocoder(upstream, cache, geocoding.ProviderFake, 0.5, time.Hour)
+
+	// Resultados do fallback e de baixa confiança não são armazenados sob o nome do provedor principal
+	for _, address := range []string{"Rua Teste, 123", "Rua Reserva, 8", "Centro", "Rua Teste, 123", "Rua Reserva, 8", "Centro"} {
+		results, err := cached.Geocode(context.Background(), address)
+		assert.NoError(t, err, address)
+		assert.NotEmpty(t, results, address)
+	}
+	assert.Equal(t, 5, upstream.calls)
+	entries, err := cache.Count(time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), entries)
+}
+
+func TestGeocodeCacheDisabled(t *testing.T) {
+	router := newTestRouter(repository.NewMemoryRepository())
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/admin/geocoding/cache", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "not_found", decodeProblem(t, rr).Code)
+}
+
+func TestGormGeocodeCache(t *testing.T) {
+	_, db := newSQLiteRepository(t)
+	cache := repository.NewGormGeocodeCache(db)
+	now := time.Now()
+	key := services.GeocodeCacheKey("Rua Teste, 123")
+
+	entry := models.GeocodeCacheEntry{
+		Provider:   geocoding.ProviderFake,
+		AddressKey: key,
+		Address:    "rua teste, 123",
+		Results:    []byte(`[{"latitude": 1}]`),
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(time.Hour),
+	}
+	assert.NoError(t, cache.Put(entry))
+
+	// Gravar novamente substitui a entrada
+	entry.Results = []byte(`[{"latitude": 2}]`)
+	assert.NoError(t, cache.Put(entry))
+	found, ok, err := cache.Get(geocoding.ProviderFake, key, now)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.JSONEq(t, `[{"latitude": 2}]`, string(found.Results))
+
+	// Outro provedor e entradas expiradas não são retornados
+	_, ok, err = cache.Get(geocoding.ProviderNominatim, key, now)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	_, ok, err = cache.Get(geocoding.ProviderFake, key, now.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	total, err := cache.Count(now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	removed, err := cache.Invalidate("")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), removed)
+}
@@ -0,0 +1,194 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"myapi/config"
+	"myapi/geocoding"
+	"myapi/models"
+	"myapi/repository"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// failingGeocoder simula um provedor fora do ar.
+type failingGeocoder struct{}
+
+func (failingGeocoder) Geocode(ctx context.Context, address string) ([]geocoding.Result, error) {
+	return nil, fmt.Errorf("%w: provedor fora do ar", geocoding.ErrUpstream)
+}
+
+func (failingGeocoder) Reverse(ctx context.Context, latitude, longitude float64) ([]geocoding.Result, error) {
+	return nil, fmt.Errorf("%w: provedor fora do ar", geocoding.ErrUpstream)
+}
+
+// component cria um componente de endereço com o tipo informado.
+func component(value, componentType string) models.AddressComponent {
+	return models.AddressComponent{LongName: value, ShortName: value, Types: []string{componentType}}
+}
+
+func TestGeocodingChainFallsThrough(t *testing.T) {
+	approximate := geocoding.NewFake()
+	approximate.Add("Rua Teste, 123", geocoding.Result{Latitude: -22.6, Longitude: -43.1, DisplayName: "Duque de Caxias",
+		Address: []models.AddressComponent{component("Duque de Caxias", "locality")}})
+	rooftop := geocoding.NewFake()
+	rooftop.Add("Rua Teste, 123", geocoding.Result{Latitude: -22.619, Longitude: -43.164, DisplayName: "Rua Teste, 123",
+		Address: []models.AddressComponent{component("123", "street_number"), component("Rua Teste", "route")}})
+	empty := geocoding.NewFake()
+
+	chain := geocoding.NewChain(0.5,
+		geocoding.ChainProvider{Name: "fora", Geocoder: failingGeocoder{}},
+		geocoding.ChainProvider{Name: "vazio", Geocoder: empty},
+		geocoding.ChainProvider{Name: "aproximado", Geocoder: approximate},
+		geocoding.ChainProvider{Name: "exato", Geocoder: rooftop},
+	)
+
+	// Falha, nenhum resultado e baixa confiança passam para o próximo provedor
+	results, err := chain.Geocode(context.Background(), "Rua Teste, 123")
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, results) {
+		assert.Equal(t, "exato", results[0].Provider)
+		assert.Equal(t, geocoding.LocationRooftop, results[0].LocationType)
+		assert.Equal(t, 1.0, results[0].Confidence)
+	}
+
+	// Sem um resultado com a confiança mínima, o de maior confiança é retornado
+	chain = geocoding.NewChain(0.5,
+		geocoding.ChainProvider{Name: "aproximado", Geocoder: approximate},
+		geocoding.ChainProvider{Name: "fora", Geocoder: failingGeocoder{}},
+	)
+	results, err = chain.Geocode(context.Background(), "Rua Teste, 123")
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, results) {
+		assert.Equal(t, "aproximado", results[0].Provider)
+		assert.Equal(t, geocoding.LocationApproximate, results[0].LocationType)
+		assert.Equal(t, 0.3, results[0].Confidence)
+	}
+
+	// Sem resultados, a falha de um provedor prevalece: outro provedor poderia ter encontrado o endereço
+	chain = geocoding.NewChain(0.5,
+		geocoding.ChainProvider{Name: "fora", Geocoder: failingGeocoder{}},
+		geocoding.ChainProvider{Name: "vazio", Geocoder: empty},
+	)
+	_, err = chain.Geocode(context.Background(), "Rua Teste, 123")
+	assert.True(t, errors.Is(err, geocoding.ErrUpstream), err)
+	assert.False(t, errors.Is(err, geocoding.ErrNoResults), err)
+
+	// ErrNoResults só quando todos os provedores responderam
+	chain = geocoding.NewChain(0.5,
+		geocoding.ChainProvider{Name: "vazio", Geocoder: empty},
+		geocoding.ChainProvider{Name: "aproximado", Geocoder: approximate},
+	)
+	_, err = chain.Geocode(context.Background(), "Rua Inexistente, 1")
+	assert.True(t, errors.Is(err, geocoding.ErrNoResults), err)
+
+	// Todos os provedores falharam
+	chain = geocoding.NewChain(0.5, geocoding.ChainProvider{Name: "fora", Geocoder: failingGeocoder{}})
+	_, err = chain.Reverse(context.Background(), -22.619, -43.164)
+	assert.True(t, errors.Is(err, geocoding.ErrUpstream), err)
+}
+
+func TestGeocodingChainInterrupted(t *testing.T) {
+	chain := geocoding.NewChain(0.5, geocoding.ChainProvider{Name: "fora", Geocoder: failingGeocoder{}})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// A consulta interrompida pelo contexto corresponde a ErrUnavailable, não a um erro interno
+	_, err := chain.Geocode(ctx, "Rua Teste, 123")
+	assert.ErrorIs(t, err, geocoding.ErrUnavailable)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	router := newTestRouterWithGeocoder(repository.NewMemoryRepository(), chain)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries/geoconding/search?endereco=Rua+Teste,+123", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code, rr.Body.String())
+	assert.Equal(t, "upstream_unavailable", decodeProblem(t, rr).Code)
+}
+
+func TestSearchAddressFallsBackToGazetteer(t *testing.T) {
+	// DistanceMatrix sem chave de acesso (indisponível), com o gazetteer local como fallback
+	settings := config.DefaultSettings().Geocoding
+	settings.Fallback = []string{geocoding.ProviderGazetteer}
+	settings.GazetteerPath = "../gazetteer.example.csv"
+	geocoder, err := config.NewGeocoder(settings, nil)
+	assert.NoError(t, err)
+	router := newTestRouterWithGeocoder(repository.NewMemoryRepository(), geocoder)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries/geoconding/search?endereco=Avenida+Paulista,+1000,+Sao+Paulo", nil))
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var result geocoding.Result
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	assert.Equal(t, geocoding.ProviderGazetteer, result.Provider)
+	assert.Equal(t, geocoding.LocationGeometricCenter, result.LocationType)
+	assert.Equal(t, 0.6, result.Confidence)
+
+	// Provedores de fallback desconhecidos ou repetidos impedem a inicialização
+	t.Setenv("GEOCODING_FALLBACK", "nominatim, oraculo, nominatim")
+	t.Setenv("GEOCODING_MIN_CONFIDENCE", "2")
+	_, err = config.Load(nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `provedor desconhecido "oraculo"`)
+		assert.Contains(t, err.Error(), `provedor "nominatim" repetido`)
+		assert.Contains(t, err.Error(), "geocoding.min_confidence")
+	}
+}
+
+func TestDeliveryStoresGeocodePrecision(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	fake := geocoding.NewFake()
+	fake.Add("Rua Teste, 123", geocoding.Result{Latitude: -22.619, Longitude: -43.164,
+		Address: []models.AddressComponent{component("Rua Teste", "route")}})
+	router := newTestRouterWithGeocoder(repo, fake, withGeocodingWorker(t, repo, fake))
+
+	// O worker grava a precisão e a confiança junto com as coordenadas
+	client := addressOnlyClient("Rua Teste, 123")
+	assert.NoError(t, repo.Create(&client))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/deliveries/geocoding/jobs", nil))
+	assert.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+	var job models.GeocodingJob
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &job))
+	waitGeocodingJob(t, router, job.ID)
+
+	stored, err := repo.FindByID(client.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, geocoding.LocationGeometricCenter, stored.LocationType)
+	assert.Equal(t, 0.6, stored.GeocodeConfidence)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/deliveries/%d", client.ID), bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Coordenadas vindas da busca de endereços trazem a precisão
+	rr = put(`{"latitude": -22.62, "longitude": -43.17, "location_type": "ROOFTOP", "geocode_confidence": 0.9}`)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	stored, _ = repo.FindByID(client.ID)
+	assert.Equal(t, geocoding.LocationRooftop, stored.LocationType)
+	assert.Equal(t, 0.9, stored.GeocodeConfidence)
+
+	// Coordenadas posicionadas manualmente descartam a precisão anterior
+	rr = put(`{"latitude": -22.63, "longitude": -43.18}`)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "", response["location_type"])
+	assert.Equal(t, 0.0, response["geocode_confidence"])
+
+	// Valores inválidos
+	rr = put(`{"location_type": "EXATO", "geocode_confidence": 1.5}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	fields := problemFields(decodeProblem(t, rr))
+	assert.Equal(t, "invalid", fields["location_type"])
+	assert.Equal(t, "out_of_range", fields["geocode_confidence"])
+}
@@ -0,0 +1,509 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"myapi/config"
+	"myapi/controller"
+	"myapi/geocoding"
+	"myapi/models"
+	"myapi/repository"
+	"myapi/services"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// addressOnlyClient retorna um cliente válido sem coordenadas, a ser geocodificado de forma assíncrona.
+func addressOnlyClient(address string) models.Client {
+	client := validClient()
+	client.Address = address
+	client.Latitude, client.Longitude = 0, 0
+	return client
+}
+
+// withGeocodingWorker substitui os jobs de geocoding do controlador por workers em execução até o fim do teste.
+func withGeocodingWorker(t *testing.T, repo repository.DeliveryRepository, geocoder geocoding.Geocoder) func(*controller.APIController) {
+	t.Helper()
+	settings := config.DefaultSettings().Geocoding
+	settings.RequestInterval = time.Millisecond
+
+	worker := services.NewGeocodingWorker(repo, repository.NewMemoryGeocodingJobStore(), geocoder, settings)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go worker.Run(ctx)
+
+	return func(api *controller.APIController) {
+		api.GeocodingJobs = worker
+	}
+}
+
+// waitGeocodingJob aguarda o job terminar e retorna sua situação final.
+func waitGeocodingJob(t *testing.T, router *mux.Router, id uint) models.GeocodingJob {
+	t.Helper()
+	var job models.GeocodingJob
+	assert.Eventually(t, func() bool {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/deliveries/geocoding/jobs/%d", id), nil))
+		if rr.Code != http.StatusOK || json.Unmarshal(rr.Body.Bytes(), &job) != nil {
+			return false
+		}
+		return job.Status == models.GeocodingJobCompleted || job.Status == models.GeocodingJobCompletedWithErrors
+	}, 2*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestCreateAddressOnlyClientIsGeocoded(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	fake := geocoding.NewFake()
+	fake.Add("Rua Teste, 123", geocoding.Result{Latitude: -22.619, Longitude: -43.164})
+	router := newTestRouterWithGeocoder(repo, fake, withGeocodingWorker(t, repo, fake))
+
+	body, _ := json.Marshal(addressOnlyClient("Rua Teste, 123"))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/deliveries", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		OperationID  uint                `json:"operationID"`
+		GeocodingJob models.GeocodingJob `json:"geocoding_job"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, int64(1), response.GeocodingJob.Items.Total)
+
+	job := waitGeocodingJob(t, router, response.GeocodingJob.ID)
+	assert.Equal(t, models.GeocodingJobCompleted, job.Status)
+	client, err := repo.FindByID(response.OperationID)
+	assert.NoError(t, err)
+	assert.Equal(t, -22.619, client.Latitude)
+	assert.Equal(t, -43.164, client.Longitude)
+	assert.Equal(t, uint(2), client.Version)
+}
+
+func TestGeocodingJobFailuresAndRetry(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	found, missing := addressOnlyClient("Rua Teste, 123"), addressOnlyClient("Rua Inexistente, 1")
+	assert.NoError(t, repo.Create(&found))
+	assert.NoError(t, repo.Create(&missing))
+
+	fake := geocoding.NewFake()
+	fake.Add("Rua Teste, 123", geocoding.Result{Latitude: -22.619, Longitude: -43.164})
+	router := newTestRouterWithGeocoder(repo, fake, withGeocodingWorker(t, repo, fake))
+
+	// O job inclui todas as entregas sem coordenadas
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/deliveries/geocoding/jobs", nil))
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	var job models.GeocodingJob
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &job))
+	assert.Equal(t, fmt.Sprintf("/deliveries/geocoding/jobs/%d", job.ID), rr.Header().Get("Location"))
+
+	job = waitGeocodingJob(t, router, job.ID)
+	assert.Equal(t, models.GeocodingJobCompletedWithErrors, job.Status)
+	assert.Equal(t, int64(1), job.Items.Done)
+	assert.Equal(t, int64(1), job.Items.Failed)
+
+	// A falha fica visível com o motivo
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/deliveries/geocoding/jobs/%d/items?status=failed", job.ID), nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var items struct {
+		Items []models.GeocodingJobItem `json:"items"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &items))
+	if assert.Len(t, items.Items, 1) {
+		assert.Equal(t, missing.ID, items.Items[0].ClientID)
+		assert.Equal(t, 1, items.Items[0].Attempts)
+		assert.Contains(t, items.Items[0].Error, "nenhum resultado")
+	}
+
+	// Depois que o endereço passa a ser encontrado, o retry conclui o job
+	fake.Add("Rua Inexistente, 1", geocoding.Result{Latitude: -23.5, Longitude: -46.6})
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/deliveries/geocoding/jobs/%d/retry", job.ID), nil))
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	job = waitGeocodingJob(t, router, job.ID)
+	assert.Equal(t, models.GeocodingJobCompleted, job.Status)
+
+	client, err := repo.FindByID(missing.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, -23.5, client.Latitude)
+
+	// Job inexistente e situação inválida
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/deliveries/geocoding/jobs/99/retry", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/deliveries/geocoding/jobs/%d/items?status=x", job.ID), nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestValidationRequiresBothCoordinates(t *testing.T) {
+	// Sem nenhuma coordenada a entrega é aceita; com apenas uma, a outra é obrigatória
+	assert.NoError(t, services.ValidateCommonClientFields(addressOnlyClient("Rua Teste, 123")))
+
+	client := addressOnlyClient("Rua Teste, 123")
+	client.Latitude = -22.619
+	var validation *services.ValidationError
+	if assert.ErrorAs(t, services.ValidateCommonClientFields(client), &validation) && assert.Len(t, validation.Errors, 1) {
+		assert.Equal(t, "longitude", validation.Errors[0].Field)
+	}
+}
+
+func TestGormGeocodingJobStore(t *testing.T) {
+	_, db := newSQLiteRepository(t)
+	store := repository.NewGormGeocodingJobStore(db)
+
+	job, err := store.CreateJob([]uint{10, 20, 30})
+	assert.NoError(t, err)
+	assert.Equal(t, models.GeocodingJobQueued, job.Status)
+
+	claimed, err := store.ClaimPending(2)
+	assert.NoError(t, err)
+	if assert.Len(t, claimed, 2) {
+		assert.Equal(t, uint(10), claimed[0].ClientID)
+		assert.Equal(t, 1, claimed[0].Attempts)
+	}
+	assert.NoError(t, store.FinishItem(claimed[0].ID, models.GeocodingItemDone, ""))
+	assert.NoError(t, store.FinishItem(claimed[1].ID, models.GeocodingItemFailed, "falha no provedor"))
+
+	job, err = store.FindJob(job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.GeocodingJobRunning, job.Status)
+	assert.Equal(t, models.GeocodingJobItemCounts{Total: 3, Pending: 1, Done: 1, Failed: 1}, job.Items)
+
+	// Itens com a reserva expirada e itens que falharam voltam para a fila
+	claimed, err = store.ClaimPending(5)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+	requeued, err := store.RequeueExpired(time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), requeued)
+	requeued, err = store.RequeueExpired(0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), requeued)
+	requeued, err = store.RetryFailed(job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), requeued)
+
+	items, err := store.ListItems(job.ID, models.GeocodingItemPending)
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+
+	_, err = store.FindJob(99)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestGeocodingJobSkipsQueuedClients(t *testing.T) {
+	_, db := newSQLiteRepository(t)
+	stores := map[string]repository.GeocodingJobStore{
+		"gorm":   repository.NewGormGeocodingJobStore(db),
+		"memory": repository.NewMemoryGeocodingJobStore(),
+	}
+	for name, store := range stores {
+		first, err := store.CreateJob([]uint{1, 2, 2})
+		assert.NoError(t, err, name)
+		assert.Equal(t, int64(2), first.Items.Total, name)
+
+		// O cliente 2 continua na fila do primeiro job, inclusive em processamento
+		claimed, err := store.ClaimPending(2)
+		assert.NoError(t, err, name)
+		second, err := store.CreateJob([]uint{2, 3})
+		assert.NoError(t, err, name)
+		items, err := store.ListItems(second.ID, "")
+		assert.NoError(t, err, name)
+		if assert.Len(t, items, 1, name) {
+			assert.Equal(t, uint(3), items[0].ClientID, name)
+		}
+
+		// Depois de concluído, o cliente pode ser enfileirado de novo
+		for _, item := range claimed {
+			assert.NoError(t, store.FinishItem(item.ID, models.GeocodingItemDone, ""), name)
+		}
+		third, err := store.CreateJob([]uint{1, 2, 3})
+		assert.NoError(t, err, name)
+		assert.Equal(t, int64(2), third.Items.Total, name)
+	}
+}
+
+func TestGeocodingJobRetrySkipsQueuedClients(t *testing.T) {
+	_, db := newSQLiteRepository(t)
+	stores := map[string]repository.GeocodingJobStore{
+		"gorm":   repository.NewGormGeocodingJobStore(db),
+		"memory": repository.NewMemoryGeocodingJobStore(),
+	}
+	for name, store := range stores {
+		first, err := store.CreateJob([]uint{1, 2, 3})
+		assert.NoError(t, err, name)
+		claimed, err := store.ClaimPending(3)
+		assert.NoError(t, err, name)
+		for _, item := range claimed {
+			assert.NoError(t, store.FinishItem(item.ID, models.GeocodingItemFailed, "falha no provedor"), name)
+		}
+
+		// O cliente 2 foi geocodificado por um job mais novo e o cliente 1 está na fila de outro job
+		second, err := store.CreateJob([]uint{2})
+		assert.NoError(t, err, name)
+		claimed, err = store.ClaimPending(1)
+		assert.NoError(t, err, name)
+		if assert.Len(t, claimed, 1, name) {
+			assert.NoError(t, store.FinishItem(claimed[0].ID, models.GeocodingItemDone, ""), name)
+		}
+		_, err = store.CreateJob([]uint{1})
+		assert.NoError(t, err, name)
+
+		// Apenas o cliente 3 volta para a fila do primeiro job
+		requeued, err := store.RetryFailed(first.ID)
+		assert.NoError(t, err, name)
+		assert.Equal(t, int64(1), requeued, name)
+		items, err := store.ListItems(first.ID, models.GeocodingItemPending)
+		assert.NoError(t, err, name)
+		if assert.Len(t, items, 1, name) {
+			assert.Equal(t, uint(3), items[0].ClientID, name)
+		}
+		job, err := store.FindJob(second.ID)
+		assert.NoError(t, err, name)
+		assert.Equal(t, models.GeocodingJobCompleted, job.Status, name)
+	}
+}
+
+func TestGormClaimPendingConcurrently(t *testing.T) {
+	_, db := newSQLiteRepository(t)
+	store := repository.NewGormGeocodingJobStore(db)
+	clientIDs := make([]uint, 20)
+	for i := range clientIDs {
+		clientIDs[i] = uint(i + 1)
+	}
+	_, err := store.CreateJob(clientIDs)
+	assert.NoError(t, err)
+
+	// Workers concorrentes nunca recebem o mesmo item
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	seen := map[uint]int{}
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				claimed, err := store.ClaimPending(3)
+				if !assert.NoError(t, err) || len(claimed) == 0 {
+					return
+				}
+				mu.Lock()
+				for _, item := range claimed {
+					seen[item.ID]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, seen, len(clientIDs))
+	for id, claims := range seen {
+		assert.Equal(t, 1, claims, id)
+	}
+}
+
+// blockingGeocoder conta as consultas e só responde depois que `release` é fechado.
+type blockingGeocoder struct {
+	geocoding.Geocoder
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (g *blockingGeocoder) Geocode(ctx context.Context, address string) ([]geocoding.Result, error) {
+	g.calls.Add(1)
+	select {
+	case <-g.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return g.Geocoder.Geocode(ctx, address)
+}
+
+func TestGeocodingWorkersShareStore(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	client := addressOnlyClient("Rua Teste, 123")
+	assert.NoError(t, repo.Create(&client))
+
+	fake := geocoding.NewFake()
+	fake.Add("Rua Teste, 123", geocoding.Result{Latitude: -22.619, Longitude: -43.164})
+	geocoder := &blockingGeocoder{Geocoder: fake, release: make(chan struct{})}
+	store := repository.NewMemoryGeocodingJobStore()
+	settings := config.DefaultSettings().Geocoding
+	settings.RequestInterval = time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// O primeiro worker reserva o item e fica aguardando o provedor
+	first := services.NewGeocodingWorker(repo, store, geocoder, settings)
+	go first.Run(ctx)
+	job, err := first.Enqueue([]uint{client.ID})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return geocoder.calls.Load() == 1 }, 2*time.Second, 5*time.Millisecond)
+
+	// Um segundo worker (outra instância) iniciado sobre o mesmo armazenamento não toma o item reservado
+	second := services.NewGeocodingWorker(repo, store, geocoder, settings)
+	go second.Run(ctx)
+	time.Sleep(50 * time.Millisecond)
+	items, err := store.ListItems(job.ID, "")
+	assert.NoError(t, err)
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, models.GeocodingItemRunning, items[0].Status)
+		assert.Equal(t, 1, items[0].Attempts)
+	}
+
+	close(geocoder.release)
+	assert.Eventually(t, func() bool {
+		job, err := store.FindJob(job.ID)
+		return err == nil && job.Status == models.GeocodingJobCompleted
+	}, 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, int32(1), geocoder.calls.Load())
+}
+
+func TestAddressChangeMarksCoordinatesStale(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	client := validClient()
+	assert.NoError(t, repo.Create(&client))
+
+	fake := geocoding.NewFake()
+	fake.Add("Rua Nova, 45", geocoding.Result{Latitude: -23.5, Longitude: -46.6})
+	router := newTestRouterWithGeocoder(repo, fake, withGeocodingWorker(t, repo, fake))
+	update := func(method, contentType, body string) map[string]interface{} {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, fmt.Sprintf("/deliveries/%d", client.ID), bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var response map[string]interface{}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		return response
+	}
+
+	// Alterar apenas o nome não afeta as coordenadas
+	response := update(http.MethodPut, "application/json", `{"name": "Outro Nome"}`)
+	assert.Equal(t, false, response["coordinates_stale"])
+	assert.NotContains(t, response, "geocoding_job")
+
+	// O endereço muda sem novas coordenadas: o pin é marcado como desatualizado e re-geocodificado
+	response = update(http.MethodPut, "application/json", `{"address": "Rua Nova, 45", "street": "Rua Nova", "number": 45}`)
+	assert.Equal(t, true, response["coordinates_stale"])
+	assert.Equal(t, -22.619, response["latitude"])
+	job, ok := response["geocoding_job"].(map[string]interface{})
+	if assert.True(t, ok) {
+		assert.Equal(t, models.GeocodingJobCompleted, waitGeocodingJob(t, router, uint(job["id"].(float64))).Status)
+	}
+	stored, err := repo.FindByID(client.ID)
+	assert.NoError(t, err)
+	assert.False(t, stored.CoordinatesStale)
+	assert.Equal(t, -23.5, stored.Latitude)
+	assert.Equal(t, -46.6, stored.Longitude)
+
+	// Coordenadas enviadas na mesma requisição prevalecem sobre o re-geocoding
+	response = update(http.MethodPut, "application/json", `{"address": "Rua Outra, 1", "latitude": -22.9, "longitude": -43.2}`)
+	assert.Equal(t, false, response["coordinates_stale"])
+	assert.NotContains(t, response, "geocoding_job")
+
+	// O PATCH segue a mesma regra e não permite alterar a marcação diretamente
+	response = update(http.MethodPatch, services.MergePatchMediaType, `{"city": "Niterói"}`)
+	assert.Equal(t, true, response["client"].(map[string]interface{})["coordinates_stale"])
+	assert.Contains(t, response, "geocoding_job")
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/deliveries/%d", client.ID), bytes.NewReader([]byte(`{"coordinates_stale": false}`)))
+	req.Header.Set("Content-Type", services.MergePatchMediaType)
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// O CEP também faz parte do endereço; reenviá-lo em outro formato não é uma alteração
+	response = update(http.MethodPut, "application/json", `{"postal_code": "24020-000", "latitude": -22.9, "longitude": -43.1}`)
+	assert.Equal(t, false, response["coordinates_stale"])
+	response = update(http.MethodPut, "application/json", `{"postal_code": "24020000"}`)
+	assert.Equal(t, false, response["coordinates_stale"])
+	assert.NotContains(t, response, "geocoding_job")
+	response = update(http.MethodPut, "application/json", `{"postal_code": "24030-000"}`)
+	assert.Equal(t, true, response["coordinates_stale"])
+	assert.Contains(t, response, "geocoding_job")
+}
+
+// racingRepository simula escritas concorrentes: antes de cada uma das próximas `races` atualizações, outra
+// requisição altera o cliente.
+type racingRepository struct {
+	*repository.MemoryRepository
+	races int
+}
+
+func (r *racingRepository) Update(id uint, expectedVersion uint, fields map[string]interface{}) (models.Client, error) {
+	if r.races > 0 {
+		r.races--
+		if _, err := r.MemoryRepository.Update(id, 0, map[string]interface{}{"Complement": "Alterado"}); err != nil {
+			return models.Client{}, err
+		}
+	}
+	return r.MemoryRepository.Update(id, expectedVersion, fields)
+}
+
+func TestConcurrentAddressUpdateWithoutIfMatch(t *testing.T) {
+	repo := &racingRepository{MemoryRepository: repository.NewMemoryRepository()}
+	client := validClient()
+	assert.NoError(t, repo.Create(&client))
+	router := newTestRouter(repo)
+	put := func(ifMatch, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/deliveries/%d", client.ID), bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Sem If-Match, a escrita concorrente não resulta em 412: a comparação do endereço é refeita
+	repo.races = 1
+	rr := put("", `{"city": "Niterói"}`)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	stored, err := repo.FindByID(client.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Niterói", stored.City)
+	assert.Equal(t, "Alterado", stored.Complement)
+	assert.True(t, stored.CoordinatesStale)
+	assert.Equal(t, uint(3), stored.Version)
+
+	// Com If-Match, a versão informada continua sendo exigida
+	repo.races = 1
+	rr = put(`"3"`, `{"city": "Maricá"}`)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code, rr.Body.String())
+
+	// Se o cliente continua sendo alterado, a atualização desiste com 409
+	repo.races = 10
+	rr = put("", `{"city": "Maricá"}`)
+	assert.Equal(t, http.StatusConflict, rr.Code, rr.Body.String())
+	stored, err = repo.FindByID(client.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Niterói", stored.City)
+}
+
+func TestListIDsNeedingGeocoding(t *testing.T) {
+	repo, _ := newSQLiteRepository(t)
+	located, missing, stale := validClient(), addressOnlyClient("Rua Teste, 123"), validClient()
+	for _, client := range []*models.Client{&located, &missing, &stale} {
+		assert.NoError(t, repo.Create(client))
+	}
+	_, err := services.UpdateClientData(repo, models.ClientUpdate{ID: stale.ID, City: "Niterói"}, 0)
+	assert.NoError(t, err)
+
+	ids, err := repo.ListIDsNeedingGeocoding()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{missing.ID, stale.ID}, ids)
+}
@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"myapi/config"
+	"myapi/geocoding"
+	"myapi/models"
+	"myapi/repository"
+	"myapi/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchAddressWithFakeGeocoder(t *testing.T) {
+	fake := geocoding.NewFake()
+	fake.Add("Rua Teste, 123", geocoding.Result{
+		Latitude:    -22.619,
+		Longitude:   -43.164,
+		DisplayName: "Rua Teste, 123 - Centro",
+	})
+	router := newTestRouterWithGeocoder(repository.NewMemoryRepository(), fake)
+
+	// A busca ignora maiúsculas e espaços extras
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries/geoconding/search?endereco=rua+teste,++123", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var result geocoding.Result
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	assert.Equal(t, -22.619, result.Latitude)
+	assert.Equal(t, -43.164, result.Longitude)
+	assert.Equal(t, "Rua Teste, 123 - Centro", result.DisplayName)
+
+	// Endereço não cadastrado
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries/geoconding/search?endereco=Rua+Inexistente", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "not_found", decodeProblem(t, rr).Code)
+}
+
+func TestNominatimGeocoder(t *testing.T) {
+	var userAgent, format string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		format = r.URL.Query().Get("format")
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("q") == "Rua Inexistente" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[{"lat": "-22.9068", "lon": "-43.1729", "display_name": "Rua Teste, Rio de Janeiro",
+			"address": {"road": "Rua Teste", "city": "Rio de Janeiro", "state": "RJ", "country": "Brasil"}}]`))
+	}))
+	defer server.Close()
+
+	settings := config.DefaultSettings().Geocoding
+	settings.Provider = geocoding.ProviderNominatim
+	settings.BaseURL = server.URL
+	geocoder, err := config.NewGeocoder(settings, nil)
+	assert.NoError(t, err)
+
+	results, err := geocoder.Geocode(context.Background(), "Rua Teste")
+	assert.NoError(t, err)
+	assert.Equal(t, "myapi/1.0", userAgent)
+	assert.Equal(t, "jsonv2", format)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, -22.9068, results[0].Latitude)
+		assert.Equal(t, -43.1729, results[0].Longitude)
+		// Os componentes usam os mesmos tipos da DistanceMatrix
+		types := map[string]string{}
+		for _, component := range results[0].Address {
+			types[component.Types[0]] = component.LongName
+		}
+		assert.Equal(t, "Rua Teste", types["route"])
+		assert.Equal(t, "Rio de Janeiro", types["locality"])
+		assert.Equal(t, "RJ", types["state"])
+	}
+
+	_, err = geocoder.Geocode(context.Background(), "Rua Inexistente")
+	assert.True(t, errors.Is(err, geocoding.ErrNoResults))
+}
+
+func TestLoadGeocodingProvider(t *testing.T) {
+	// O endpoint padrão acompanha o provedor escolhido
+	settings, err := config.Load([]string{"-geocoding-provider", geocoding.ProviderNominatim})
+	assert.NoError(t, err)
+	assert.Equal(t, geocoding.NominatimURL, settings.Geocoding.BaseURL)
+
+	t.Setenv("GEOCODING_PROVIDER", geocoding.ProviderFake)
+	settings, err = config.Load(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, geocoding.ProviderFake, settings.Geocoding.Provider)
+
+	_, err = config.Load([]string{"-geocoding-provider", "google"})
+	assert.ErrorContains(t, err, "geocoding.provider")
+
+	t.Setenv("GEOCODING_USER_AGENT", "")
+	_, err = config.Load([]string{"-geocoding-provider", geocoding.ProviderNominatim})
+	assert.ErrorContains(t, err, "geocoding.user_agent")
+}
+
+func TestReverseGeocodeFillsClientFields(t *testing.T) {
+	fake := geocoding.NewFake()
+	fake.Add("Rua Teste, 123", geocoding.Result{
+		Latitude:    -22.9068,
+		Longitude:   -43.1729,
+		DisplayName: "Rua Teste, 123 - Centro, Rio de Janeiro - RJ, Brasil",
+		Address: []models.AddressComponent{
+			{LongName: "123", Types: []string{"street_number"}},
+			{LongName: "Rua Teste", Types: []string{"route"}},
+			{LongName: "Centro", Types: []string{"sublocality", "political"}},
+			{LongName: "Rio de Janeiro", Types: []string{"locality", "political"}},
+			{LongName: "RJ", Types: []string{"administrative_area_level_1", "political"}},
+			{LongName: "Brasil", Types: []string{"country", "political"}},
+		},
+	})
+	router := newTestRouterWithGeocoder(repository.NewMemoryRepository(), fake)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries/geocoding/reverse?lat=-22.90681&lng=-43.17289", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var address models.ClientAddress
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &address))
+	assert.Equal(t, models.ClientAddress{
+		Address:      "Rua Teste, 123 - Centro, Rio de Janeiro - RJ, Brasil",
+		Street:       "Rua Teste",
+		Number:       123,
+		Neighborhood: "Centro",
+		City:         "Rio de Janeiro",
+		State:        "RJ",
+		Country:      "Brasil",
+		Latitude:     -22.9068,
+		Longitude:    -43.1729,
+
+		LocationType:      geocoding.LocationRooftop,
+		GeocodeConfidence: 1,
+	}, address)
+
+	// Coordenadas sem endereço cadastrado
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries/geocoding/reverse?lat=0&lng=0", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// Todos os parâmetros inválidos são reportados
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries/geocoding/reverse?lat=91", nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	fields := problemFields(decodeProblem(t, rr))
+	assert.Equal(t, "out_of_range", fields["lat"])
+	assert.Equal(t, "required", fields["lng"])
+}
+
+func TestReverseGeocodeProviders(t *testing.T) {
+	var path, latlng string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		latlng = r.URL.Query().Get("latlng")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/reverse" && r.URL.Query().Get("lat") == "0":
+			w.Write([]byte(`{"error": "Unable to geocode"}`))
+		case r.URL.Path == "/reverse":
+			w.Write([]byte(`{"lat": "-22.9068", "lon": "-43.1729", "display_name": "Rua Teste",
+				"address": {"road": "Rua Teste", "suburb": "Centro", "city": "Rio de Janeiro"}}`))
+		default:
+			w.Write([]byte(`{"status": "OK", "result": [{"formatted_address": "Rua Teste",
+				"geometry": {"location": {"lat": -22.9068, "lng": -43.1729}}}]}`))
+		}
+	}))
+	defer server.Close()
+
+	// Nominatim: endpoint /reverse ao lado do endpoint /search
+	nominatim := geocoding.NewNominatim(server.URL+"/search", "myapi-testes", http.DefaultClient)
+	address, err := services.ReverseGeocode(context.Background(), nominatim, -22.9068, -43.1729)
+	assert.NoError(t, err)
+	assert.Equal(t, "/reverse", path)
+	assert.Equal(t, "Rua Teste", address.Street)
+	assert.Equal(t, "Centro", address.Neighborhood)
+	assert.Equal(t, "Rio de Janeiro", address.City)
+
+	_, err = services.ReverseGeocode(context.Background(), nominatim, 0, 0)
+	assert.True(t, errors.Is(err, services.ErrNotFound))
+
+	// DistanceMatrix: parâmetro latlng no mesmo endpoint do geocoding
+	distanceMatrix := geocoding.NewDistanceMatrix(server.URL, "chave-teste", http.DefaultClient)
+	address, err = services.ReverseGeocode(context.Background(), distanceMatrix, -22.9068, -43.1729)
+	assert.NoError(t, err)
+	assert.Equal(t, "-22.9068,-43.1729", latlng)
+	assert.Equal(t, "Rua Teste", address.Address)
+}
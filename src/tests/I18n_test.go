@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"myapi/i18n"
+	"myapi/repository"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   i18n.Lang
+	}{
+		{"", i18n.PtBR},
+		{"en", i18n.En},
+		{"en-US,en;q=0.9", i18n.En},
+		{"pt-BR,pt;q=0.9,en;q=0.8", i18n.PtBR},
+		{"fr-FR, en;q=0.5, pt;q=0.7", i18n.PtBR},
+		{"fr-FR, en-GB;q=0.5", i18n.En},
+		{"de, fr", i18n.PtBR},
+		{"en;q=0, *", i18n.PtBR},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, i18n.Parse(tt.header), tt.header)
+	}
+}
+
+func TestMessageCatalog(t *testing.T) {
+	assert.Equal(t, "Client deleted successfully.", i18n.T(i18n.En, "client.deleted"))
+	assert.Equal(t, "name é obrigatório", i18n.T(i18n.PtBR, "validation.required", "name"))
+	assert.Equal(t, "latitude must be between -90 and 90", i18n.T(i18n.En, "validation.out_of_range", "latitude", -90, 90))
+	assert.Equal(t, "chave.inexistente", i18n.T(i18n.En, "chave.inexistente"))
+}
+
+func TestResponsesFollowAcceptLanguage(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	router := newTestRouter(repo)
+
+	// Erros de validação em inglês
+	req := httptest.NewRequest(http.MethodPost, "/deliveries", strings.NewReader(`{"name": ""}`))
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Equal(t, "en", rr.Header().Get("Content-Language"))
+	problem := decodeProblem(t, rr)
+	assert.Equal(t, "Invalid data", problem.Title)
+	assert.Equal(t, "name is required", problemField(problem, "name").Message)
+
+	// Os mesmos erros em português (padrão)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/deliveries", strings.NewReader(`{"name": ""}`)))
+	problem = decodeProblem(t, rr)
+	assert.Equal(t, "pt-BR", rr.Header().Get("Content-Language"))
+	assert.Equal(t, "name é obrigatório", problemField(problem, "name").Message)
+
+	// Erros de requisição e confirmações de exclusão
+	req = httptest.NewRequest(http.MethodGet, "/deliveries?id=abc", nil)
+	req.Header.Set("Accept-Language", "en")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, "invalid ID", decodeProblem(t, rr).Detail)
+
+	client := validClient()
+	assert.NoError(t, repo.Create(&client))
+	req = httptest.NewRequest(http.MethodDelete, "/deliveries/1", nil)
+	req.Header.Set("Accept-Language", "en")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "Client deleted successfully.", rr.Body.String())
+}
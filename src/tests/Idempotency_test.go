@@ -0,0 +1,164 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"myapi/controller"
+	"myapi/geocoding"
+	"myapi/models"
+	"myapi/repository"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createRequest monta um POST /deliveries com o cliente e a Idempotency-Key informados.
+func createRequest(t *testing.T, client models.Client, key string) *http.Request {
+	t.Helper()
+	body, err := json.Marshal(client)
+	if err != nil {
+		t.Fatalf("Erro ao serializar o cliente: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/deliveries", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	return req
+}
+
+func TestCreateClientWithIdempotencyKey(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	router := newTestRouter(repo)
+	client := validClient()
+
+	first := httptest.NewRecorder()
+	router.ServeHTTP(first, createRequest(t, client, "pedido-123"))
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+
+	// A repetição reproduz a resposta original sem criar outro cliente
+	replay := httptest.NewRecorder()
+	router.ServeHTTP(replay, createRequest(t, client, "pedido-123"))
+	assert.Equal(t, http.StatusOK, replay.Code)
+	assert.Equal(t, "true", replay.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Body.String(), replay.Body.String())
+
+	_, total, err := repo.List(repository.ClientFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	// A mesma chave com outro corpo é rejeitada
+	other := validClient()
+	other.Name = "Outro cliente"
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, createRequest(t, other, "pedido-123"))
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	// Sem a chave, cada requisição cria um cliente
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, createRequest(t, client, ""))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	_, total, err = repo.List(repository.ClientFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+}
+
+func TestGormIdempotencyStore(t *testing.T) {
+	_, db := newSQLiteRepository(t)
+	store := repository.NewGormIdempotencyStore(db)
+
+	now := time.Now()
+	record := models.IdempotencyKey{Key: "pedido-1", RequestHash: "abc", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	_, reserved, err := store.Reserve(record, 0)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+
+	// Enquanto a requisição original não termina, a chave fica em andamento
+	existing, reserved, err := store.Reserve(record, 0)
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+	assert.True(t, existing.InProgress())
+
+	assert.NoError(t, store.Complete("pedido-1", http.StatusOK, "application/json", []byte(`{"ok":true}`)))
+	existing, reserved, err = store.Reserve(record, 0)
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, http.StatusOK, existing.StatusCode)
+	assert.Equal(t, `{"ok":true}`, string(existing.Body))
+
+	// Depois da janela, a chave expira e pode ser reutilizada
+	later := now.Add(2 * time.Hour)
+	_, reserved, err = store.Reserve(models.IdempotencyKey{Key: "pedido-1", RequestHash: "def", CreatedAt: later, ExpiresAt: later.Add(time.Hour)}, 0)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+
+	// Liberar a chave permite repetir a requisição
+	assert.NoError(t, store.Release("pedido-1"))
+	_, reserved, err = store.Reserve(models.IdempotencyKey{Key: "pedido-1", RequestHash: "def", CreatedAt: later, ExpiresAt: later.Add(time.Hour)}, 0)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+}
+
+func TestIdempotencyStoreReplacesAbandonedReservation(t *testing.T) {
+	_, db := newSQLiteRepository(t)
+	stores := map[string]repository.IdempotencyStore{
+		"gorm":   repository.NewGormIdempotencyStore(db),
+		"memory": repository.NewMemoryIdempotencyStore(),
+	}
+	for name, store := range stores {
+		now := time.Now()
+		_, reserved, err := store.Reserve(models.IdempotencyKey{Key: "pedido-1", RequestHash: "abc", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}, time.Minute)
+		assert.NoError(t, err, name)
+		assert.True(t, reserved, name)
+
+		// Dentro do lease a reserva continua em andamento
+		soon := now.Add(30 * time.Second)
+		existing, reserved, err := store.Reserve(models.IdempotencyKey{Key: "pedido-1", RequestHash: "abc", CreatedAt: soon, ExpiresAt: soon.Add(time.Hour)}, time.Minute)
+		assert.NoError(t, err, name)
+		assert.False(t, reserved, name)
+		assert.True(t, existing.InProgress(), name)
+
+		// Depois do lease a reserva foi abandonada e é substituída
+		later := now.Add(2 * time.Minute)
+		_, reserved, err = store.Reserve(models.IdempotencyKey{Key: "pedido-1", RequestHash: "abc", CreatedAt: later, ExpiresAt: later.Add(time.Hour)}, time.Minute)
+		assert.NoError(t, err, name)
+		assert.True(t, reserved, name)
+
+		// Respostas concluídas não são afetadas pelo lease
+		assert.NoError(t, store.Complete("pedido-1", http.StatusOK, "application/json", []byte(`{}`)), name)
+		muchLater := now.Add(10 * time.Minute)
+		existing, reserved, err = store.Reserve(models.IdempotencyKey{Key: "pedido-1", RequestHash: "abc", CreatedAt: muchLater, ExpiresAt: muchLater.Add(time.Hour)}, time.Minute)
+		assert.NoError(t, err, name)
+		assert.False(t, reserved, name)
+		assert.Equal(t, http.StatusOK, existing.StatusCode, name)
+	}
+}
+
+// failingCompleteStore simula uma falha ao gravar a resposta da chave de idempotência.
+type failingCompleteStore struct {
+	repository.IdempotencyStore
+}
+
+func (failingCompleteStore) Complete(string, int, string, []byte) error {
+	return errors.New("falha ao gravar")
+}
+
+func TestIdempotencyKeyReleasedWhenCompleteFails(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	router := newTestRouterWithGeocoder(repo, geocoding.NewFake(), func(api *controller.APIController) {
+		api.Idempotency = failingCompleteStore{repository.NewMemoryIdempotencyStore()}
+	})
+
+	// Sem a resposta armazenada, a chave é liberada e a repetição é processada em vez de receber 409
+	for i := 0; i < 2; i++ {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, createRequest(t, validClient(), "pedido-123"))
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Empty(t, rr.Header().Get("Idempotent-Replayed"))
+	}
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"myapi/config"
+	"myapi/migrations"
+	"myapi/repository"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMigrationsOrdersByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_index.up.sql":      {Data: []byte("CREATE INDEX idx ON t (c);")},
+		"0002_add_index.down.sql":    {Data: []byte("DROP INDEX idx ON t;")},
+		"0001_create_table.up.sql":   {Data: []byte("CREATE TABLE t (c INT);")},
+		"0001_create_table.down.sql": {Data: []byte("DROP TABLE t;")},
+		"README.md":                  {Data: []byte("ignorado")},
+	}
+
+	list, err := migrations.Load(fsys)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, 1, list[0].Version)
+		assert.Equal(t, "create_table", list[0].Name)
+		assert.Equal(t, "DROP TABLE t;", list[0].Down)
+		assert.Equal(t, 2, list[1].Version)
+	}
+}
+
+func TestLoadMigrationsRejectsInvalidFiles(t *testing.T) {
+	// Migração sem o script down
+	_, err := migrations.Load(fstest.MapFS{
+		"0001_create_table.up.sql": {Data: []byte("CREATE TABLE t (c INT);")},
+	})
+	assert.Error(t, err)
+
+	// Nome fora do padrão NNNN_nome.(up|down).sql
+	_, err = migrations.Load(fstest.MapFS{
+		"create_table.up.sql": {Data: []byte("CREATE TABLE t (c INT);")},
+	})
+	assert.Error(t, err)
+}
+
+func TestMigratorUpDownOnSQLite(t *testing.T) {
+	db, err := config.OpenDB(config.DatabaseSettings{Backend: config.BackendSQLite, DSN: ":memory:"})
+	if err != nil {
+		t.Fatalf("Erro ao abrir o SQLite em memória: %v", err)
+	}
+	migrator, err := migrations.NewMigrator(db, config.BackendSQLite)
+	assert.NoError(t, err)
+
+	// Todas as migrações embutidas do SQLite são aplicadas
+	fsys, err := migrations.Files(config.BackendSQLite)
+	assert.NoError(t, err)
+	all, err := migrations.Load(fsys)
+	assert.NoError(t, err)
+	last := all[len(all)-1].Version
+
+	applied, err := migrator.Up()
+	assert.NoError(t, err)
+	assert.Len(t, applied, len(all))
+
+	// Aplicar novamente não deve fazer nada
+	applied, err = migrator.Up()
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+
+	// O schema criado pelas migrações atende o repositório GORM, inclusive o filtro por cidade
+	repo := repository.NewGormRepository(db)
+	client := validClient()
+	assert.NoError(t, repo.Create(&client))
+	clients, total, err := repo.List(repository.ClientFilter{City: client.City, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, clients, 1)
+
+	reverted, err := migrator.Down(1)
+	assert.NoError(t, err)
+	if assert.Len(t, reverted, 1) {
+		assert.Equal(t, last, reverted[0].Version)
+	}
+	pending, err := migrator.Pending()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+}
+
+func TestEmbeddedMigrationsForEveryDialect(t *testing.T) {
+	for _, dialect := range []string{config.BackendMySQL, config.BackendPostgres, config.BackendSQLite} {
+		fsys, err := migrations.Files(dialect)
+		if !assert.NoError(t, err, dialect) {
+			continue
+		}
+		list, err := migrations.Load(fsys)
+		assert.NoError(t, err, dialect)
+		assert.NotEmpty(t, list, dialect)
+		for i, m := range list {
+			assert.Equal(t, i+1, m.Version, "as versões de %s devem ser sequenciais", dialect)
+		}
+	}
+
+	_, err := migrations.Files(config.BackendMemory)
+	assert.Error(t, err)
+}
@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"myapi/config"
+	"myapi/controller"
+	"myapi/geocoding"
+	"myapi/models"
+	"myapi/repository"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withPostalCodes configura no controlador a consulta de CEP no gazetteer de exemplo do projeto.
+func withPostalCodes(t *testing.T, crossCheck bool) func(*controller.APIController) {
+	settings := config.DefaultSettings()
+	settings.PostalCode.Provider = geocoding.ProviderGazetteer
+	settings.Geocoding.GazetteerPath = "../gazetteer.example.csv"
+	lookup, err := config.NewPostalCodeLookup(settings.PostalCode, settings.Geocoding)
+	assert.NoError(t, err)
+
+	return func(api *controller.APIController) {
+		api.PostalCodes = lookup
+		api.PostalCodeCrossCheck = crossCheck
+	}
+}
+
+func TestViaCEPLookup(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/ws/01310100/json/" {
+			w.Write([]byte(`{"cep": "01310-100", "logradouro": "Avenida Paulista", "complemento": "de 612 a 1510 - lado par",
+				"bairro": "Bela Vista", "localidade": "São Paulo", "uf": "SP", "ibge": "3550308"}`))
+			return
+		}
+		w.Write([]byte(`{"erro": "true"}`))
+	}))
+	defer server.Close()
+
+	lookup := geocoding.NewViaCEP(server.URL+"/ws/", http.DefaultClient)
+	address, err := lookup.LookupPostalCode(context.Background(), "01310-100")
+	assert.NoError(t, err)
+	assert.Equal(t, models.PostalCodeAddress{PostalCode: "01310-100", Street: "Avenida Paulista", Neighborhood: "Bela Vista",
+		City: "São Paulo", State: "SP", Country: "Brasil"}, address)
+
+	// CEPs inexistentes são respondidos com o campo `erro`
+	_, err = lookup.LookupPostalCode(context.Background(), "99999999")
+	assert.True(t, errors.Is(err, geocoding.ErrNoResults), err)
+	assert.Equal(t, []string{"/ws/01310100/json/", "/ws/99999999/json/"}, paths)
+
+	for value, expected := range map[string]string{"01310100": "01310-100", " 01310-100 ": "01310-100", "01.310-100": "01310-100"} {
+		formatted, ok := geocoding.FormatPostalCode(value)
+		assert.True(t, ok, value)
+		assert.Equal(t, expected, formatted, value)
+	}
+	for _, value := range []string{"", "0131010", "013101000", "01310-10a", "01-310-100"} {
+		_, ok := geocoding.FormatPostalCode(value)
+		assert.False(t, ok, value)
+	}
+}
+
+func TestPostalCodeRoute(t *testing.T) {
+	router := newTestRouterWithGeocoder(repository.NewMemoryRepository(), geocoding.NewFake(), withPostalCodes(t, false))
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	// O endereço do CEP vem com os nomes de campo do cliente
+	rr := get("/deliveries/cep/22021001")
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var address map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &address))
+	assert.Equal(t, "22021-001", address["postal_code"])
+	assert.Equal(t, "Avenida Atlântica", address["street"])
+	assert.Equal(t, "Copacabana", address["neighborhood"])
+	assert.Equal(t, "Rio de Janeiro", address["city"])
+	assert.Equal(t, "RJ", address["state"])
+
+	assert.Equal(t, http.StatusNotFound, get("/deliveries/cep/99999-999").Code)
+
+	rr = get("/deliveries/cep/2202")
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Equal(t, "invalid", problemFields(decodeProblem(t, rr))["cep"])
+
+	// Sem a consulta de CEP configurada
+	rr = httptest.NewRecorder()
+	newTestRouter(repository.NewMemoryRepository()).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries/cep/22021001", nil))
+	assert.Equal(t, http.StatusBadGateway, rr.Code)
+}
+
+func TestCreateClientCrossChecksPostalCode(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	router := newTestRouterWithGeocoder(repo, geocoding.NewFake(), withPostalCodes(t, true))
+	create := func(client models.Client) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(client)
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/deliveries", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Cidade e estado conferem com o CEP, sem diferenciar acentos e a sigla do nome do estado
+	client := validClient()
+	client.PostalCode, client.City, client.State = "01310100", "sao paulo", "São Paulo"
+	rr := create(client)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	stored, err := repo.FindByID(uint(response["operationID"].(float64)))
+	assert.NoError(t, err)
+	assert.Equal(t, "01310-100", stored.PostalCode)
+
+	// Cidade e estado de outro CEP
+	client.City, client.State = "Rio de Janeiro", "RJ"
+	rr = create(client)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	fields := problemFields(decodeProblem(t, rr))
+	assert.Equal(t, "mismatch", fields["city"])
+	assert.Equal(t, "mismatch", fields["state"])
+
+	// Com campos inválidos, o CEP não é conferido e apenas os erros da validação são retornados
+	invalid := client
+	invalid.WeightKg = -1
+	rr = create(invalid)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	fields = problemFields(decodeProblem(t, rr))
+	assert.Contains(t, fields, "weight_kg")
+	assert.NotContains(t, fields, "city")
+	assert.NotContains(t, fields, "state")
+
+	// CEP inexistente e CEP malformado
+	client.PostalCode = "99999-999"
+	rr = create(client)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Equal(t, "not_found", problemFields(decodeProblem(t, rr))["postal_code"])
+
+	client.PostalCode = "9999"
+	rr = create(client)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Equal(t, "invalid", problemFields(decodeProblem(t, rr))["postal_code"])
+
+	// Provedores de consulta de CEP desconhecidos impedem a inicialização
+	t.Setenv("POSTAL_CODE_PROVIDER", "correios")
+	_, err = config.Load(nil)
+	assert.ErrorContains(t, err, `postal_code.provider desconhecido: "correios"`)
+}
@@ -0,0 +1,203 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"myapi/controller"
+	"myapi/geocoding"
+	"myapi/repository"
+	"myapi/services"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// nominatimResponse é uma resposta válida do Nominatim com um resultado.
+const nominatimResponse = `[{"lat": "-22.9068", "lon": "-43.1729", "display_name": "Rua Teste, Rio de Janeiro"}]`
+
+// flakyProvider simula um provedor que responde com os status informados, em ordem, e depois com 200.
+func flakyProvider(t *testing.T, calls *atomic.Int32, statuses ...int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(calls.Add(1))
+		if call <= len(statuses) {
+			if statuses[call-1] == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "0")
+			}
+			w.WriteHeader(statuses[call-1])
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(nominatimResponse))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newResilientNominatim cria um Nominatim apontando para o servidor de teste com o cliente resiliente.
+func newResilientNominatim(server *httptest.Server, policy geocoding.RetryPolicy, breaker *geocoding.Breaker) geocoding.Geocoder {
+	return geocoding.NewNominatim(server.URL+"/search", "myapi-testes", geocoding.NewResilientClient(policy, breaker))
+}
+
+// fastRetries é uma política de novas tentativas com intervalos curtos para os testes.
+var fastRetries = geocoding.RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestResilientClientRetriesTemporaryFailures(t *testing.T) {
+	// 5xx e 429 são repetidos até a política se esgotar
+	var calls atomic.Int32
+	geocoder := newResilientNominatim(flakyProvider(t, &calls, http.StatusServiceUnavailable, http.StatusTooManyRequests), fastRetries, nil)
+	results, err := geocoder.Geocode(context.Background(), "Rua Teste")
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// Sem tentativas restantes, a falha chega ao chamador como ErrUpstream
+	calls.Store(0)
+	geocoder = newResilientNominatim(flakyProvider(t, &calls, 500, 502, 503), fastRetries, nil)
+	_, err = geocoder.Geocode(context.Background(), "Rua Teste")
+	assert.True(t, errors.Is(err, geocoding.ErrUpstream), err)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// Erros 4xx (exceto 429) não são repetidos
+	calls.Store(0)
+	geocoder = newResilientNominatim(flakyProvider(t, &calls, http.StatusBadRequest), fastRetries, nil)
+	_, err = geocoder.Geocode(context.Background(), "Rua Teste")
+	assert.True(t, errors.Is(err, geocoding.ErrUpstream), err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestResilientClientAttemptTimeout(t *testing.T) {
+	// A primeira tentativa fica presa no provedor e é abandonada pelo prazo de cada tentativa
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			select {
+			case <-time.After(5 * time.Second):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(nominatimResponse))
+	}))
+	defer server.Close()
+
+	policy := fastRetries
+	policy.Timeout = 50 * time.Millisecond
+	geocoder := newResilientNominatim(server, policy, nil)
+
+	started := time.Now()
+	results, err := geocoder.Geocode(context.Background(), "Rua Teste")
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Less(t, time.Since(started), 2*time.Second)
+
+	// O prazo do contexto do chamador também interrompe as tentativas
+	calls.Store(0)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	policy.Timeout = 0
+	_, err = newResilientNominatim(server, policy, nil).Geocode(ctx, "Rua Teste")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestCircuitBreakerFailsFastAndRecovers(t *testing.T) {
+	var calls atomic.Int32
+	var failing atomic.Bool
+	failing.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(nominatimResponse))
+	}))
+	defer server.Close()
+
+	breaker := geocoding.NewBreaker(2, 100*time.Millisecond)
+	geocoder := newResilientNominatim(server, geocoding.RetryPolicy{}, breaker)
+	router := newTestRouterWithGeocoder(repository.NewMemoryRepository(), geocoder, func(api *controller.APIController) {
+		api.GeocodingBreaker = breaker
+	})
+
+	search := func() *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries/geoconding/search?endereco=Rua+Teste", nil))
+		return rr
+	}
+	health := func() controller.HealthResponse {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response controller.HealthResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		return response
+	}
+
+	status := health()
+	assert.Equal(t, "ok", status.Status)
+	assert.Equal(t, geocoding.BreakerClosed, status.Checks["geocoding"].CircuitBreaker.State)
+
+	// As falhas seguidas abrem o circuito
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusBadGateway, search().Code)
+	}
+	status = health()
+	assert.Equal(t, "degraded", status.Status)
+	if check := status.Checks["geocoding"]; assert.NotNil(t, check.CircuitBreaker) {
+		assert.Equal(t, "degraded", check.Status)
+		assert.Equal(t, geocoding.BreakerOpen, check.CircuitBreaker.State)
+		assert.Equal(t, 2, check.CircuitBreaker.ConsecutiveFailures)
+		assert.NotNil(t, check.CircuitBreaker.RetryAt)
+	}
+
+	// Com o circuito aberto, as buscas falham sem acessar o provedor
+	rr := search()
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "upstream_unavailable", decodeProblem(t, rr).Code)
+	_, err := geocoder.Geocode(context.Background(), "Rua Teste")
+	assert.True(t, errors.Is(err, services.ErrUnavailable), err)
+	assert.True(t, errors.Is(err, services.ErrUpstream), err)
+	assert.Equal(t, int32(2), calls.Load())
+
+	// Após o cooldown, a requisição de teste que falha reabre o circuito
+	time.Sleep(120 * time.Millisecond)
+	assert.Equal(t, http.StatusBadGateway, search().Code)
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, http.StatusServiceUnavailable, search().Code)
+
+	// Com o provedor recuperado, a requisição de teste fecha o circuito
+	failing.Store(false)
+	time.Sleep(120 * time.Millisecond)
+	assert.Equal(t, http.StatusOK, search().Code)
+	status = health()
+	assert.Equal(t, "ok", status.Status)
+	assert.Equal(t, geocoding.BreakerState{State: geocoding.BreakerClosed}, *status.Checks["geocoding"].CircuitBreaker)
+}
+
+func TestCircuitBreakerHalfOpenAllowsSingleProbe(t *testing.T) {
+	breaker := geocoding.NewBreaker(1, 10*time.Millisecond)
+	assert.NoError(t, breaker.Allow())
+	breaker.Failure()
+	assert.ErrorIs(t, breaker.Allow(), geocoding.ErrCircuitOpen)
+
+	time.Sleep(20 * time.Millisecond)
+	assert.NoError(t, breaker.Allow())
+	assert.Equal(t, geocoding.BreakerHalfOpen, breaker.State().State)
+	assert.ErrorIs(t, breaker.Allow(), geocoding.ErrCircuitOpen)
+
+	// Uma requisição de teste cancelada pelo chamador libera a próxima
+	breaker.Release()
+	assert.NoError(t, breaker.Allow())
+	breaker.Success()
+	assert.Equal(t, geocoding.BreakerClosed, breaker.State().State)
+	assert.NoError(t, breaker.Allow())
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"myapi/config"
+	"myapi/models"
+	"myapi/repository"
+	"myapi/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurgeArchivedClients(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	for i := 0; i < 3; i++ {
+		client := validClient()
+		assert.NoError(t, repo.Create(&client))
+	}
+	_, err := repo.ArchiveAll()
+	assert.NoError(t, err)
+
+	// Clientes arquivados agora ainda estão dentro do período de retenção
+	result, err := services.PurgeArchivedClients(repo, 30, time.Now(), false)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Count())
+
+	// Trinta e um dias depois, o dry-run reporta todos sem removê-los
+	later := time.Now().AddDate(0, 0, 31)
+	result, err = services.PurgeArchivedClients(repo, 30, later, true)
+	assert.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, 3, result.Count())
+	_, total, err := repo.ListArchived(repository.ClientFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+
+	result, err = services.PurgeArchivedClients(repo, 30, later, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.Count())
+	_, total, err = repo.ListArchived(repository.ClientFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+}
+
+func TestPurgeArchivedInBatches(t *testing.T) {
+	repo, db := newSQLiteRepository(t)
+	repo.ArchiveBatchSize = 2
+
+	now := time.Now()
+	for i := 1; i <= 5; i++ {
+		archived := models.ArchivedClient{ID: i, Name: "Cliente", DeletedAt: now.AddDate(0, 0, -i*10)}
+		assert.NoError(t, db.Table("archived_clients").Create(&archived).Error)
+	}
+
+	// Apenas os arquivados há mais de 25 dias (IDs 3, 4 e 5) são removidos, em lotes de dois
+	var batches []repository.PurgeBatch
+	purged, err := repo.PurgeArchived(now.AddDate(0, 0, -25), false, func(batch repository.PurgeBatch) {
+		batches = append(batches, batch)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, purged)
+	assert.Equal(t, []repository.PurgeBatch{{Count: 2, MinID: 3, MaxID: 4}, {Count: 1, MinID: 5, MaxID: 5}}, batches)
+
+	var remaining []int
+	assert.NoError(t, db.Table("archived_clients").Order("id").Pluck("id", &remaining).Error)
+	assert.Equal(t, []int{1, 2}, remaining)
+}
+
+func TestLoadRetentionSettings(t *testing.T) {
+	t.Setenv("RETENTION_INTERVAL", "1h")
+
+	settings, err := config.Load([]string{"-db-backend", config.BackendMemory, "-retention-days", "90"})
+	assert.NoError(t, err)
+	assert.True(t, settings.Retention.Enabled())
+	assert.Equal(t, 90, settings.Retention.ArchivedDays)
+	assert.Equal(t, time.Hour, settings.Retention.Interval)
+
+	_, err = config.Load([]string{"-db-backend", config.BackendMemory, "-retention-days", "-1"})
+	assert.ErrorContains(t, err, "retention.archived_days")
+}
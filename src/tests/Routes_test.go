@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"encoding/json"
+	"myapi/repository"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeliveryResourceRoutes(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	router := newTestRouter(repo)
+
+	client := validClient()
+	assert.NoError(t, repo.Create(&client))
+
+	// GET /deliveries/{id}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries/1", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("Deprecation"))
+	var response struct {
+		Client map[string]interface{} `json:"client"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, client.Name, response.Client["name"])
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries/99", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// PUT /deliveries/{id}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/deliveries/1", strings.NewReader(`{"name": "Cliente atualizado"}`)))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	updated, err := repo.FindByID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Cliente atualizado", updated.Name)
+
+	// DELETE /deliveries/{id}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/deliveries/1", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	_, err = repo.FindByID(1)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/deliveries/1", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestDeleteAllRequiresConfirmation(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	router := newTestRouter(repo)
+
+	for i := 0; i < 2; i++ {
+		client := validClient()
+		assert.NoError(t, repo.Create(&client))
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/deliveries/all", nil))
+	assert.Equal(t, http.StatusPreconditionRequired, rr.Code)
+	_, total, err := repo.List(repository.ClientFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+
+	req := httptest.NewRequest(http.MethodDelete, "/deliveries/all", nil)
+	req.Header.Set("X-Confirm-Delete-All", "true")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("X-Archived-Count"))
+}
+
+func TestLegacyQueryRoutesAreDeprecated(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	router := newTestRouter(repo)
+
+	client := validClient()
+	assert.NoError(t, repo.Create(&client))
+
+	requests := []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/deliveries?id=1", nil),
+		httptest.NewRequest(http.MethodPut, "/deliveries?id=1", strings.NewReader(`{"name": "Cliente legado"}`)),
+		httptest.NewRequest(http.MethodDelete, "/deliveries?id=1", nil),
+	}
+	for _, req := range requests {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code, req.Method)
+		assert.Equal(t, "true", rr.Header().Get("Deprecation"), req.Method)
+		assert.Equal(t, `</deliveries/1>; rel="successor-version"`, rr.Header().Get("Link"), req.Method)
+	}
+
+	// A listagem sem ?id= não é obsoleta
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("Deprecation"))
+}
@@ -0,0 +1,49 @@
+package tests
+
+import (
+	"myapi/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadSettingsPrecedence(t *testing.T) {
+	// Arquivo de configuração define porta, backend e timeout de geocoding
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := []byte("server:\n  port: 9000\ndatabase:\n  backend: memory\ngeocoding:\n  timeout: 3s\n")
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatalf("Erro ao criar arquivo de configuração: %v", err)
+	}
+
+	// Variável de ambiente sobrescreve o arquivo
+	t.Setenv("APP_PORT", "9100")
+	t.Setenv("GEOCODING_API_KEY", "chave-env")
+
+	// Flag sobrescreve a variável de ambiente
+	settings, err := config.Load([]string{"-config", path, "-geocoding-key", "chave-flag"})
+	assert.NoError(t, err)
+	assert.Equal(t, 9100, settings.Server.Port)
+	assert.Equal(t, config.BackendMemory, settings.Database.Backend)
+	assert.Equal(t, 3*time.Second, settings.Geocoding.Timeout)
+	assert.Equal(t, "chave-flag", settings.Geocoding.APIKey)
+	assert.Equal(t, ":9100", settings.Server.Addr())
+}
+
+func TestLoadSettingsValidation(t *testing.T) {
+	t.Setenv("APP_PORT", "70000")
+	t.Setenv("DB_BACKEND", "oracle")
+
+	_, err := config.Load(nil)
+	if assert.Error(t, err) {
+		// Todos os valores inválidos são reportados de uma só vez
+		assert.Contains(t, err.Error(), "server.port")
+		assert.Contains(t, err.Error(), "database.backend")
+	}
+
+	t.Setenv("APP_PORT", "abc")
+	_, err = config.Load(nil)
+	assert.ErrorContains(t, err, "APP_PORT")
+}
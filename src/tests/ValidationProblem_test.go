@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"myapi/controller"
+	"myapi/repository"
+	"myapi/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// decodeProblem verifica o Content-Type da resposta e decodifica o documento de problema.
+func decodeProblem(t *testing.T, rr *httptest.ResponseRecorder) controller.Problem {
+	t.Helper()
+	assert.Equal(t, controller.ProblemMediaType, rr.Header().Get("Content-Type"))
+
+	var problem controller.Problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Erro ao decodificar o documento de problema: %v", err)
+	}
+	return problem
+}
+
+// problemFields retorna os campos inválidos do problema, chaveados pelo caminho do campo.
+func problemFields(problem controller.Problem) map[string]string {
+	fields := map[string]string{}
+	for _, fieldError := range problem.Errors {
+		fields[fieldError.Field] = fieldError.Code
+	}
+	return fields
+}
+
+// problemField retorna o campo inválido do problema com o caminho informado.
+func problemField(problem controller.Problem, field string) services.FieldError {
+	for _, fieldError := range problem.Errors {
+		if fieldError.Field == field {
+			return fieldError
+		}
+	}
+	return services.FieldError{}
+}
+
+func TestValidateCommonClientFieldsReportsEveryField(t *testing.T) {
+	client := validClient()
+	client.Name = ""
+	client.WeightKg = -1
+	client.City = " "
+	client.Latitude = 91
+
+	err := services.ValidateCommonClientFields(client)
+
+	var validationErr *services.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Esperava um *services.ValidationError, recebeu %v", err)
+	}
+	assert.Len(t, validationErr.Errors, 4)
+	assert.Equal(t, services.CodeRequired, validationErr.Errors[0].Code)
+	assert.NoError(t, services.ValidateCommonClientFields(validClient()))
+}
+
+func TestCreateClientReturnsValidationProblem(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	router := newTestRouter(repo)
+
+	body := `{"name": "", "weight_kg": 0, "address": "Rua Teste, 123", "street": "Rua Teste", "number": 123,
+		"neighborhood": "Centro", "city": "Cidade", "state": "RJ", "country": "Brasil", "latitude": -22.6, "longitude": 200}`
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/deliveries", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	problem := decodeProblem(t, rr)
+	assert.Equal(t, http.StatusUnprocessableEntity, problem.Status)
+	assert.Equal(t, "/deliveries", problem.Instance)
+	assert.Equal(t, map[string]string{
+		"name":      services.CodeRequired,
+		"weight_kg": services.CodeMustBePositive,
+		"longitude": services.CodeOutOfRange,
+	}, problemFields(problem))
+
+	clients, _, err := repo.List(repository.ClientFilter{Limit: 10})
+	assert.NoError(t, err)
+	assert.Empty(t, clients)
+}
+
+func TestUpdateAndPatchReturnValidationProblem(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	router := newTestRouter(repo)
+	client := validClient()
+	assert.NoError(t, repo.Create(&client))
+
+	// PUT com valores fora do intervalo
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/deliveries/1", strings.NewReader(`{"weight_kg": -5, "latitude": -95}`)))
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Equal(t, map[string]string{
+		"weight_kg": services.CodeMustBePositive,
+		"latitude":  services.CodeOutOfRange,
+	}, problemFields(decodeProblem(t, rr)))
+
+	// PATCH que limpa dois campos obrigatórios
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, patchRequest("/deliveries/1", "application/merge-patch+json", `{"city": null, "state": ""}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Equal(t, map[string]string{
+		"city":  services.CodeRequired,
+		"state": services.CodeRequired,
+	}, problemFields(decodeProblem(t, rr)))
+
+	unchanged, err := repo.FindByID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, client.WeightKg, unchanged.WeightKg)
+	assert.Equal(t, client.City, unchanged.City)
+}
+
+func TestInvalidRequestsReturnProblem(t *testing.T) {
+	router := newTestRouter(repository.NewMemoryRepository())
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		field  string
+	}{
+		{"JSON malformado", http.MethodPost, "/deliveries", `{`, http.StatusBadRequest, ""},
+		{"ID inválido na listagem", http.MethodGet, "/deliveries?id=abc", "", http.StatusBadRequest, "id"},
+		{"endereço ausente", http.MethodGet, "/deliveries/geoconding/search", "", http.StatusBadRequest, "endereco"},
+		{"exclusão total sem confirmação", http.MethodDelete, "/deliveries/all", "", http.StatusPreconditionRequired, "X-Confirm-Delete-All"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.status, rr.Code)
+			problem := decodeProblem(t, rr)
+			assert.Equal(t, tt.status, problem.Status)
+			if tt.field != "" {
+				assert.Contains(t, problemFields(problem), tt.field)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"myapi/config"
+	"myapi/controller"
+	"myapi/geocoding"
+	"myapi/migrations"
+	"myapi/repository"
+	"os"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// loadTestSettings carrega a configuração dos testes através de config.Load.
+// Por padrão os testes utilizam o armazenamento em memória; para executá-los contra o MySQL
+// do docker-compose, defina `DB_BACKEND=mysql` (e, opcionalmente, `DB_DSN`). Nesse caso o schema é
+// criado pelo AutoMigrate do GORM.
+func loadTestSettings(t *testing.T) config.Settings {
+	t.Helper()
+
+	if os.Getenv("DB_BACKEND") == "" {
+		t.Setenv("DB_BACKEND", config.BackendMemory)
+	}
+	settings, err := config.Load([]string{"-db-auto-migrate"})
+	if err != nil {
+		t.Fatalf("Erro ao carregar a configuração de testes: %v", err)
+	}
+	return settings
+}
+
+// newTestRepository cria o repositório usado pelos testes, com o backend definido por loadTestSettings.
+func newTestRepository(t *testing.T) repository.DeliveryRepository {
+	t.Helper()
+
+	repo, err := config.NewRepository(loadTestSettings(t).Database)
+	if err != nil {
+		t.Fatalf("Erro ao criar o repositório de testes: %v", err)
+	}
+	return repo
+}
+
+// newSQLiteRepository cria um repositório GORM sobre um SQLite em memória com todas as migrações aplicadas.
+// É usado nos testes que dependem de comportamento transacional do banco de dados.
+func newSQLiteRepository(t *testing.T) (*repository.GormRepository, *gorm.DB) {
+	t.Helper()
+
+	db, err := config.OpenDB(config.DatabaseSettings{Backend: config.BackendSQLite, DSN: ":memory:"})
+	if err != nil {
+		t.Fatalf("Erro ao abrir o SQLite em memória: %v", err)
+	}
+	migrator, err := migrations.NewMigrator(db, config.BackendSQLite)
+	if err != nil {
+		t.Fatalf("Erro ao carregar as migrações: %v", err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("Erro ao aplicar as migrações: %v", err)
+	}
+	return repository.NewGormRepository(db), db
+}
+
+// newTestRouter registra as rotas da API sobre o repositório informado, com o provedor de geocoding local.
+func newTestRouter(repo repository.DeliveryRepository) *mux.Router {
+	return newTestRouterWithGeocoder(repo, geocoding.NewFake())
+}
+
+// newTestRouterWithGeocoder registra as rotas da API sobre o repositório e o provedor de geocoding informados.
+// As opções ajustam o controlador (por exemplo, o cache de geocoding ou a consulta de CEP) antes do registro
+// das rotas.
+func newTestRouterWithGeocoder(repo repository.DeliveryRepository, geocoder geocoding.Geocoder, options ...func(*controller.APIController)) *mux.Router {
+	api := controller.NewAPIController(repo, geocoder)
+	for _, option := range options {
+		option(api)
+	}
+	router := mux.NewRouter()
+	router.Use(controller.RequestID)
+	api.RegisterRoutes(router)
+	return router
+}